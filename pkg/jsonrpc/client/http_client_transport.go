@@ -1,58 +1,308 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/nalbion/go-mcp/pkg/jsonrpc"
-	ybjsonrpc "github.com/ybbus/jsonrpc"
 )
 
+// Client transport for plain request/response HTTP: each message is POSTed to the endpoint and any
+// JSON-RPC responses in the body of the HTTP response are passed to OnMessage, so that Protocol can route
+// them to the waiting requests.
+// If the POST fails, or the server does not respond to a request, the request receives a JSONRPCError.
 type HttpClientTransport struct {
 	jsonrpc.BaseTransport
-	// endpoint string // eg: "http://my-rpc-service:8080/rpc"
-	ctx    context.Context
-	client ybjsonrpc.RPCClient
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	client   *http.Client
+	endpoint string // eg: "http://my-rpc-service:8080/rpc"
+	headers  http.Header
+
+	// messages sent within batchWindow of each other are POSTed together as a JSON-RPC batch
+	batchWindow  time.Duration
+	maxBatchSize int
+	batch        []jsonrpc.JSONRPCMessage
+	batchTimer   *time.Timer
+
+	closed bool
+	job    *sync.WaitGroup
+	mu     sync.Mutex
 }
 
 func NewHttpClientTransport(ctx context.Context, endpoint string) *HttpClientTransport {
+	ctx, cancel := context.WithCancel(ctx)
+
 	return &HttpClientTransport{
-		ctx:    ctx,
-		client: ybjsonrpc.NewClient(endpoint),
+		ctx:      ctx,
+		cancel:   cancel,
+		client:   http.DefaultClient,
+		endpoint: endpoint,
+		headers:  http.Header{},
+		job:      &sync.WaitGroup{},
 	}
 }
 
+// WithHTTPClient sets the client used to POST messages, defaults to http.DefaultClient.
+func (c *HttpClientTransport) WithHTTPClient(client *http.Client) *HttpClientTransport {
+	c.client = client
+	return c
+}
+
+// WithHeader adds a header to be sent with every POST, eg: "Authorization".
+func (c *HttpClientTransport) WithHeader(key string, value string) *HttpClientTransport {
+	c.headers.Add(key, value)
+	return c
+}
+
+// WithBatching delays each message by up to `window` so that messages sent concurrently can be POSTed as a single
+// JSON-RPC batch. The batch is sent early if it reaches `maxBatchSize` messages, unless `maxBatchSize` is 0.
+func (c *HttpClientTransport) WithBatching(window time.Duration, maxBatchSize int) *HttpClientTransport {
+	c.batchWindow = window
+	c.maxBatchSize = maxBatchSize
+	return c
+}
+
 func (c *HttpClientTransport) Start() error {
 	return nil
 }
 
-// the response is parsed by Protocol.SendRequest() calling parseResponse()
-func (c *HttpClientTransport) Send(message ybjsonrpc.RPCRequest) error {
-	response, err := c.client.Call(message.Method, message.Params)
-	if err != nil {
+// Send POSTs the message to the endpoint, or adds it to the current batch if batching is enabled.
+// Responses to requests are passed to OnMessage before Send returns, unless batching is enabled.
+// Notifications expect no response: the server may reply with 202 Accepted or 204 No Content and any body is ignored.
+func (c *HttpClientTransport) Send(message jsonrpc.JSONRPCMessage) error {
+	if c.batchWindow > 0 {
+		return c.enqueue(message)
+	}
+
+	if err := c.ctx.Err(); err != nil {
 		return err
 	}
+	return c.post([]jsonrpc.JSONRPCMessage{message})
+}
+
+func (c *HttpClientTransport) enqueue(message jsonrpc.JSONRPCMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return errors.New("HttpClientTransport is closed")
+	}
+
+	c.batch = append(c.batch, message)
+	if c.maxBatchSize > 0 && len(c.batch) >= c.maxBatchSize {
+		c.flushLocked()
+	} else if c.batchTimer == nil {
+		c.batchTimer = time.AfterFunc(c.batchWindow, c.flush)
+	}
+	return nil
+}
+
+func (c *HttpClientTransport) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+}
 
-	if response.Error != nil {
-		c.OnError(jsonrpc.NewJSONRPCErrorError(
-			jsonrpc.RequestId(response.ID),
-			jsonrpc.ErrorCode(response.Error.Code),
-			response.Error.Message,
-			response.Error.Data,
-		))
+func (c *HttpClientTransport) flushLocked() {
+	if c.batchTimer != nil {
+		c.batchTimer.Stop()
+		c.batchTimer = nil
+	}
+
+	batch := c.batch
+	c.batch = nil
+	if len(batch) == 0 {
+		return
+	}
+
+	c.job.Add(1)
+	go func() {
+		defer c.job.Done()
+		if err := c.post(batch); err != nil {
+			c.onError(err)
+		}
+	}()
+}
+
+// post sends the messages, as a batch if there is more than one, and dispatches the responses.
+// Failures are routed to any requests in the batch, otherwise they are returned.
+func (c *HttpClientTransport) post(messages []jsonrpc.JSONRPCMessage) error {
+	var body []byte
+	var err error
+	if len(messages) == 1 {
+		body, err = json.Marshal(messages[0])
 	} else {
-		responseMessage := jsonrpc.JSONRPCResponse{
-			Id: jsonrpc.RequestId(response.ID),
-			Result: jsonrpc.Result{
-				AdditionalProperties: response.Result,
-			},
+		body, err = json.Marshal(messages)
+	}
+	if err != nil {
+		return err
+	}
+
+	pending := make(map[jsonrpc.RequestId]bool)
+	for _, message := range messages {
+		if id, ok := requestId(message); ok {
+			pending[id] = true
+		}
+	}
+
+	hasRequests := len(pending) > 0
+
+	// failed reports the error to each request still waiting for a response, or returns it if there were no requests.
+	failed := func(code jsonrpc.ErrorCode, message string, data any) error {
+		if !hasRequests {
+			return fmt.Errorf("HTTP JSON-RPC POST failed: %s", message)
 		}
+		for id := range pending {
+			c.onMessage(jsonrpc.NewJSONRPCError(id, *jsonrpc.NewJSONRPCErrorError(id, code, message, data)))
+		}
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return failed(jsonrpc.ConnectionClosed, err.Error(), nil)
+	}
+	defer resp.Body.Close()
 
-		c.OnMessage(responseMessage)
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return failed(jsonrpc.ConnectionClosed, err.Error(), nil)
 	}
 
+	// servers may respond with a JSON-RPC error along with a 4xx or 5xx status
+	responses, parseErr := parseResponses(responseBody)
+	for _, response := range responses {
+		if id, ok := responseId(response); ok {
+			delete(pending, id)
+		}
+		c.onMessage(response)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return failed(jsonrpc.InternalError, "HTTP "+resp.Status, string(responseBody))
+	}
+	if parseErr != nil {
+		return failed(jsonrpc.ParseError, fmt.Sprintf("invalid JSON-RPC response: %v", parseErr), string(responseBody))
+	}
+	if len(pending) > 0 {
+		return failed(jsonrpc.InternalError, "no response received for request", nil)
+	}
 	return nil
 }
 
+// parseResponses parses the body of an HTTP response, which may be empty, a single message or a batch.
+func parseResponses(body []byte) ([]jsonrpc.JSONRPCMessage, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	if body[0] != '[' {
+		message, err := jsonrpc.ParseJSONRPCMessage(body)
+		if err != nil {
+			return nil, err
+		}
+		return []jsonrpc.JSONRPCMessage{message}, nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, err
+	}
+
+	messages := make([]jsonrpc.JSONRPCMessage, 0, len(batch))
+	for _, raw := range batch {
+		message, err := jsonrpc.ParseJSONRPCMessage(raw)
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func requestId(message jsonrpc.JSONRPCMessage) (jsonrpc.RequestId, bool) {
+	switch message := message.(type) {
+	case jsonrpc.JSONRPCRequest:
+		return message.Id, true
+	case *jsonrpc.JSONRPCRequest:
+		return message.Id, true
+	}
+	return 0, false
+}
+
+func responseId(message jsonrpc.JSONRPCMessage) (jsonrpc.RequestId, bool) {
+	switch message := message.(type) {
+	case jsonrpc.JSONRPCResponse:
+		return message.Id, true
+	case *jsonrpc.JSONRPCResponse:
+		return message.Id, true
+	case jsonrpc.JSONRPCError:
+		return message.Id, true
+	case *jsonrpc.JSONRPCError:
+		return message.Id, true
+	}
+	return 0, false
+}
+
+// Close discards any messages waiting to be batched and waits for POSTs in progress to complete.
+// Requests in the discarded batch receive a JSONRPCError, as they do when a POST fails.
 func (c *HttpClientTransport) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	if c.batchTimer != nil {
+		c.batchTimer.Stop()
+		c.batchTimer = nil
+	}
+	discarded := c.batch
+	c.batch = nil
+	c.mu.Unlock()
+
+	for _, message := range discarded {
+		if id, ok := requestId(message); ok {
+			c.onMessage(jsonrpc.NewJSONRPCError(id, *jsonrpc.NewJSONRPCErrorError(id, jsonrpc.ConnectionClosed, "transport closed before the request was sent", nil)))
+		}
+	}
+
+	c.cancel()
+	c.job.Wait()
+
+	if c.OnClose != nil {
+		c.OnClose()
+	}
 	return nil
 }
+
+func (c *HttpClientTransport) onMessage(message jsonrpc.JSONRPCMessage) {
+	if c.OnMessage != nil {
+		c.OnMessage(message)
+	}
+}
+
+func (c *HttpClientTransport) onError(err error) {
+	if c.OnError != nil {
+		c.OnError(err)
+	}
+}
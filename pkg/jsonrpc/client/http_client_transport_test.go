@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpClientTransport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should route the response to the waiting request", func(t *testing.T) {
+		// given a server which echoes the params of the request
+		var authorization string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			var request map[string]any
+			json.NewDecoder(r.Body).Decode(&request)
+			json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": request["id"], "result": request["params"]})
+		}))
+		defer server.Close()
+
+		transport := NewHttpClientTransport(ctx, server.URL).WithHeader("Authorization", "Bearer token")
+		protocol := jsonrpc.NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, transport))
+
+		// when
+		result := &jsonrpc.Result{AdditionalProperties: &map[string]any{}}
+		err := protocol.SendRequest(ctx, "echo", &jsonrpc.JSONRPCRequestParams{
+			AdditionalProperties: map[string]any{"message": "hello"},
+		}, result)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"message": "hello"}, *result.AdditionalProperties.(*map[string]any))
+		assert.Equal(t, "Bearer token", authorization)
+	})
+
+	t.Run("should route JSON-RPC errors to the waiting request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"Invalid params"}}`))
+		}))
+		defer server.Close()
+
+		protocol := jsonrpc.NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, NewHttpClientTransport(ctx, server.URL)))
+
+		err := protocol.SendRequest(ctx, "echo", nil, nil)
+
+		var jsonrpcErr *jsonrpc.JSONRPCErrorError
+		require.ErrorAs(t, err, &jsonrpcErr)
+		assert.Equal(t, int(jsonrpc.InvalidParams), jsonrpcErr.Code)
+	})
+
+	t.Run("should route HTTP failures to the waiting request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "oops", http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		protocol := jsonrpc.NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, NewHttpClientTransport(ctx, server.URL)))
+
+		err := protocol.SendRequest(ctx, "echo", nil, nil)
+
+		var jsonrpcErr *jsonrpc.JSONRPCErrorError
+		require.ErrorAs(t, err, &jsonrpcErr)
+		assert.Equal(t, int(jsonrpc.InternalError), jsonrpcErr.Code)
+		assert.Equal(t, "HTTP 500 Internal Server Error", jsonrpcErr.Message)
+	})
+
+	t.Run("should send notifications without expecting a response", func(t *testing.T) {
+		status := http.StatusAccepted
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		defer server.Close()
+
+		transport := NewHttpClientTransport(ctx, server.URL)
+		transport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
+			t.Errorf("unexpected message: %v", message)
+		})
+
+		err := transport.Send(jsonrpc.NewJSONRPCNotification("notifications/initialized", nil))
+		assert.NoError(t, err)
+
+		status = http.StatusInternalServerError
+		err = transport.Send(jsonrpc.NewJSONRPCNotification("notifications/initialized", nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("should batch concurrent requests", func(t *testing.T) {
+		// given a server which responds to batches in reverse order
+		var mu sync.Mutex
+		var posts []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			posts = append(posts, string(body))
+			mu.Unlock()
+
+			var batch []map[string]any
+			require.NoError(t, json.Unmarshal(body, &batch))
+			var responses []map[string]any
+			for i := len(batch) - 1; i >= 0; i-- {
+				if id, ok := batch[i]["id"]; ok {
+					responses = append(responses, map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{}})
+				}
+			}
+			json.NewEncoder(w).Encode(responses)
+		}))
+		defer server.Close()
+
+		transport := NewHttpClientTransport(ctx, server.URL).WithBatching(time.Hour, 3)
+		defer transport.Close()
+		responses := make(chan jsonrpc.JSONRPCMessage, 3)
+		transport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
+			responses <- message
+		})
+
+		// when
+		require.NoError(t, transport.Send(&jsonrpc.JSONRPCRequest{Jsonrpc: "2.0", Id: 1, Method: "a"}))
+		require.NoError(t, transport.Send(jsonrpc.NewJSONRPCNotification("b", nil)))
+		require.NoError(t, transport.Send(&jsonrpc.JSONRPCRequest{Jsonrpc: "2.0", Id: 2, Method: "c"}))
+
+		// then
+		var ids []jsonrpc.RequestId
+		for range 2 {
+			select {
+			case message := <-responses:
+				ids = append(ids, message.(jsonrpc.JSONRPCResponse).Id)
+			case <-time.After(2 * time.Second):
+				t.Fatal("timed out waiting for response")
+			}
+		}
+		assert.ElementsMatch(t, []jsonrpc.RequestId{1, 2}, ids)
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, posts, 1)
+		assert.JSONEq(t, `[{"jsonrpc":"2.0","id":1,"method":"a"},{"jsonrpc":"2.0","method":"b"},{"jsonrpc":"2.0","id":2,"method":"c"}]`, posts[0])
+	})
+
+	t.Run("should send a batch after the window", func(t *testing.T) {
+		received := make(chan string, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received <- string(body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		transport := NewHttpClientTransport(ctx, server.URL).WithBatching(10*time.Millisecond, 0)
+		defer transport.Close()
+
+		require.NoError(t, transport.Send(jsonrpc.NewJSONRPCNotification("a", nil)))
+
+		select {
+		case body := <-received:
+			assert.JSONEq(t, `{"jsonrpc":"2.0","method":"a"}`, body)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for batch")
+		}
+	})
+	t.Run("should route an error to requests discarded from the batch on Close", func(t *testing.T) {
+		// given a batch which is waiting to be sent
+		posted := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			posted = true
+		}))
+		defer server.Close()
+
+		transport := NewHttpClientTransport(ctx, server.URL).WithBatching(time.Hour, 0)
+		var messages []jsonrpc.JSONRPCMessage
+		transport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
+			messages = append(messages, message)
+		})
+
+		require.NoError(t, transport.Send(&jsonrpc.JSONRPCRequest{Jsonrpc: "2.0", Id: 1, Method: "a"}))
+		require.NoError(t, transport.Send(jsonrpc.NewJSONRPCNotification("b", nil)))
+
+		// when
+		err := transport.Close()
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, posted)
+		require.Len(t, messages, 1)
+		jsonrpcErr := messages[0].(*jsonrpc.JSONRPCError)
+		assert.Equal(t, jsonrpc.RequestId(1), jsonrpcErr.Id)
+		assert.Equal(t, int(jsonrpc.ConnectionClosed), jsonrpcErr.Error.Code)
+	})
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+)
+
+// Client transport for TCP and Unix domain sockets, exchanging newline-delimited JSON-RPC messages.
+type SocketClientTransport struct {
+	jsonrpc.BaseTransport
+	ctx       context.Context
+	network   string // "tcp", "tcp4", "tcp6" or "unix"
+	address   string // eg: "localhost:8080" or "/run/my-mcp-server.sock"
+	dialer    *net.Dialer
+	transport *jsonrpc.ConnTransport
+	mu        sync.Mutex
+}
+
+func NewSocketClientTransport(ctx context.Context, network string, address string) *SocketClientTransport {
+	return &SocketClientTransport{
+		ctx:     ctx,
+		network: network,
+		address: address,
+		dialer:  &net.Dialer{},
+	}
+}
+
+// WithDialer sets the dialer used to connect, eg: to set a timeout or keep-alive.
+func (t *SocketClientTransport) WithDialer(dialer *net.Dialer) *SocketClientTransport {
+	t.dialer = dialer
+	return t
+}
+
+// Start connects to the socket and starts reading messages.
+func (t *SocketClientTransport) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.transport != nil {
+		return errors.New("SocketClientTransport already started")
+	}
+
+	conn, err := t.dialer.DialContext(t.ctx, t.network, t.address)
+	if err != nil {
+		return err
+	}
+
+	t.transport = jsonrpc.NewConnTransport(t.ctx, conn)
+	t.transport.SetOnClose(t.OnClose)
+	t.transport.SetOnError(t.OnError)
+	t.transport.SetOnMessage(t.OnMessage)
+	return t.transport.Start()
+}
+
+// the callbacks may be replaced after Start(), eg: by mcp/shared.Protocol.Connect()
+
+func (t *SocketClientTransport) SetOnClose(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnClose = f
+	if t.transport != nil {
+		t.transport.SetOnClose(f)
+	}
+}
+
+func (t *SocketClientTransport) SetOnError(f func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnError = f
+	if t.transport != nil {
+		t.transport.SetOnError(f)
+	}
+}
+
+func (t *SocketClientTransport) SetOnMessage(f func(message jsonrpc.JSONRPCMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnMessage = f
+	if t.transport != nil {
+		t.transport.SetOnMessage(f)
+	}
+}
+
+func (t *SocketClientTransport) Send(message jsonrpc.JSONRPCMessage) error {
+	t.mu.Lock()
+	transport := t.transport
+	t.mu.Unlock()
+	if transport == nil {
+		return errors.New("transport not started")
+	}
+	return transport.Send(message)
+}
+
+func (t *SocketClientTransport) Close() error {
+	t.mu.Lock()
+	transport := t.transport
+	t.mu.Unlock()
+	if transport == nil {
+		return nil
+	}
+	return transport.Close()
+}
@@ -27,11 +27,14 @@ type SSEClientTransport struct {
 	requestBuilder   func(req *http.Request)
 
 	initiated bool
-	session   sse.ClientSSESession
+	session   *sse.ClientSSESession
 	job       *sync.WaitGroup
 
-	endpoint chan string
-	baseUrl  string
+	// the server sends the endpoint for POSTing messages as the first event on each connection
+	endpointReceived chan struct{}
+	endpoint         string
+	startErr         chan error
+	mu               sync.Mutex
 }
 
 func NewDefaultSSEClientTransport(ctx context.Context, url string, reconnectionTime time.Duration) (*SSEClientTransport, error) {
@@ -55,19 +58,24 @@ func NewSSEClientTransport(ctx context.Context, client *http.Client, urlString s
 		url:              parsedUrl,
 		reconnectionTime: reconnectionTime,
 		requestBuilder:   requestBuilder,
-		endpoint:         make(chan string, 1),
+		endpointReceived: make(chan struct{}),
+		startErr:         make(chan error, 1),
 		job:              &sync.WaitGroup{},
 	}, nil
 }
 
+// Start connects to the SSE stream and blocks until the server has sent the `endpoint` event.
+// If the stream is interrupted, the session reconnects with the Last-Event-ID of the last message received.
 func (s *SSEClientTransport) Start() error {
 	if s.initiated {
 		return errors.New("SSEClientTransport already started")
 	}
 	s.initiated = true
 
-	// s.session = NewClientSSESession(s.client, s.url, s.reconnectionTime, s.requestBuilder)
-	s.baseUrl = s.url.String()
+	session := sse.NewClientSSESession(s.client, s.url, s.reconnectionTime, s.requestBuilder)
+	s.mu.Lock()
+	s.session = session
+	s.mu.Unlock()
 
 	s.job.Add(1)
 	go func() {
@@ -76,30 +84,17 @@ func (s *SSEClientTransport) Start() error {
 			select {
 			case <-s.ctx.Done():
 				return
-			case event := <-s.session.Incoming():
-				switch string(event.Event) {
-				case "error":
-					err := fmt.Errorf("error receiving SSE error: %s", event.Data)
-					s.BaseTransport.OnError(err)
+			case err := <-session.Errors():
+				err = fmt.Errorf("SSE connection failed: %w", err)
+				s.startErr <- err
+				s.onError(err)
+				s.Close()
+				return
+			case event, ok := <-session.Incoming():
+				if !ok {
 					return
-				case "open":
-					// The connection is open, but we need to wait for the endpoint to be received.
-				case "endpoint":
-					endpointUrl, err := url.Parse(s.baseUrl + "/" + string(event.Data))
-					if err != nil {
-						s.BaseTransport.OnError(err)
-						s.Close()
-						return
-					}
-					s.endpoint <- endpointUrl.String()
-				default:
-					var message jsonrpc.JSONRPCMessage
-					if err := json.Unmarshal([]byte(event.Data), &message); err != nil {
-						s.BaseTransport.OnError(err)
-					} else {
-						s.BaseTransport.OnMessage(message)
-					}
 				}
+				s.handleEvent(event)
 			}
 		}
 	}()
@@ -107,40 +102,100 @@ func (s *SSEClientTransport) Start() error {
 	select {
 	case <-s.ctx.Done():
 		return s.ctx.Err()
-	case <-s.endpoint:
+	case err := <-s.startErr:
+		return err
+	case <-s.endpointReceived:
 		return nil
 	}
 }
 
-func (s *SSEClientTransport) Send(message jsonrpc.JSONRPCMessage) error {
-	select {
-	case <-s.ctx.Done():
-		return s.ctx.Err()
-	case endpoint := <-s.endpoint:
-		body, err := json.Marshal(message)
+func (s *SSEClientTransport) handleEvent(event *sse.ServerSentEvent) {
+	var data string
+	if event.Data != nil {
+		data = *event.Data
+	}
+
+	switch *event.Event {
+	case "endpoint":
+		endpointUrl, err := url.Parse(data)
 		if err != nil {
-			return err
+			s.onError(fmt.Errorf("invalid endpoint received from SSE server: %w", err))
+			return
 		}
 
-		req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, endpoint, bytes.NewBuffer(body))
+		// the endpoint may be relative to the SSE URL, and may change when we reconnect
+		s.mu.Lock()
+		first := s.endpoint == ""
+		s.endpoint = s.url.ResolveReference(endpointUrl).String()
+		s.mu.Unlock()
+
+		if first {
+			close(s.endpointReceived)
+		}
+	case "message":
+		message, err := jsonrpc.ParseJSONRPCMessage([]byte(data))
 		if err != nil {
-			return err
+			s.onError(fmt.Errorf("failed to parse SSE message: %w", err))
+			return
 		}
-		req.Header.Set("Content-Type", "application/json")
+		if s.BaseTransport.OnMessage != nil {
+			s.BaseTransport.OnMessage(message)
+		}
+	default:
+		jsonrpc.Logger.Printf("ignoring SSE event of type %s\n", *event.Event)
+	}
+}
+
+// Endpoint returns the URL that messages are POSTed to, once the server has sent it.
+func (s *SSEClientTransport) Endpoint() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.endpoint
+}
+
+// LastEventID returns the ID of the last event received from the server.
+func (s *SSEClientTransport) LastEventID() string {
+	s.mu.Lock()
+	session := s.session
+	s.mu.Unlock()
+	if session == nil {
+		return ""
+	}
+	return session.LastEventID()
+}
+
+func (s *SSEClientTransport) Send(message jsonrpc.JSONRPCMessage) error {
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	case <-s.endpointReceived:
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.Endpoint(), bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.requestBuilder != nil {
 		s.requestBuilder(req)
+	}
 
-		resp, err := s.client.Do(req)
-		if err != nil {
-			s.BaseTransport.OnError(err)
-			return err
-		}
-		defer resp.Body.Close()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.onError(err)
+		return err
+	}
+	defer resp.Body.Close()
 
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			return errors.New("Error POSTing to endpoint: " + resp.Status)
-		}
-		return nil
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New("Error POSTing to endpoint: " + resp.Status)
 	}
+	return nil
 }
 
 func (s *SSEClientTransport) Close() error {
@@ -148,12 +203,27 @@ func (s *SSEClientTransport) Close() error {
 		return errors.New("SSEClientTransport is not initialized")
 	}
 
-	s.session.Close()
-	s.BaseTransport.OnClose()
-	s.job.Wait()
+	s.mu.Lock()
+	session := s.session
+	s.session = nil
+	s.mu.Unlock()
+	if session == nil {
+		return nil
+	}
+
+	session.Close()
+	if s.BaseTransport.OnClose != nil {
+		s.BaseTransport.OnClose()
+	}
 	return nil
 }
 
+func (s *SSEClientTransport) onError(err error) {
+	if s.BaseTransport.OnError != nil {
+		s.BaseTransport.OnError(err)
+	}
+}
+
 func (s *SSEClientTransport) OnClose(block func()) {
 	old := s.BaseTransport.OnClose
 	s.BaseTransport.OnClose = func() {
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,26 +9,109 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/nalbion/go-mcp/pkg/jsonrpc"
 	"github.com/nalbion/go-mcp/pkg/mcp/shared"
 )
 
+const (
+	DEFAULT_CLOSE_TIMEOUT     = 2 * time.Second
+	DEFAULT_TERMINATE_TIMEOUT = 2 * time.Second
+	DEFAULT_OUTPUT_TIMEOUT    = time.Second
+)
+
+// DEFAULT_INHERITED_ENV_VARS are the environment variables inherited by default,
+// so that the server can run without being given secrets such as API keys from the client's environment.
+var DEFAULT_INHERITED_ENV_VARS = defaultInheritedEnvVars()
+
+func defaultInheritedEnvVars() []string {
+	if runtime.GOOS == "windows" {
+		return []string{
+			"APPDATA", "HOMEDRIVE", "HOMEPATH", "LOCALAPPDATA", "PATH", "PROCESSOR_ARCHITECTURE",
+			"SYSTEMDRIVE", "SYSTEMROOT", "TEMP", "USERNAME", "USERPROFILE",
+		}
+	}
+	return []string{"HOME", "LOGNAME", "PATH", "SHELL", "TERM", "USER"}
+}
+
+// GetDefaultEnvironment returns the values of DEFAULT_INHERITED_ENV_VARS from the current environment.
+func GetDefaultEnvironment() map[string]string {
+	env := make(map[string]string)
+	for _, key := range DEFAULT_INHERITED_ENV_VARS {
+		value, ok := os.LookupEnv(key)
+		if !ok || strings.HasPrefix(value, "()") {
+			// skip functions, which are a security risk
+			continue
+		}
+		env[key] = value
+	}
+	return env
+}
+
 type StdioServerParameters struct {
 	Command string
 	Args    []string
-	Env     map[string]string
-	StdErr  io.Writer
+	// Env is added to the variables from GetDefaultEnvironment(), overriding any with the same name.
+	Env map[string]string
+	// InheritEnv names additional variables to inherit from the current environment.
+	InheritEnv []string
+	// Cwd is the working directory of the server process, defaults to the current working directory.
+	Cwd string
+	// StdErr receives everything the server writes to stderr.
+	StdErr io.Writer
+	// OnStderr is called with each line the server writes to stderr.
+	// If neither StdErr nor OnStderr are provided, stderr is logged.
+	OnStderr func(line string)
+	// CloseTimeout is how long Close() waits for the server to exit after closing its stdin before sending SIGTERM.
+	CloseTimeout time.Duration
+	// TerminateTimeout is how long Close() waits for the server to exit after SIGTERM before sending SIGKILL.
+	TerminateTimeout time.Duration
+	// OutputTimeout is how long the server's stdout and stderr are read after it exits,
+	// eg: if a process started by the server has inherited them and is still running.
+	OutputTimeout time.Duration
 }
 
+// ServerExitError is reported to OnError when the server process exits unexpectedly with a failure.
+type ServerExitError struct {
+	// ExitCode is -1 if the process was terminated by a signal.
+	ExitCode int
+	Err      error
+}
+
+func (e *ServerExitError) Error() string {
+	if e.ExitCode < 0 {
+		return fmt.Sprintf("server process terminated: %v", e.Err)
+	}
+	return fmt.Sprintf("server process exited with code %d", e.ExitCode)
+}
+
+func (e *ServerExitError) Unwrap() error {
+	return e.Err
+}
+
+// Client transport for stdio: this will connect to a server by spawning a process and communicating with it over stdin/stdout.
+// If the process exits before Close() is called, a ServerExitError is reported to OnError (unless it exited with code 0)
+// and then OnClose is called.
 type StdioClientTransport struct {
 	jsonrpc.BaseTransport
 	ctx          context.Context
 	cancel       context.CancelFunc
 	serverParams StdioServerParameters
 	process      *os.Process
-	sendChannel  chan jsonrpc.JSONRPCMessage
+	stdin        io.WriteCloser
 	readBuffer   *jsonrpc.ReadBuffer
+	exited       chan struct{}
+	exitCode     int
+	exitErr      error
+	closing      bool
+	done         bool
+	writeLock    sync.Mutex
+	mu           sync.Mutex
 }
 
 func NewStdioClientTransport(ctx context.Context, server StdioServerParameters) *StdioClientTransport {
@@ -38,99 +122,231 @@ func NewStdioClientTransport(ctx context.Context, server StdioServerParameters)
 }
 
 func (t *StdioClientTransport) Start() error {
+	var startErr error
+	var onError func(err error)
+	// OnError is called after the lock is released, as it may call back into the transport
+	defer func() {
+		if startErr != nil && onError != nil {
+			onError(startErr)
+		}
+	}()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	if t.process != nil {
 		return errors.New("already started! If using Client class, note that Connect() calls Start() automatically")
 	}
 
-	ctx := t.ctx
 	cmd := exec.Command(t.serverParams.Command, t.serverParams.Args...)
-	// if t.serverParams.Env != nil {
-	//   cmd.Env = t.serverParams.Env
-	// }
-	// TODO: filter by DEFAULT_INHERITED_ENV_VARS
-	cmd.Env = os.Environ()
-	if t.serverParams.StdErr != nil {
-		cmd.Stdout = t.serverParams.StdErr
-	}
+	cmd.Env = t.environment()
+	cmd.Dir = t.serverParams.Cwd
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return fmt.Errorf("failed to get stdin pipe: %w", err)
 	}
 
-	t.sendChannel = make(chan jsonrpc.JSONRPCMessage)
+	// exec copies the output to these pipes until it reaches EOF, or for up to WaitDelay after the process exits
+	stdout, stdoutWriter := io.Pipe()
+	stderr, stderrWriter := io.Pipe()
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+	cmd.WaitDelay = t.serverParams.OutputTimeout
+	if cmd.WaitDelay <= 0 {
+		cmd.WaitDelay = DEFAULT_OUTPUT_TIMEOUT
+	}
+
+	if err := cmd.Start(); err != nil {
+		startErr, onError = err, t.OnError
+		return fmt.Errorf("failed to start command '%s' with args %v: %w", t.serverParams.Command, t.serverParams.Args, err)
+	}
+
+	t.process = cmd.Process
+	t.stdin = stdin
+	t.readBuffer = jsonrpc.NewReadBuffer(t.ctx)
+	t.exited = make(chan struct{})
+	t.ctx, t.cancel = context.WithCancel(t.ctx)
+
+	readers := &sync.WaitGroup{}
+	readers.Add(2)
 	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				stdin.Close()
-				return
-			case message := <-t.sendChannel:
-				jsonMessage, err := json.Marshal(message)
-				if err != nil {
-					if t.OnError != nil {
-						t.OnError(err)
-					}
-					continue
-				}
-
-				// jsonrpc.Logger.Printf("sending message: %s\n", string(jsonMessage))
-				_, err = io.WriteString(stdin, string(jsonMessage)+"\n")
-				if err != nil {
-					if t.OnError != nil {
-						t.OnError(err)
-					}
-				}
-			}
+		defer readers.Done()
+		t.readServerOutput(stdout)
+	}()
+	go func() {
+		defer readers.Done()
+		t.readServerErr(stderr)
+	}()
+
+	go func() {
+		err := cmd.Wait()
+		if errors.Is(err, exec.ErrWaitDelay) {
+			// the process exited successfully, but something else still holds its stdout or stderr
+			err = nil
 		}
+		// the readers reach EOF once the pipes are closed, and finish delivering what was read before OnClose
+		stdoutWriter.Close()
+		stderrWriter.Close()
+		readers.Wait()
+		t.processExited(err)
 	}()
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	exited := t.exited
+	go func() {
+		select {
+		case <-t.ctx.Done():
+			t.Close()
+		case <-exited:
+		}
+	}()
+
+	return nil
+}
+
+func (t *StdioClientTransport) environment() []string {
+	env := GetDefaultEnvironment()
+	for _, key := range t.serverParams.InheritEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			env[key] = value
+		}
+	}
+	for key, value := range t.serverParams.Env {
+		env[key] = value
 	}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stderr pipe: %w", err)
+	environment := make([]string, 0, len(env))
+	for key, value := range env {
+		environment = append(environment, key+"="+value)
 	}
+	return environment
+}
 
-	if err := cmd.Start(); err != nil {
-		if t.OnError != nil {
-			t.OnError(err)
-		}
-		return fmt.Errorf("failet to start command '%s' with args %v: %w", t.serverParams.Command, t.serverParams.Args, err)
+func (t *StdioClientTransport) processExited(err error) {
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		exitCode = -1
 	}
 
-	t.process = cmd.Process
-	t.readBuffer = jsonrpc.NewReadBuffer(ctx)
+	t.mu.Lock()
+	t.exitCode = exitCode
+	t.done = true
+	closing := t.closing
+	if !closing && err != nil {
+		t.exitErr = &ServerExitError{ExitCode: exitCode, Err: err}
+	}
+	serverExitErr := t.exitErr
+	onClose := t.OnClose
+	onError := t.OnError
+	t.mu.Unlock()
+
+	if serverExitErr != nil && onError != nil {
+		onError(serverExitErr)
+	}
+	if onClose != nil {
+		onClose()
+	}
 
-	t.ctx, t.cancel = context.WithCancel(ctx)
-	go t.readServerOutput(stdout)
-	go t.readServerErr(stderr)
+	close(t.exited)
+	t.cancel()
+}
 
-	return nil
+// Exited is closed when the server process has exited and OnClose has returned, or nil if it has not been started.
+func (t *StdioClientTransport) Exited() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exited
+}
+
+// Running reports whether the server process has been started and has not exited.
+func (t *StdioClientTransport) Running() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.process != nil && !t.done
+}
+
+// ExitCode returns the exit code of the server process once it has exited, or -1 if it was terminated by a signal.
+func (t *StdioClientTransport) ExitCode() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exitCode
+}
+
+// ExitError returns the ServerExitError reported to OnError if the server process exited unexpectedly, otherwise nil.
+func (t *StdioClientTransport) ExitError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exitErr
 }
 
 func (t *StdioClientTransport) Send(message jsonrpc.JSONRPCMessage) error {
-	if t.process == nil {
+	t.mu.Lock()
+	stdin := t.stdin
+	t.mu.Unlock()
+	if stdin == nil {
 		return errors.New("transport not started")
 	}
 
-	t.sendChannel <- message
-	return nil
+	jsonMessage, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	_, err = stdin.Write(append(jsonMessage, '\n'))
+	return err
 }
 
+// Close shuts the server down gracefully: stdin is closed so that the server can exit by itself,
+// then SIGTERM is sent after CloseTimeout and SIGKILL after a further TerminateTimeout.
+// Close returns once the process has exited.
 func (t *StdioClientTransport) Close() error {
-	t.cancel()
-	if t.process != nil {
-		t.process.Kill()
-		t.process.Wait()
-		t.process = nil
+	t.mu.Lock()
+	if t.process == nil || t.closing || t.done {
+		t.mu.Unlock()
+		return nil
 	}
-	if t.readBuffer != nil {
-		t.readBuffer.Close()
+	t.closing = true
+	process := t.process
+	exited := t.exited
+	t.mu.Unlock()
+
+	t.writeLock.Lock()
+	t.stdin.Close()
+	t.writeLock.Unlock()
+
+	closeTimeout := t.serverParams.CloseTimeout
+	if closeTimeout <= 0 {
+		closeTimeout = DEFAULT_CLOSE_TIMEOUT
+	}
+	terminateTimeout := t.serverParams.TerminateTimeout
+	if terminateTimeout <= 0 {
+		terminateTimeout = DEFAULT_TERMINATE_TIMEOUT
+	}
+
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(closeTimeout):
 	}
+
+	// os.Process.Signal() does not support SIGTERM on Windows
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		process.Kill()
+	}
+
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(terminateTimeout):
+	}
+
+	shared.Logger.Printf("server process %d did not exit after SIGTERM, killing it\n", process.Pid)
+	process.Kill()
+	<-exited
 	return nil
 }
 
@@ -138,56 +354,82 @@ func (t *StdioClientTransport) readServerOutput(reader io.Reader) {
 	buf := make([]byte, 8192)
 	for {
 		n, err := reader.Read(buf)
+		if n > 0 {
+			t.readBuffer.Append(buf[:n])
+			t.processReadBuffer()
+		}
 		if err != nil {
-			if err != io.EOF {
-				if t.OnError != nil {
-					t.OnError(err)
-				}
+			if err != io.EOF && !errors.Is(err, os.ErrClosed) {
+				t.onError(err)
 			}
 			break
 		}
-		if n > 0 {
-			chunk := make([]byte, n)
-			copy(chunk, buf[:n])
-			t.readBuffer.Append(chunk)
-			t.processReadBuffer()
-		}
 	}
 }
 
 func (t *StdioClientTransport) readServerErr(reader io.Reader) {
-	buf := make([]byte, 8192)
-	for {
-		n, err := reader.Read(buf)
-		if err != nil {
-			if err != io.EOF {
-				if t.OnError != nil {
-					t.OnError(err)
-				}
-			}
-			break
-		}
-		if n > 0 {
+	if t.serverParams.StdErr != nil {
+		reader = io.TeeReader(reader, t.serverParams.StdErr)
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 8192), 1024*1024)
+	for scanner.Scan() {
+		if t.serverParams.OnStderr != nil {
+			t.serverParams.OnStderr(scanner.Text())
+		} else if t.serverParams.StdErr == nil {
 			// docker logs progress (and also "daemon not started") to stderr
-			shared.Logger.Printf("stderr: %s\n", string(buf[:n]))
+			shared.Logger.Printf("stderr: %s\n", scanner.Text())
 		}
 	}
+	// drain anything left, eg: a line longer than the scanner buffer, so that the process is not blocked
+	io.Copy(io.Discard, reader)
 }
 
 func (t *StdioClientTransport) processReadBuffer() {
 	for {
 		message, err := t.readBuffer.ReadMessage()
 		if err != nil {
-			if t.OnError != nil {
-				t.OnError(err)
-			}
+			t.onError(err)
 			return
 		}
 		if message == nil {
 			break
 		}
-		if t.OnMessage != nil {
-			t.OnMessage(message)
+		t.mu.Lock()
+		onMessage := t.OnMessage
+		t.mu.Unlock()
+		if onMessage != nil {
+			onMessage(message)
 		}
 	}
 }
+
+// the callbacks may be replaced after Start(), eg: by mcp/shared.Protocol.Connect()
+
+func (t *StdioClientTransport) SetOnClose(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnClose = f
+}
+
+func (t *StdioClientTransport) SetOnError(f func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnError = f
+}
+
+func (t *StdioClientTransport) SetOnMessage(f func(message jsonrpc.JSONRPCMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnMessage = f
+}
+
+func (t *StdioClientTransport) onError(err error) {
+	t.mu.Lock()
+	onError := t.OnError
+	t.mu.Unlock()
+	if onError != nil {
+		onError(err)
+	}
+}
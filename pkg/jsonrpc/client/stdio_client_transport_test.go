@@ -0,0 +1,265 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperProcess is not a real test, it is run as the server process by the tests below.
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv("GO_MCP_HELPER_PROCESS")
+	if mode == "" {
+		return
+	}
+
+	switch mode {
+	case "echo":
+		// echo each line from stdin to stdout, and report the environment to stderr
+		cwd, _ := os.Getwd()
+		fmt.Fprintf(os.Stderr, "FOO=%s\n", os.Getenv("FOO"))
+		fmt.Fprintf(os.Stderr, "SECRET=%s\n", os.Getenv("SECRET"))
+		fmt.Fprintf(os.Stderr, "INHERITED=%s\n", os.Getenv("INHERITED"))
+		fmt.Fprintf(os.Stderr, "CWD=%s\n", cwd)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			fmt.Println(scanner.Text())
+		}
+		os.Exit(0)
+	case "ignore-stdin":
+		fmt.Fprintln(os.Stderr, "ready")
+		time.Sleep(time.Minute)
+	case "ignore-sigterm":
+		signal.Ignore(syscall.SIGTERM)
+		fmt.Fprintln(os.Stderr, "ready")
+		time.Sleep(time.Minute)
+	case "crash":
+		os.Exit(3)
+	case "orphan":
+		// start a process which inherits stdout and stderr and outlives this one
+		orphan := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+		orphan.Env = append(os.Environ(), "GO_MCP_HELPER_PROCESS=sleep")
+		orphan.Stdout = os.Stdout
+		orphan.Stderr = os.Stderr
+		if err := orphan.Start(); err != nil {
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "orphan=%d\n", orphan.Process.Pid)
+		io.Copy(io.Discard, os.Stdin)
+	case "sleep":
+		time.Sleep(time.Minute)
+	}
+	os.Exit(0)
+}
+
+func newHelperTransport(mode string, params StdioServerParameters) *StdioClientTransport {
+	params.Command = os.Args[0]
+	params.Args = []string{"-test.run=TestHelperProcess"}
+	if params.Env == nil {
+		params.Env = map[string]string{}
+	}
+	params.Env["GO_MCP_HELPER_PROCESS"] = mode
+	return NewStdioClientTransport(context.Background(), params)
+}
+
+// waitForStderr returns an OnStderr callback, and a channel which is closed when `line` is received.
+func waitForStderr(line string) (func(string), <-chan struct{}) {
+	received := make(chan struct{})
+	var once sync.Once
+	return func(l string) {
+		if l == line {
+			once.Do(func() { close(received) })
+		}
+	}, received
+}
+
+func TestStdioClientTransport(t *testing.T) {
+	t.Run("should pass env, working directory and stderr", func(t *testing.T) {
+		// given
+		t.Setenv("SECRET", "do-not-leak")
+		t.Setenv("INHERITED", "yes")
+		cwd := t.TempDir()
+		var mu sync.Mutex
+		var stderr []string
+
+		transport := newHelperTransport("echo", StdioServerParameters{
+			Env:        map[string]string{"FOO": "bar"},
+			InheritEnv: []string{"INHERITED"},
+			Cwd:        cwd,
+			OnStderr: func(line string) {
+				mu.Lock()
+				defer mu.Unlock()
+				stderr = append(stderr, line)
+			},
+		})
+		messages := make(chan jsonrpc.JSONRPCMessage, 1)
+		transport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
+			messages <- message
+		})
+
+		// when
+		require.NoError(t, transport.Start())
+		require.NoError(t, transport.Send(jsonrpc.NewJSONRPCNotification("echo", nil)))
+
+		// then
+		select {
+		case message := <-messages:
+			assert.Equal(t, "echo", message.(*jsonrpc.JSONRPCNotification).Method)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for echo")
+		}
+
+		require.NoError(t, transport.Close())
+		assert.Equal(t, 0, transport.ExitCode())
+
+		expectedCwd, _ := filepath.EvalSymlinks(cwd)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Contains(t, stderr, "FOO=bar")
+		assert.Contains(t, stderr, "SECRET=")
+		assert.Contains(t, stderr, "INHERITED=yes")
+		assert.Contains(t, stderr, "CWD="+expectedCwd)
+	})
+
+	t.Run("should only inherit the default environment variables", func(t *testing.T) {
+		t.Setenv("PATH", "/usr/bin")
+		t.Setenv("SECRET", "do-not-leak")
+
+		env := GetDefaultEnvironment()
+
+		assert.Equal(t, "/usr/bin", env["PATH"])
+		assert.NotContains(t, env, "SECRET")
+	})
+
+	t.Run("should close stdin and wait for the server to exit", func(t *testing.T) {
+		transport := newHelperTransport("echo", StdioServerParameters{OnStderr: func(string) {}})
+		closed := make(chan struct{})
+		transport.SetOnClose(func() { close(closed) })
+		transport.SetOnError(func(err error) { t.Errorf("unexpected error: %v", err) })
+		require.NoError(t, transport.Start())
+
+		start := time.Now()
+		require.NoError(t, transport.Close())
+
+		assert.Less(t, time.Since(start), DEFAULT_CLOSE_TIMEOUT)
+		assert.Equal(t, 0, transport.ExitCode())
+		<-closed
+	})
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	t.Run("should send SIGTERM if the server does not exit when stdin is closed", func(t *testing.T) {
+		onStderr, ready := waitForStderr("ready")
+		transport := newHelperTransport("ignore-stdin", StdioServerParameters{
+			OnStderr:     onStderr,
+			CloseTimeout: 50 * time.Millisecond,
+		})
+		transport.SetOnError(func(err error) { t.Errorf("unexpected error: %v", err) })
+		require.NoError(t, transport.Start())
+		<-ready
+
+		require.NoError(t, transport.Close())
+
+		assert.Equal(t, -1, transport.ExitCode())
+	})
+
+	t.Run("should send SIGKILL if the server ignores SIGTERM", func(t *testing.T) {
+		onStderr, ready := waitForStderr("ready")
+		transport := newHelperTransport("ignore-sigterm", StdioServerParameters{
+			OnStderr:         onStderr,
+			CloseTimeout:     50 * time.Millisecond,
+			TerminateTimeout: 50 * time.Millisecond,
+		})
+		require.NoError(t, transport.Start())
+		<-ready
+
+		require.NoError(t, transport.Close())
+
+		assert.Equal(t, -1, transport.ExitCode())
+	})
+
+	t.Run("should report a crash to OnError and OnClose", func(t *testing.T) {
+		transport := newHelperTransport("crash", StdioServerParameters{OnStderr: func(string) {}})
+		errs := make(chan error, 1)
+		transport.SetOnError(func(err error) { errs <- err })
+		closed := make(chan struct{})
+		transport.SetOnClose(func() { close(closed) })
+
+		require.NoError(t, transport.Start())
+
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for OnClose")
+		}
+		var exitErr *ServerExitError
+		require.ErrorAs(t, <-errs, &exitErr)
+		assert.Equal(t, 3, exitErr.ExitCode)
+		assert.Equal(t, 3, transport.ExitCode())
+	})
+
+	t.Run("should not wait for processes which inherit stdout after the server exits", func(t *testing.T) {
+		// given
+		orphans := make(chan int, 1)
+		transport := newHelperTransport("orphan", StdioServerParameters{
+			OnStderr: func(line string) {
+				var pid int
+				if _, err := fmt.Sscanf(line, "orphan=%d", &pid); err == nil {
+					orphans <- pid
+				}
+			},
+			OutputTimeout: 100 * time.Millisecond,
+		})
+		transport.SetOnError(func(err error) { t.Errorf("unexpected error: %v", err) })
+		require.NoError(t, transport.Start())
+		orphan, err := os.FindProcess(<-orphans)
+		require.NoError(t, err)
+		defer orphan.Kill()
+
+		// when
+		start := time.Now()
+		require.NoError(t, transport.Close())
+
+		// then
+		assert.Less(t, time.Since(start), DEFAULT_CLOSE_TIMEOUT)
+		assert.Equal(t, 0, transport.ExitCode())
+	})
+
+	t.Run("should report a failure to start to OnError without holding the lock", func(t *testing.T) {
+		transport := NewStdioClientTransport(context.Background(), StdioServerParameters{
+			Command: filepath.Join(t.TempDir(), "does-not-exist"),
+		})
+		errs := make(chan error, 1)
+		transport.SetOnError(func(err error) {
+			// Running() takes the lock, so this would deadlock if OnError were called while it was held
+			assert.False(t, transport.Running())
+			errs <- err
+		})
+
+		err := transport.Start()
+
+		require.Error(t, err)
+		select {
+		case onErr := <-errs:
+			assert.ErrorIs(t, err, onErr)
+		case <-time.After(time.Second):
+			t.Fatal("OnError was not called")
+		}
+	})
+}
@@ -1,56 +1,130 @@
 package client
 
-// Generated by AI, not tested (or needed?)
-// import (
-// 	"net/http"
-// 	"net/url"
-// 	"time"
-
-// 	"github.com/gorilla/websocket"
-// 	"github.com/nalbion/go-mcp/pkg/agent/channels/mcp/shared"
-// )
-
-// type WebSocketClientTransport struct {
-// 	client         *http.Client
-// 	url            *url.URL
-// 	requestBuilder func(req *http.Request)
-// 	conn           *websocket.Conn
-// }
-
-// func NewWebSocketClientTransport(client *http.Client, urlString string, requestBuilder func(req *http.Request)) (*WebSocketClientTransport, error) {
-// 	parsedUrl, err := url.Parse(urlString)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	return &WebSocketClientTransport{
-// 		client:         client,
-// 		url:            parsedUrl,
-// 		requestBuilder: requestBuilder,
-// 	}, nil
-// }
-
-// func (w *WebSocketClientTransport) InitializeSession() error {
-// 	headers := http.Header{}
-// 	headers.Set("Sec-WebSocket-Protocol", shared.MCP_SUBPROTOCOL)
-
-// 	dialer := websocket.Dialer{
-// 		Proxy:            http.ProxyFromEnvironment,
-// 		HandshakeTimeout: 45 * time.Second,
-// 	}
-
-// 	conn, _, err := dialer.Dial(w.url.String(), headers)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	w.conn = conn
-// 	return nil
-// }
-
-// func (w *WebSocketClientTransport) Close() error {
-// 	if w.conn != nil {
-// 		return w.conn.Close()
-// 	}
-// 	return nil
-// }
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// Client transport for WebSocket: this will connect to a server using the `mcp` subprotocol
+// and exchange JSON-RPC messages in both directions over the one connection.
+type WebSocketClientTransport struct {
+	jsonrpc.BaseTransport
+	ctx       context.Context
+	url       string // eg: "ws://localhost:8080/mcp"
+	header    http.Header
+	options   shared.WebSocketOptions
+	dialer    *websocket.Dialer
+	transport *shared.WebSocketTransport
+	mu        sync.Mutex
+}
+
+// NewWebSocketClientTransport creates a transport which connects to `url` when started.
+// `header` may be used to authenticate, eg: with an "Authorization" header, and may be nil.
+func NewWebSocketClientTransport(ctx context.Context, url string, header http.Header, options shared.WebSocketOptions) *WebSocketClientTransport {
+	return &WebSocketClientTransport{
+		ctx:     ctx,
+		url:     url,
+		header:  header,
+		options: options,
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: 45 * time.Second,
+			Subprotocols:     []string{shared.MCP_SUBPROTOCOL},
+		},
+	}
+}
+
+// Start connects to the server and fails if the server does not accept the `mcp` subprotocol.
+func (t *WebSocketClientTransport) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.transport != nil {
+		return errors.New("WebSocketClientTransport already started")
+	}
+
+	conn, resp, err := t.dialer.DialContext(t.ctx, t.url, t.header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("WebSocket handshake failed with status %s: %w", resp.Status, err)
+		}
+		return err
+	}
+
+	if conn.Subprotocol() != shared.MCP_SUBPROTOCOL {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseProtocolError, "mcp subprotocol required"),
+			time.Now().Add(time.Second))
+		conn.Close()
+		return fmt.Errorf("server did not accept the %s WebSocket subprotocol", shared.MCP_SUBPROTOCOL)
+	}
+
+	t.transport = shared.NewWebSocketTransport(t.ctx, conn, t.options)
+	t.transport.SetOnClose(t.OnClose)
+	t.transport.SetOnError(t.OnError)
+	t.transport.SetOnMessage(t.OnMessage)
+	return t.transport.Start()
+}
+
+// the callbacks may be replaced after Start(), eg: by mcp/shared.Protocol.Connect()
+
+func (t *WebSocketClientTransport) SetOnClose(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnClose = f
+	if t.transport != nil {
+		t.transport.SetOnClose(f)
+	}
+}
+
+func (t *WebSocketClientTransport) SetOnError(f func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnError = f
+	if t.transport != nil {
+		t.transport.SetOnError(f)
+	}
+}
+
+func (t *WebSocketClientTransport) SetOnMessage(f func(message jsonrpc.JSONRPCMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnMessage = f
+	if t.transport != nil {
+		t.transport.SetOnMessage(f)
+	}
+}
+
+func (t *WebSocketClientTransport) Send(message jsonrpc.JSONRPCMessage) error {
+	transport := t.connected()
+	if transport == nil {
+		return errors.New("transport not started")
+	}
+	return transport.Send(message)
+}
+
+// Close closes the connection with status 1000 (normal closure).
+func (t *WebSocketClientTransport) Close() error {
+	return t.CloseWithCode(websocket.CloseNormalClosure, "")
+}
+
+func (t *WebSocketClientTransport) CloseWithCode(code int, reason string) error {
+	transport := t.connected()
+	if transport == nil {
+		return nil
+	}
+	return transport.CloseWithCode(code, reason)
+}
+
+func (t *WebSocketClientTransport) connected() *shared.WebSocketTransport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.transport
+}
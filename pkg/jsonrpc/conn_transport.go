@@ -0,0 +1,172 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ConnTransport sends and receives newline-delimited JSON-RPC messages over a stream connection such as a socket.
+// Incoming messages are framed by ReadBuffer, in the same way as the stdio transports,
+// so `Content-Length` headers are also accepted.
+type ConnTransport struct {
+	BaseTransport
+	ctx        context.Context
+	cancel     context.CancelFunc
+	conn       io.ReadWriteCloser
+	readBuffer *ReadBuffer
+	started    bool
+	closed     bool
+	done       chan struct{}
+	writeLock  sync.Mutex
+	mu         sync.Mutex
+}
+
+func NewConnTransport(ctx context.Context, conn io.ReadWriteCloser) *ConnTransport {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &ConnTransport{
+		ctx:        ctx,
+		cancel:     cancel,
+		conn:       conn,
+		readBuffer: NewReadBuffer(ctx),
+		done:       make(chan struct{}),
+	}
+}
+
+func (t *ConnTransport) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started {
+		return errors.New("ConnTransport already started")
+	}
+	if t.closed {
+		return errors.New("ConnTransport is closed")
+	}
+	t.started = true
+
+	go t.read()
+	go func() {
+		select {
+		case <-t.ctx.Done():
+			t.Close()
+		case <-t.done:
+		}
+	}()
+
+	return nil
+}
+
+func (t *ConnTransport) read() {
+	buf := make([]byte, 8192)
+	for {
+		n, err := t.conn.Read(buf)
+		if n > 0 {
+			t.readBuffer.Append(buf[:n])
+			t.processReadBuffer()
+		}
+		if err != nil {
+			if err != io.EOF && t.ctx.Err() == nil {
+				t.onError(err)
+			}
+			break
+		}
+	}
+
+	t.readBuffer.Clear()
+	t.Close()
+}
+
+func (t *ConnTransport) processReadBuffer() {
+	for {
+		message, err := t.readBuffer.ReadMessage()
+		if err != nil {
+			t.onError(err)
+			return
+		}
+		if message == nil {
+			break
+		}
+		if onMessage := t.onMessage(); onMessage != nil {
+			onMessage(message)
+		}
+	}
+}
+
+func (t *ConnTransport) Send(message JSONRPCMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if t.ctx.Err() != nil {
+		return errors.New("not connected")
+	}
+
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	_, err = t.conn.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the connection. It is called automatically when the other side closes the connection or the context is done.
+func (t *ConnTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	onClose := t.OnClose
+	t.mu.Unlock()
+
+	t.cancel()
+	err := t.conn.Close()
+	close(t.done)
+	if onClose != nil {
+		onClose()
+	}
+	return err
+}
+
+// Done is closed when the transport has been closed.
+func (t *ConnTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// the callbacks may be replaced while reading, eg: by mcp/shared.Protocol.Connect() after Start()
+
+func (t *ConnTransport) SetOnClose(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnClose = f
+}
+
+func (t *ConnTransport) SetOnError(f func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnError = f
+}
+
+func (t *ConnTransport) SetOnMessage(f func(message JSONRPCMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnMessage = f
+}
+
+func (t *ConnTransport) onMessage() func(message JSONRPCMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.OnMessage
+}
+
+func (t *ConnTransport) onError(err error) {
+	t.mu.Lock()
+	onError := t.OnError
+	t.mu.Unlock()
+	if onError != nil {
+		onError(err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"sync"
 )
 
+// NewClientServerInMemoryTransports returns a pair of connected transports.
+// Messages are delivered asynchronously and in order, so a transport may Send() from within OnMessage.
 func NewClientServerInMemoryTransports() (*InMemoryTransport, *InMemoryTransport) {
 	clientTransport := newInMemoryTransport()
 	serverTransport := newInMemoryTransport()
@@ -18,69 +20,151 @@ func NewClientServerInMemoryTransports() (*InMemoryTransport, *InMemoryTransport
 func newInMemoryTransport() *InMemoryTransport {
 	return &InMemoryTransport{
 		messageQueue: make([]JSONRPCMessage, 0),
-		// messageQueue: make(chan(JSONRPCMessage))
+		signal:       make(chan struct{}, 1),
+		done:         make(chan struct{}),
 	}
 }
 
 type InMemoryTransport struct {
 	BaseTransport
 	otherTransport *InMemoryTransport
-	// messageQueue  chan (JSONRPCMessage)
+	// messages received before Start(), or not yet delivered to OnMessage
 	messageQueue []JSONRPCMessage
-	mu           sync.Mutex
+	signal       chan struct{}
+	done         chan struct{}
+	started      bool
+	// closing is set when either side is closed. Messages which have already been sent are still delivered, then OnClose is called.
+	closing bool
+	mu      sync.Mutex
 }
 
+// Start delivers any messages which were queued before Start was called, and then each message as it arrives.
 func (t *InMemoryTransport) Start() error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	if t.started {
+		return errors.New("already started")
+	}
+	t.started = true
+
+	go t.deliver()
+	return nil
+}
+
+func (t *InMemoryTransport) deliver() {
+	defer close(t.done)
+
+	for {
+		t.mu.Lock()
+		if len(t.messageQueue) == 0 {
+			if t.closing {
+				onClose := t.OnClose
+				t.mu.Unlock()
+				if onClose != nil {
+					onClose()
+				}
+				return
+			}
+			t.mu.Unlock()
+			<-t.signal
+			continue
+		}
 
-	// Process any messages that were queued before start was called
-	for len(t.messageQueue) > 0 {
 		message := t.messageQueue[0]
 		t.messageQueue = t.messageQueue[1:]
-		if t.OnMessage != nil {
-			t.OnMessage(message)
+		onMessage := t.OnMessage
+		t.mu.Unlock()
+
+		if onMessage != nil {
+			onMessage(message)
 		}
 	}
-
-	// go func() {
-	// 	for message := range reqChan {
-	// 		resChan <- message
-	// 		if t.OnMessage != nil {
-	// 			t.OnMessage(message)
-	// 		}
-	// 	}
-	// }()
-
-	return nil
 }
 
 func (t *InMemoryTransport) Send(message JSONRPCMessage) error {
-	if t.otherTransport == nil {
+	t.mu.Lock()
+	other := t.otherTransport
+	closing := t.closing
+	t.mu.Unlock()
+	if other == nil || closing {
 		return errors.New("not connected")
 	}
 
-	if t.otherTransport.OnMessage != nil {
-		t.otherTransport.OnMessage(message)
-	} else {
-		t.otherTransport.messageQueue = append(t.otherTransport.messageQueue, message)
+	return other.receive(message)
+}
+
+func (t *InMemoryTransport) receive(message JSONRPCMessage) error {
+	t.mu.Lock()
+	if t.closing {
+		t.mu.Unlock()
+		return errors.New("not connected")
 	}
+	t.messageQueue = append(t.messageQueue, message)
+	t.mu.Unlock()
 
+	t.notify()
 	return nil
 }
 
+func (t *InMemoryTransport) notify() {
+	select {
+	case t.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Close disconnects both transports. OnClose is called on each once it has received the messages already sent to it.
 func (t *InMemoryTransport) Close() error {
 	t.mu.Lock()
-	defer t.mu.Unlock()
-
 	other := t.otherTransport
-	t.otherTransport = nil
+	t.mu.Unlock()
+
+	t.disconnect()
 	if other != nil {
-		other.Close()
+		other.disconnect()
+	}
+	return nil
+}
+
+func (t *InMemoryTransport) disconnect() {
+	t.mu.Lock()
+	if t.closing {
+		t.mu.Unlock()
+		return
 	}
-	if t.OnClose != nil {
-		t.OnClose()
+	t.closing = true
+	started := t.started
+	onClose := t.OnClose
+	t.mu.Unlock()
+
+	if started {
+		t.notify()
+	} else if onClose != nil {
+		onClose()
 	}
+}
 
-	return nil
+// Done is closed once OnClose has been called, if the transport was started.
+func (t *InMemoryTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// the callbacks may be replaced after Start(), eg: by mcp/shared.Protocol.Connect()
+
+func (t *InMemoryTransport) SetOnClose(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnClose = f
+}
+
+func (t *InMemoryTransport) SetOnError(f func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnError = f
+}
+
+func (t *InMemoryTransport) SetOnMessage(f func(message JSONRPCMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnMessage = f
 }
@@ -0,0 +1,73 @@
+package jsonrpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryTransport(t *testing.T) {
+	t.Run("should deliver messages in order, including those sent before Start", func(t *testing.T) {
+		// given
+		client, server := NewClientServerInMemoryTransports()
+		received := make(chan JSONRPCMessage, 10)
+		server.SetOnMessage(func(message JSONRPCMessage) {
+			received <- message
+		})
+		require.NoError(t, client.Send(NewJSONRPCNotification("first", nil)))
+
+		// when
+		require.NoError(t, server.Start())
+		require.NoError(t, client.Send(NewJSONRPCNotification("second", nil)))
+
+		// then
+		assert.Equal(t, "first", nextValue(t, received).(*JSONRPCNotification).Method)
+		assert.Equal(t, "second", nextValue(t, received).(*JSONRPCNotification).Method)
+	})
+
+	t.Run("should allow sending from OnMessage", func(t *testing.T) {
+		// given a server which echoes every message
+		client, server := NewClientServerInMemoryTransports()
+		server.SetOnMessage(func(message JSONRPCMessage) {
+			server.Send(message)
+		})
+		received := make(chan JSONRPCMessage, 10)
+		client.SetOnMessage(func(message JSONRPCMessage) {
+			received <- message
+		})
+		require.NoError(t, server.Start())
+		require.NoError(t, client.Start())
+
+		// when
+		require.NoError(t, client.Send(NewJSONRPCNotification("echo", nil)))
+
+		// then
+		assert.Equal(t, "echo", nextValue(t, received).(*JSONRPCNotification).Method)
+	})
+
+	t.Run("should deliver sent messages before closing both sides", func(t *testing.T) {
+		// given
+		client, server := NewClientServerInMemoryTransports()
+		var events []string
+		server.SetOnMessage(func(message JSONRPCMessage) {
+			events = append(events, message.(*JSONRPCNotification).Method)
+		})
+		server.SetOnClose(func() { events = append(events, "closed") })
+		clientClosed := make(chan struct{})
+		client.SetOnClose(func() { close(clientClosed) })
+		require.NoError(t, server.Start())
+		require.NoError(t, client.Start())
+
+		// when
+		require.NoError(t, client.Send(NewJSONRPCNotification("last", nil)))
+		require.NoError(t, client.Close())
+
+		// then
+		nextValue(t, clientClosed)
+		nextValue(t, server.Done())
+		assert.Equal(t, []string{"last", "closed"}, events)
+		assert.Error(t, client.Send(NewJSONRPCNotification("too late", nil)))
+		assert.Error(t, server.Send(NewJSONRPCNotification("too late", nil)))
+	})
+}
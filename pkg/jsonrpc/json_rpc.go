@@ -233,13 +233,22 @@ type Result struct {
 
 type ResultMeta map[string]any
 
+// MarshalJSON implements json.Marshaler, flattening AdditionalProperties into the result.
+func (r Result) MarshalJSON() ([]byte, error) {
+	var meta *map[string]any
+	if r.Meta != nil {
+		meta = (*map[string]any)(&r.Meta)
+	}
+	return marshalParam(r.AdditionalProperties, meta)
+}
+
 func (r *Result) UnmarshalJSON(b []byte) error {
 	var raw map[string]any
 	if err := json.Unmarshal(b, &raw); err != nil {
 		return err
 	}
-	if meta, ok := raw["_meta"]; raw != nil && ok {
-		r.Meta = meta.(ResultMeta)
+	if meta, ok := raw["_meta"].(map[string]any); ok {
+		r.Meta = ResultMeta(meta)
 		delete(raw, "_meta")
 	}
 
@@ -0,0 +1,65 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult(t *testing.T) {
+	t.Run("should flatten additional properties when marshalled", func(t *testing.T) {
+		// given
+		result := Result{
+			Meta:                 ResultMeta{"progressToken": "abc"},
+			AdditionalProperties: map[string]any{"tools": []any{}},
+		}
+
+		// when
+		b, err := json.Marshal(result)
+
+		// then
+		require.NoError(t, err)
+		require.JSONEq(t, `{"_meta":{"progressToken":"abc"},"tools":[]}`, string(b))
+	})
+
+	t.Run("should omit _meta when not set", func(t *testing.T) {
+		// given
+		result := Result{AdditionalProperties: map[string]any{"ok": true}}
+
+		// when
+		b, err := json.Marshal(result)
+
+		// then
+		require.NoError(t, err)
+		require.JSONEq(t, `{"ok":true}`, string(b))
+	})
+
+	t.Run("should decode _meta without panicking", func(t *testing.T) {
+		// given
+		jsonResult := []byte(`{"_meta":{"progressToken":"abc"},"tools":[]}`)
+
+		// when
+		var result Result
+		err := json.Unmarshal(jsonResult, &result)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, ResultMeta{"progressToken": "abc"}, result.Meta)
+		require.Equal(t, map[string]any{"tools": []any{}}, result.AdditionalProperties)
+	})
+
+	t.Run("should round trip", func(t *testing.T) {
+		// given
+		jsonResult := `{"_meta":{"progressToken":"abc"},"tools":[]}`
+		var result Result
+		require.NoError(t, json.Unmarshal([]byte(jsonResult), &result))
+
+		// when
+		b, err := json.Marshal(result)
+
+		// then
+		require.NoError(t, err)
+		require.JSONEq(t, jsonResult, string(b))
+	})
+}
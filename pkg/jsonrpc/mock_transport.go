@@ -1,72 +1,401 @@
 package jsonrpc
 
-import "sync"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
 
-// Mock implementations for testing
+// MockTransport is a Transport for unit tests which records every message sent on it,
+// replies to expected requests, and lets the test push inbound messages with the Receive*() methods.
+// The zero value is ready to use.
 type MockTransport struct {
+	BaseTransport
+	// Sent contains every message sent, in order.
+	Sent              []JSONRPCMessage
 	SentRequests      []*JSONRPCRequest
 	SentNotifications []*JSONRPCNotification
-	// requestHandler      func(*JSONRPCRequest) (Result, error)
-	// notificationHandler func(*JSONRPCNotification) error
-	mu sync.Mutex
+	SentResponses     []*JSONRPCResponse
+	SentErrors        []*JSONRPCError
+	// SendError, if set, is returned by Send() and the message is not recorded, to simulate a broken connection.
+	SendError error
+
+	expectations []*MockExpectation
+	// unexpected contains the requests which did not match any expectation
+	unexpected []*JSONRPCRequest
+	// waited contains the messages already returned by the WaitFor*() methods
+	waited  map[JSONRPCMessage]bool
+	changed chan struct{}
+	started bool
+	closed  bool
+	mu      sync.Mutex
+}
+
+// MockExpectation describes a request which the code under test is expected to send, and how to reply to it.
+type MockExpectation struct {
+	mock        *MockTransport
+	method      Method
+	params      any
+	matchParams bool
+	reply       func(request *JSONRPCRequest) JSONRPCMessage
+	// times is the number of matching requests expected, or any number if negative
+	times int
+	calls int
+}
+
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
 }
 
 func (m *MockTransport) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return errors.New("already started")
+	}
+	m.started = true
 	return nil
 }
 
+// Close calls OnClose, as a real transport would.
 func (m *MockTransport) Close() error {
+	m.Disconnect()
 	return nil
 }
 
+// Disconnect simulates the other side closing the connection.
+func (m *MockTransport) Disconnect() {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.closed = true
+	onClose := m.OnClose
+	m.notifyLocked()
+	m.mu.Unlock()
+
+	if onClose != nil {
+		onClose()
+	}
+}
+
 func (m *MockTransport) Send(message JSONRPCMessage) error {
+	m.mu.Lock()
+	if m.SendError != nil {
+		err := m.SendError
+		m.mu.Unlock()
+		return err
+	}
+	if m.closed {
+		m.mu.Unlock()
+		return errors.New("not connected")
+	}
+
+	m.Sent = append(m.Sent, message)
+	// the reply is made once the lock has been released, as it may use the mock
+	var request *JSONRPCRequest
+	var reply func(request *JSONRPCRequest) JSONRPCMessage
+	switch message := message.(type) {
+	case *JSONRPCRequest:
+		m.SentRequests = append(m.SentRequests, message)
+		if expectation := m.matchLocked(message); expectation == nil {
+			m.unexpected = append(m.unexpected, message)
+		} else {
+			request, reply = message, expectation.reply
+		}
+	case *JSONRPCNotification:
+		m.SentNotifications = append(m.SentNotifications, message)
+	case *JSONRPCResponse:
+		m.SentResponses = append(m.SentResponses, message)
+	case *JSONRPCError:
+		m.SentErrors = append(m.SentErrors, message)
+	}
+	m.notifyLocked()
+	m.mu.Unlock()
+
+	if reply != nil {
+		if response := reply(request); response != nil {
+			// the caller may not be ready for the response until Send() returns
+			go m.Receive(response)
+		}
+	}
+	return nil
+}
+
+// ExpectRequest adds an expectation that a request will be sent with the given method.
+// By default the expectation matches any params, once, and the request is not answered.
+func (m *MockTransport) ExpectRequest(method Method) *MockExpectation {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	expectation := &MockExpectation{mock: m, method: method, times: 1}
+	m.expectations = append(m.expectations, expectation)
+	return expectation
+}
+
+// WithParams only matches requests with params which are equal to `params` when both are marshalled to JSON.
+// Any "_meta" sent with the request is ignored.
+func (e *MockExpectation) WithParams(params any) *MockExpectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.params = normaliseJSON(params)
+	e.matchParams = true
+	return e
+}
+
+// Reply responds to each matching request with `result`, which is sent as Result.AdditionalProperties.
+func (e *MockExpectation) Reply(result any) *MockExpectation {
+	return e.ReplyWith(func(request *JSONRPCRequest) JSONRPCMessage {
+		return newJSONRPCResponse(request.Id, Result{AdditionalProperties: result})
+	})
+}
+
+// ReplyError responds to each matching request with a JSON-RPC error.
+func (e *MockExpectation) ReplyError(code ErrorCode, message string) *MockExpectation {
+	return e.ReplyWith(func(request *JSONRPCRequest) JSONRPCMessage {
+		return NewJSONRPCError(request.Id, JSONRPCErrorError{Code: int(code), Message: message})
+	})
+}
+
+// ReplyWith responds to each matching request with the message returned by `reply`, or not at all if it returns nil.
+// `reply` is called from Send(), and may use the mock, eg: to send a notification before the response.
+func (e *MockExpectation) ReplyWith(reply func(request *JSONRPCRequest) JSONRPCMessage) *MockExpectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.reply = reply
+	return e
+}
 
-	if notification, ok := message.(*JSONRPCNotification); ok {
-		m.SentNotifications = append(m.SentNotifications, notification)
+// Times sets the number of matching requests expected.
+func (e *MockExpectation) Times(n int) *MockExpectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.times = n
+	return e
+}
+
+// AnyTimes allows any number of matching requests, including none.
+func (e *MockExpectation) AnyTimes() *MockExpectation {
+	return e.Times(-1)
+}
+
+func (e *MockExpectation) String() string {
+	if e.matchParams {
+		params, _ := json.Marshal(e.params)
+		return fmt.Sprintf("%s %s", e.method, params)
 	}
+	return string(e.method)
+}
 
-	// m.sentNotifications = append(m.sentNotifications, message)
+// matchLocked returns the first expectation which matches the request and has not been used up.
+func (m *MockTransport) matchLocked(request *JSONRPCRequest) *MockExpectation {
+	var params any
+	for _, expectation := range m.expectations {
+		if expectation.method != Method(request.Method) || (expectation.times >= 0 && expectation.calls >= expectation.times) {
+			continue
+		}
+		if expectation.matchParams {
+			if params == nil {
+				params = requestParams(request)
+			}
+			if !reflect.DeepEqual(expectation.params, params) {
+				continue
+			}
+		}
+		expectation.calls++
+		return expectation
+	}
 	return nil
 }
 
-// func (m *MockTransport) SendRequest(request *JSONRPCRequest) (Result, error) {
-// 	m.mu.Lock()
-// 	defer m.mu.Unlock()
-// 	m.sentRequests = append(m.sentRequests, request)
-// 	if m.requestHandler != nil {
-// 		return m.requestHandler(request)
-// 	}
-// 	return Result{}, nil
-// }
-
-// func (m *MockTransport) SendNotification(notification *JSONRPCNotification) error {
-// 	m.mu.Lock()
-// 	defer m.mu.Unlock()
-// 	m.sentNotifications = append(m.sentNotifications, notification)
-// 	if m.notificationHandler != nil {
-// 		return m.notificationHandler(notification)
-// 	}
-// 	return nil
-// }
-
-// func (m *MockTransport) SetRequestHandler(method Method, handler RequestHandler) {
-// 	// Not needed for testing
-// }
-
-// func (m *MockTransport) SetNotificationHandler(method Method, handler NotificationHandler) {
-// 	// Not needed for testing
-// }
+// requestParams returns the params as they would be sent on the wire, without "_meta".
+func requestParams(request *JSONRPCRequest) any {
+	if request.Params == nil {
+		return nil
+	}
+	params := normaliseJSON(request.Params)
+	if object, ok := params.(map[string]any); ok {
+		delete(object, "_meta")
+	}
+	return params
+}
+
+func normaliseJSON(value any) any {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var normalised any
+	if err := json.Unmarshal(content, &normalised); err != nil {
+		return err
+	}
+	return normalised
+}
+
+// AssertExpectations reports an error for each expectation which has not been matched the expected number of times,
+// and for each request which was sent without matching an expectation.
+func (m *MockTransport) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, expectation := range m.expectations {
+		if expectation.times >= 0 && expectation.calls != expectation.times {
+			t.Errorf("expected request %s %d time(s), but it was sent %d time(s)", expectation, expectation.times, expectation.calls)
+			ok = false
+		}
+	}
+	for _, request := range m.unexpected {
+		params, _ := json.Marshal(requestParams(request))
+		t.Errorf("unexpected request %d %s %s", request.Id, request.Method, params)
+		ok = false
+	}
+	return ok
+}
+
+// Receive passes an inbound message to OnMessage.
+func (m *MockTransport) Receive(message JSONRPCMessage) {
+	m.mu.Lock()
+	onMessage := m.OnMessage
+	m.mu.Unlock()
+	if onMessage != nil {
+		onMessage(message)
+	}
+}
+
+func (m *MockTransport) ReceiveRequest(id RequestId, method Method, params any) {
+	request := &JSONRPCRequest{Jsonrpc: "2.0", Id: id, Method: string(method)}
+	if params != nil {
+		request.Params = &JSONRPCRequestParams{AdditionalProperties: params}
+	}
+	m.Receive(request)
+}
+
+func (m *MockTransport) ReceiveNotification(method Method, params any) {
+	var notificationParams *JSONRPCNotificationParams
+	if params != nil {
+		notificationParams = &JSONRPCNotificationParams{AdditionalProperties: params}
+	}
+	m.Receive(NewJSONRPCNotification(method, notificationParams))
+}
+
+func (m *MockTransport) ReceiveResponse(id RequestId, result any) {
+	m.Receive(newJSONRPCResponse(id, Result{AdditionalProperties: result}))
+}
+
+func (m *MockTransport) ReceiveError(id RequestId, code ErrorCode, message string) {
+	m.Receive(NewJSONRPCError(id, JSONRPCErrorError{Code: int(code), Message: message}))
+}
+
+// WaitForRequest returns the first request sent with the given method which has not already been returned by WaitForRequest.
+func (m *MockTransport) WaitForRequest(method Method, timeout time.Duration) (*JSONRPCRequest, error) {
+	message, err := m.waitFor(timeout, fmt.Sprintf("request %s", method), func(message JSONRPCMessage) bool {
+		request, ok := message.(*JSONRPCRequest)
+		return ok && request.Method == string(method)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message.(*JSONRPCRequest), nil
+}
+
+// WaitForNotification returns the first notification sent with the given method which has not already been returned by WaitForNotification.
+func (m *MockTransport) WaitForNotification(method Method, timeout time.Duration) (*JSONRPCNotification, error) {
+	message, err := m.waitFor(timeout, fmt.Sprintf("notification %s", method), func(message JSONRPCMessage) bool {
+		notification, ok := message.(*JSONRPCNotification)
+		return ok && notification.Method == string(method)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message.(*JSONRPCNotification), nil
+}
+
+// WaitForResponse returns the response sent for the request with the given id.
+// If an error response was sent, the *JSONRPCErrorError is returned as the error.
+func (m *MockTransport) WaitForResponse(id RequestId, timeout time.Duration) (*JSONRPCResponse, error) {
+	message, err := m.waitFor(timeout, fmt.Sprintf("response %d", id), func(message JSONRPCMessage) bool {
+		switch message := message.(type) {
+		case *JSONRPCResponse:
+			return message.Id == id
+		case *JSONRPCError:
+			return message.Id == id
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if errorResponse, ok := message.(*JSONRPCError); ok {
+		return nil, &errorResponse.Error
+	}
+	return message.(*JSONRPCResponse), nil
+}
+
+func (m *MockTransport) waitFor(timeout time.Duration, description string, match func(message JSONRPCMessage) bool) (JSONRPCMessage, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		m.mu.Lock()
+		for _, message := range m.Sent {
+			if !m.waited[message] && match(message) {
+				if m.waited == nil {
+					m.waited = make(map[JSONRPCMessage]bool)
+				}
+				m.waited[message] = true
+				m.mu.Unlock()
+				return message, nil
+			}
+		}
+		if m.changed == nil {
+			m.changed = make(chan struct{})
+		}
+		changed := m.changed
+		closed := m.closed
+		m.mu.Unlock()
+
+		if closed {
+			return nil, fmt.Errorf("transport closed while waiting for %s", description)
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return nil, fmt.Errorf("timed out after %v waiting for %s", timeout, description)
+		}
+	}
+}
+
+// notifyLocked wakes any WaitFor*() callers.
+func (m *MockTransport) notifyLocked() {
+	if m.changed != nil {
+		close(m.changed)
+		m.changed = nil
+	}
+}
+
+// the callbacks may be replaced after Start(), eg: by mcp/shared.Protocol.Connect()
 
 func (m *MockTransport) SetOnClose(f func()) {
-	// Not needed for testing
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.OnClose = f
 }
 
 func (m *MockTransport) SetOnError(f func(err error)) {
-	// Not needed for testing
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.OnError = f
 }
 
 func (m *MockTransport) SetOnMessage(f func(message JSONRPCMessage)) {
-	// Not needed for testing
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.OnMessage = f
 }
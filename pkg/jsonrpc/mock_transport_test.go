@@ -0,0 +1,224 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingT records the errors reported by MockTransport.AssertExpectations.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockTransport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should reply to an expected request", func(t *testing.T) {
+		// given
+		transport := &MockTransport{}
+		transport.ExpectRequest("tools/call").
+			WithParams(map[string]any{"name": "echo", "arguments": map[string]any{"text": "hello"}}).
+			Reply(map[string]any{"echoed": "hello"})
+		protocol := NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, transport))
+
+		// when
+		var result map[string]any
+		err := protocol.SendRequest(ctx, "tools/call", &JSONRPCRequestParams{
+			Meta:                 &JSONRPCRequestParamsMeta{"progressToken": 1},
+			AdditionalProperties: map[string]any{"name": "echo", "arguments": map[string]any{"text": "hello"}},
+		}, &Result{AdditionalProperties: &result})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"echoed": "hello"}, result)
+		assert.Len(t, transport.SentRequests, 1)
+		assert.True(t, transport.AssertExpectations(t))
+	})
+
+	t.Run("should reply with an error", func(t *testing.T) {
+		// given
+		transport := &MockTransport{}
+		transport.ExpectRequest("ping").ReplyError(InternalError, "boom")
+		protocol := NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, transport))
+
+		// when
+		err := protocol.SendRequest(ctx, "ping", nil, nil)
+
+		// then
+		var jsonrpcErr *JSONRPCErrorError
+		require.ErrorAs(t, err, &jsonrpcErr)
+		assert.Equal(t, int(InternalError), jsonrpcErr.Code)
+	})
+
+	t.Run("should let the reply use the mock", func(t *testing.T) {
+		// given
+		transport := &MockTransport{}
+		transport.ExpectRequest("tools/call").ReplyWith(func(request *JSONRPCRequest) JSONRPCMessage {
+			require.NoError(t, transport.Send(NewJSONRPCNotification("notifications/progress", nil)))
+			transport.ExpectRequest("ping").Reply(map[string]any{})
+			return newJSONRPCResponse(request.Id, Result{AdditionalProperties: map[string]any{}})
+		})
+		protocol := NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, transport))
+
+		// when
+		err := protocol.SendRequest(ctx, "tools/call", nil, nil)
+
+		// then
+		require.NoError(t, err)
+		_, err = transport.WaitForNotification("notifications/progress", time.Second)
+		require.NoError(t, err)
+		require.NoError(t, protocol.SendRequest(ctx, "ping", nil, nil))
+		assert.True(t, transport.AssertExpectations(t))
+	})
+
+	t.Run("should report unmet expectations", func(t *testing.T) {
+		// given
+		transport := &MockTransport{}
+		transport.ExpectRequest("tools/call").WithParams(map[string]any{"name": "expected"})
+		transport.ExpectRequest("ping").Times(2)
+		transport.ExpectRequest("tools/list").AnyTimes()
+		require.NoError(t, transport.Send(&JSONRPCRequest{Jsonrpc: "2.0", Id: 1, Method: "tools/call",
+			Params: &JSONRPCRequestParams{AdditionalProperties: map[string]any{"name": "other"}}}))
+		require.NoError(t, transport.Send(&JSONRPCRequest{Jsonrpc: "2.0", Id: 2, Method: "ping"}))
+
+		// when
+		recorder := &recordingT{}
+		ok := transport.AssertExpectations(recorder)
+
+		// then
+		assert.False(t, ok)
+		assert.Equal(t, []string{
+			`expected request tools/call {"name":"expected"} 1 time(s), but it was sent 0 time(s)`,
+			"expected request ping 2 time(s), but it was sent 1 time(s)",
+			`unexpected request 1 tools/call {"name":"other"}`,
+		}, recorder.errors)
+	})
+
+	t.Run("should report requests which match no expectation", func(t *testing.T) {
+		// given
+		transport := &MockTransport{}
+		transport.ExpectRequest("ping")
+		require.NoError(t, transport.Send(&JSONRPCRequest{Jsonrpc: "2.0", Id: 1, Method: "ping"}))
+		require.NoError(t, transport.Send(&JSONRPCRequest{Jsonrpc: "2.0", Id: 2, Method: "tools/list",
+			Params: &JSONRPCRequestParams{Meta: &JSONRPCRequestParamsMeta{"progressToken": 1}}}))
+
+		// when
+		recorder := &recordingT{}
+		ok := transport.AssertExpectations(recorder)
+
+		// then
+		assert.False(t, ok)
+		assert.Equal(t, []string{"unexpected request 2 tools/list {}"}, recorder.errors)
+	})
+
+	t.Run("should pass injected requests to the handler and wait for the response", func(t *testing.T) {
+		// given
+		transport := &MockTransport{}
+		protocol := NewProtocol(ctx)
+		protocol.SetRequestHandler("echo", func(ctx context.Context, request *JSONRPCRequest, extra *RequestHandlerExtra) (Result, error) {
+			return Result{AdditionalProperties: request.Params.AdditionalProperties}, nil
+		})
+		require.NoError(t, protocol.Connect(ctx, transport))
+
+		// when
+		transport.ReceiveRequest(1, "echo", map[string]any{"text": "hello"})
+		transport.ReceiveRequest(2, "unknown", nil)
+
+		// then
+		response, err := transport.WaitForResponse(1, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"text": "hello"}, response.Result.AdditionalProperties)
+
+		_, err = transport.WaitForResponse(2, time.Second)
+		var jsonrpcErr *JSONRPCErrorError
+		require.ErrorAs(t, err, &jsonrpcErr)
+		assert.Equal(t, int(MethodNotFound), jsonrpcErr.Code)
+	})
+
+	t.Run("should pass injected notifications to the handler", func(t *testing.T) {
+		// given
+		transport := &MockTransport{}
+		protocol := NewProtocol(ctx)
+		received := make(chan *JSONRPCNotification, 1)
+		protocol.SetNotificationHandler("notifications/message", func(notification *JSONRPCNotification) error {
+			received <- notification
+			return nil
+		})
+		require.NoError(t, protocol.Connect(ctx, transport))
+
+		// when
+		transport.ReceiveNotification("notifications/message", map[string]any{"level": "info"})
+
+		// then
+		notification := nextValue(t, received)
+		assert.Equal(t, map[string]any{"level": "info"}, notification.Params.AdditionalProperties)
+	})
+
+	t.Run("should wait for each request in turn", func(t *testing.T) {
+		// given
+		transport := &MockTransport{}
+		go func() {
+			for id := 1; id <= 2; id++ {
+				time.Sleep(10 * time.Millisecond)
+				_ = transport.Send(&JSONRPCRequest{Jsonrpc: "2.0", Id: RequestId(id), Method: "ping"})
+			}
+		}()
+
+		// when
+		first, err1 := transport.WaitForRequest("ping", time.Second)
+		second, err2 := transport.WaitForRequest("ping", time.Second)
+		_, err3 := transport.WaitForRequest("ping", 10*time.Millisecond)
+
+		// then
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		assert.Equal(t, RequestId(1), first.Id)
+		assert.Equal(t, RequestId(2), second.Id)
+		assert.ErrorContains(t, err3, "timed out")
+	})
+
+	t.Run("should fail pending requests when disconnected", func(t *testing.T) {
+		// given
+		transport := &MockTransport{}
+		protocol := NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, transport))
+		done := make(chan error, 1)
+		go func() {
+			done <- protocol.SendRequest(ctx, "ping", nil, nil)
+		}()
+		_, err := transport.WaitForRequest("ping", time.Second)
+		require.NoError(t, err)
+
+		// when
+		transport.Disconnect()
+
+		// then
+		var jsonrpcErr *JSONRPCErrorError
+		require.ErrorAs(t, nextValue(t, done), &jsonrpcErr)
+		assert.Equal(t, int(ConnectionClosed), jsonrpcErr.Code)
+	})
+
+	t.Run("should return SendError", func(t *testing.T) {
+		transport := &MockTransport{SendError: errors.New("broken pipe")}
+
+		err := transport.Send(NewJSONRPCNotification("ping", nil))
+
+		assert.EqualError(t, err, "broken pipe")
+		assert.Empty(t, transport.Sent)
+	})
+}
@@ -0,0 +1,450 @@
+package jsonrpc
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// NetworkConditions describe how a NetworkSimulator treats each message.
+type NetworkConditions struct {
+	// Latency is the delay before each message is delivered, plus a random amount up to Jitter.
+	Latency time.Duration
+	Jitter  time.Duration
+	// LossRate is the probability that a message is dropped.
+	LossRate float64
+	// DuplicateRate is the probability that a message is delivered twice.
+	DuplicateRate float64
+	// ReorderRate is the probability that a message is held back so that later messages overtake it.
+	ReorderRate float64
+	// DisconnectRate is the probability that the connection is dropped after a message is sent.
+	DisconnectRate float64
+}
+
+type NetworkEventType int
+
+const (
+	NetworkEventDelivered NetworkEventType = iota
+	NetworkEventDropped
+	NetworkEventDuplicated
+	NetworkEventReordered
+	NetworkEventDisconnected
+)
+
+func (e NetworkEventType) String() string {
+	switch e {
+	case NetworkEventDelivered:
+		return "delivered"
+	case NetworkEventDropped:
+		return "dropped"
+	case NetworkEventDuplicated:
+		return "duplicated"
+	case NetworkEventReordered:
+		return "reordered"
+	case NetworkEventDisconnected:
+		return "disconnected"
+	}
+	return fmt.Sprintf("NetworkEventType(%d)", int(e))
+}
+
+// A NetworkEvent records what the simulator did with a message. Seq is the order in which the message was sent.
+type NetworkEvent struct {
+	Seq     uint64
+	Type    NetworkEventType
+	From    string
+	To      string
+	Delay   time.Duration
+	Message JSONRPCMessage
+}
+
+func (e NetworkEvent) String() string {
+	description := "connection"
+	switch message := e.Message.(type) {
+	case *JSONRPCRequest:
+		description = fmt.Sprintf("request %d %s", message.Id, message.Method)
+	case *JSONRPCNotification:
+		description = "notification " + message.Method
+	case *JSONRPCResponse:
+		description = fmt.Sprintf("response %d", message.Id)
+	case *JSONRPCError:
+		description = fmt.Sprintf("error %d", message.Id)
+	case nil:
+	default:
+		description = fmt.Sprintf("%T", message)
+	}
+	return fmt.Sprintf("#%d %s->%s %s %s after %v", e.Seq, e.From, e.To, description, e.Type, e.Delay)
+}
+
+// NetworkSimulator creates pairs of connected transports which deliver messages asynchronously with the
+// latency, loss, duplication, reordering and disconnects described by its NetworkConditions.
+//
+// Every random decision is taken from a generator seeded with `seed` in the order that messages are sent,
+// so a failing test can be reproduced by logging the seed and running it again with the same seed.
+// Messages are delivered by a single goroutine, in order of their delivery time and then the order they were sent.
+// Delivery times are measured on a simulated clock which advances as each message is delivered, and catches up
+// with the wall clock while no message is in flight, so the order does not depend on the scheduler provided that
+// messages are sent before the first delivery or from OnMessage.
+type NetworkSimulator struct {
+	// OnEvent is called for each event, eg: to log the trace of a failing test. It must not call the simulator.
+	OnEvent func(event NetworkEvent)
+
+	seed  uint64
+	rand  *rand.Rand
+	start time.Time
+	// now is the simulated time since start, the delivery time of the last message delivered
+	now time.Duration
+	// delivering is set while OnMessage or OnClose is called
+	delivering bool
+	conditions NetworkConditions
+	queue      deliveryQueue
+	seq        uint64
+	pairs      int
+	trace      []NetworkEvent
+	signal     chan struct{}
+	done       chan struct{}
+	closed     bool
+	mu         sync.Mutex
+}
+
+func NewNetworkSimulator(seed uint64, conditions NetworkConditions) *NetworkSimulator {
+	s := &NetworkSimulator{
+		seed:       seed,
+		rand:       rand.New(rand.NewPCG(seed, seed)),
+		start:      time.Now(),
+		conditions: conditions,
+		signal:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	go s.deliver()
+	return s
+}
+
+// Seed returns the seed of the random generator.
+func (s *NetworkSimulator) Seed() uint64 {
+	return s.seed
+}
+
+// SetConditions changes the conditions for messages sent from now on, eg: to end a period of heavy loss.
+func (s *NetworkSimulator) SetConditions(conditions NetworkConditions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conditions = conditions
+}
+
+// Trace returns the events so far.
+func (s *NetworkSimulator) Trace() []NetworkEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]NetworkEvent(nil), s.trace...)
+}
+
+// NewTransportPair returns a connected client and server transport.
+func (s *NetworkSimulator) NewTransportPair() (*SimulatedTransport, *SimulatedTransport) {
+	s.mu.Lock()
+	s.pairs++
+	link := &simulatedLink{}
+	client := &SimulatedTransport{name: fmt.Sprintf("client%d", s.pairs), simulator: s, link: link}
+	server := &SimulatedTransport{name: fmt.Sprintf("server%d", s.pairs), simulator: s, link: link}
+	s.mu.Unlock()
+
+	client.peer = server
+	server.peer = client
+	return client, server
+}
+
+// Disconnect drops the connection of `transport`, discarding messages which have not been delivered.
+// OnClose is called on both transports.
+func (s *NetworkSimulator) Disconnect(transport *SimulatedTransport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.disconnect(transport)
+}
+
+// Close stops delivering messages. OnClose is not called on the transports.
+func (s *NetworkSimulator) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	s.notify()
+	<-s.done
+}
+
+func (s *NetworkSimulator) disconnect(transport *SimulatedTransport) {
+	if transport.link.down {
+		return
+	}
+	transport.link.down = true
+
+	s.seq++
+	s.record(NetworkEvent{Seq: s.seq, Type: NetworkEventDisconnected, From: transport.name, To: transport.peer.name})
+	now := s.clock()
+	s.schedule(&delivery{at: now, to: transport})
+	s.schedule(&delivery{at: now, to: transport.peer})
+}
+
+func (s *NetworkSimulator) send(from *SimulatedTransport, message JSONRPCMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || from.link.down {
+		return errors.New("not connected")
+	}
+
+	s.seq++
+	conditions := s.conditions
+	// always take the same number of random values, so that changing one rate does not change the other decisions
+	lost := s.rand.Float64() < conditions.LossRate
+	duplicated := s.rand.Float64() < conditions.DuplicateRate
+	reordered := s.rand.Float64() < conditions.ReorderRate
+	disconnect := s.rand.Float64() < conditions.DisconnectRate
+	delay := conditions.Latency + s.jitter(conditions)
+	duplicateDelay := conditions.Latency + s.jitter(conditions)
+
+	event := NetworkEvent{Seq: s.seq, Type: NetworkEventDelivered, From: from.name, To: from.peer.name, Delay: delay, Message: message}
+	now := s.clock()
+	switch {
+	case lost:
+		event.Type = NetworkEventDropped
+		s.record(event)
+	default:
+		if reordered {
+			// held back long enough for any message sent after it to overtake it
+			delay += 2*(conditions.Latency+conditions.Jitter) + time.Millisecond
+			event.Type = NetworkEventReordered
+			event.Delay = delay
+		}
+		s.record(event)
+		s.schedule(&delivery{at: now + delay, to: from.peer, message: message, link: from.link})
+
+		if duplicated {
+			s.record(NetworkEvent{Seq: s.seq, Type: NetworkEventDuplicated, From: from.name, To: from.peer.name, Delay: duplicateDelay, Message: message})
+			s.schedule(&delivery{at: now + duplicateDelay, to: from.peer, message: message, link: from.link})
+		}
+	}
+
+	if disconnect {
+		s.disconnect(from)
+	}
+	return nil
+}
+
+// clock returns the simulated time at which a message is sent. While no message is in flight the simulated clock
+// catches up with the wall clock, so that the latency of a message sent after a pause is measured from when it was sent.
+func (s *NetworkSimulator) clock() time.Duration {
+	if len(s.queue) == 0 && !s.delivering {
+		s.now = max(s.now, time.Since(s.start))
+	}
+	return s.now
+}
+
+func (s *NetworkSimulator) jitter(conditions NetworkConditions) time.Duration {
+	return time.Duration(s.rand.Float64() * float64(conditions.Jitter))
+}
+
+func (s *NetworkSimulator) record(event NetworkEvent) {
+	s.trace = append(s.trace, event)
+	if s.OnEvent != nil {
+		s.OnEvent(event)
+	}
+}
+
+func (s *NetworkSimulator) schedule(d *delivery) {
+	d.seq = s.seq
+	heap.Push(&s.queue, d)
+	s.notify()
+}
+
+func (s *NetworkSimulator) notify() {
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (s *NetworkSimulator) deliver() {
+	defer close(s.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+
+		var next *delivery
+		wait := time.Hour
+		if len(s.queue) > 0 {
+			wait = time.Until(s.start.Add(s.queue[0].at))
+			if wait <= 0 {
+				next = heap.Pop(&s.queue).(*delivery)
+				s.now = max(s.now, next.at)
+			}
+		}
+
+		if next == nil {
+			s.mu.Unlock()
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-s.signal:
+				if !timer.Stop() {
+					<-timer.C
+				}
+			}
+			continue
+		}
+
+		to := next.to
+		if next.message != nil && next.link.down {
+			// the connection dropped while the message was in flight
+			s.mu.Unlock()
+			continue
+		}
+		if next.message != nil && !to.started {
+			to.pending = append(to.pending, next.message)
+			s.mu.Unlock()
+			continue
+		}
+		s.delivering = true
+		s.mu.Unlock()
+
+		if next.message == nil {
+			to.onClose()
+		} else {
+			to.onMessage(next.message)
+		}
+
+		s.mu.Lock()
+		s.delivering = false
+		s.mu.Unlock()
+	}
+}
+
+type simulatedLink struct {
+	down bool
+}
+
+type delivery struct {
+	at   time.Duration
+	seq  uint64
+	to   *SimulatedTransport
+	link *simulatedLink
+	// message is nil to call OnClose
+	message JSONRPCMessage
+}
+
+type deliveryQueue []*delivery
+
+func (q deliveryQueue) Len() int { return len(q) }
+
+func (q deliveryQueue) Less(i, j int) bool {
+	if q[i].at == q[j].at {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at < q[j].at
+}
+
+func (q deliveryQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *deliveryQueue) Push(x any) { *q = append(*q, x.(*delivery)) }
+
+func (q *deliveryQueue) Pop() any {
+	old := *q
+	d := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return d
+}
+
+// SimulatedTransport is one end of a connection created by NetworkSimulator.NewTransportPair().
+type SimulatedTransport struct {
+	BaseTransport
+	name      string
+	simulator *NetworkSimulator
+	peer      *SimulatedTransport
+	link      *simulatedLink
+	started   bool
+	// messages which arrived before Start()
+	pending []JSONRPCMessage
+	mu      sync.Mutex
+}
+
+// Name identifies the transport in NetworkEvents.
+func (t *SimulatedTransport) Name() string {
+	return t.name
+}
+
+func (t *SimulatedTransport) Start() error {
+	s := t.simulator
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.started {
+		return errors.New("already started")
+	}
+	t.started = true
+
+	now := s.clock()
+	for _, message := range t.pending {
+		s.schedule(&delivery{at: now, to: t, message: message, link: t.link})
+	}
+	t.pending = nil
+	return nil
+}
+
+func (t *SimulatedTransport) Send(message JSONRPCMessage) error {
+	return t.simulator.send(t, message)
+}
+
+// Close drops the connection, as NetworkSimulator.Disconnect().
+func (t *SimulatedTransport) Close() error {
+	t.simulator.Disconnect(t)
+	return nil
+}
+
+func (t *SimulatedTransport) onMessage(message JSONRPCMessage) {
+	t.mu.Lock()
+	onMessage := t.OnMessage
+	t.mu.Unlock()
+	if onMessage != nil {
+		onMessage(message)
+	}
+}
+
+func (t *SimulatedTransport) onClose() {
+	t.mu.Lock()
+	onClose := t.OnClose
+	t.mu.Unlock()
+	if onClose != nil {
+		onClose()
+	}
+}
+
+// the callbacks may be replaced after Start(), eg: by mcp/shared.Protocol.Connect()
+
+func (t *SimulatedTransport) SetOnClose(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnClose = f
+}
+
+func (t *SimulatedTransport) SetOnError(f func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnError = f
+}
+
+func (t *SimulatedTransport) SetOnMessage(f func(message JSONRPCMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnMessage = f
+}
@@ -0,0 +1,234 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// simulate sends `count` notifications from a client to a server and returns the methods in the order they were received,
+// once no more have been received for a while.
+func simulate(t *testing.T, simulator *NetworkSimulator, count int) []string {
+	t.Helper()
+	client, server := simulator.NewTransportPair()
+	received := make(chan string, count*2)
+	server.SetOnMessage(func(message JSONRPCMessage) {
+		received <- message.(*JSONRPCNotification).Method
+	})
+	require.NoError(t, server.Start())
+	require.NoError(t, client.Start())
+
+	for i := 0; i < count; i++ {
+		client.Send(NewJSONRPCNotification(Method(fmt.Sprint(i)), nil))
+	}
+
+	var methods []string
+	for {
+		select {
+		case method := <-received:
+			methods = append(methods, method)
+		case <-time.After(100 * time.Millisecond):
+			return methods
+		}
+	}
+}
+
+func eventTypes(trace []NetworkEvent) []NetworkEventType {
+	types := make([]NetworkEventType, len(trace))
+	for i, event := range trace {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestNetworkSimulator(t *testing.T) {
+	t.Run("should deliver messages in order with latency", func(t *testing.T) {
+		simulator := NewNetworkSimulator(1, NetworkConditions{Latency: 20 * time.Millisecond})
+		defer simulator.Close()
+
+		start := time.Now()
+		methods := simulate(t, simulator, 3)
+
+		assert.Equal(t, []string{"0", "1", "2"}, methods)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("should reproduce the same decisions from the same seed", func(t *testing.T) {
+		conditions := NetworkConditions{
+			Latency:       20 * time.Millisecond,
+			Jitter:        20 * time.Millisecond,
+			LossRate:      0.2,
+			DuplicateRate: 0.2,
+			ReorderRate:   0.2,
+		}
+		first := NewNetworkSimulator(42, conditions)
+		defer first.Close()
+		second := NewNetworkSimulator(42, conditions)
+		defer second.Close()
+
+		firstMethods := simulate(t, first, 20)
+		secondMethods := simulate(t, second, 20)
+
+		assert.Equal(t, eventTypes(first.Trace()), eventTypes(second.Trace()))
+		assert.Equal(t, firstMethods, secondMethods)
+		assert.Contains(t, eventTypes(first.Trace()), NetworkEventDropped)
+		assert.Contains(t, eventTypes(first.Trace()), NetworkEventDuplicated)
+		assert.Contains(t, eventTypes(first.Trace()), NetworkEventReordered)
+	})
+
+	t.Run("should drop lost messages", func(t *testing.T) {
+		simulator := NewNetworkSimulator(1, NetworkConditions{LossRate: 1})
+		defer simulator.Close()
+
+		assert.Empty(t, simulate(t, simulator, 3))
+	})
+
+	t.Run("should duplicate messages", func(t *testing.T) {
+		simulator := NewNetworkSimulator(1, NetworkConditions{DuplicateRate: 1})
+		defer simulator.Close()
+
+		assert.Equal(t, []string{"0", "0"}, simulate(t, simulator, 1))
+	})
+
+	t.Run("should let later messages overtake reordered messages", func(t *testing.T) {
+		simulator := NewNetworkSimulator(1, NetworkConditions{Latency: time.Millisecond})
+		defer simulator.Close()
+		client, server := simulator.NewTransportPair()
+		received := make(chan string, 2)
+		server.SetOnMessage(func(message JSONRPCMessage) {
+			received <- message.(*JSONRPCNotification).Method
+		})
+		require.NoError(t, server.Start())
+
+		simulator.SetConditions(NetworkConditions{Latency: time.Millisecond, ReorderRate: 1})
+		require.NoError(t, client.Send(NewJSONRPCNotification("first", nil)))
+		simulator.SetConditions(NetworkConditions{Latency: time.Millisecond})
+		require.NoError(t, client.Send(NewJSONRPCNotification("second", nil)))
+
+		assert.Equal(t, "second", nextValue(t, received))
+		assert.Equal(t, "first", nextValue(t, received))
+	})
+
+	t.Run("should apply the latency to messages sent after the network has been idle", func(t *testing.T) {
+		// given
+		simulator := NewNetworkSimulator(1, NetworkConditions{Latency: 100 * time.Millisecond})
+		defer simulator.Close()
+		client, server := simulator.NewTransportPair()
+		received := make(chan string, 2)
+		server.SetOnMessage(func(message JSONRPCMessage) {
+			received <- message.(*JSONRPCNotification).Method
+		})
+		require.NoError(t, server.Start())
+		time.Sleep(150 * time.Millisecond)
+
+		// when
+		start := time.Now()
+		require.NoError(t, client.Send(NewJSONRPCNotification("after idle", nil)))
+
+		// then
+		assert.Equal(t, "after idle", nextValue(t, received))
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("should let later messages overtake reordered messages after the network has been idle", func(t *testing.T) {
+		// given
+		simulator := NewNetworkSimulator(1, NetworkConditions{Latency: 20 * time.Millisecond})
+		defer simulator.Close()
+		client, server := simulator.NewTransportPair()
+		received := make(chan string, 2)
+		server.SetOnMessage(func(message JSONRPCMessage) {
+			received <- message.(*JSONRPCNotification).Method
+		})
+		require.NoError(t, server.Start())
+		time.Sleep(100 * time.Millisecond)
+
+		// when
+		simulator.SetConditions(NetworkConditions{Latency: 20 * time.Millisecond, ReorderRate: 1})
+		require.NoError(t, client.Send(NewJSONRPCNotification("first", nil)))
+		time.Sleep(10 * time.Millisecond)
+		simulator.SetConditions(NetworkConditions{Latency: 20 * time.Millisecond})
+		require.NoError(t, client.Send(NewJSONRPCNotification("second", nil)))
+
+		// then
+		assert.Equal(t, "second", nextValue(t, received))
+		assert.Equal(t, "first", nextValue(t, received))
+	})
+
+	t.Run("should close both sides when disconnected", func(t *testing.T) {
+		// given
+		simulator := NewNetworkSimulator(1, NetworkConditions{Latency: time.Hour})
+		defer simulator.Close()
+		client, server := simulator.NewTransportPair()
+		clientClosed := make(chan struct{})
+		client.SetOnClose(func() { close(clientClosed) })
+		serverClosed := make(chan struct{})
+		server.SetOnClose(func() { close(serverClosed) })
+		server.SetOnMessage(func(message JSONRPCMessage) {
+			t.Errorf("unexpected message: %v", message)
+		})
+		require.NoError(t, client.Start())
+		require.NoError(t, server.Start())
+		require.NoError(t, client.Send(NewJSONRPCNotification("in flight", nil)))
+
+		// when
+		simulator.Disconnect(server)
+
+		// then
+		nextValue(t, clientClosed)
+		nextValue(t, serverClosed)
+		assert.Error(t, client.Send(NewJSONRPCNotification("too late", nil)))
+	})
+
+	t.Run("should work with Protocol", func(t *testing.T) {
+		simulator := NewNetworkSimulator(7, NetworkConditions{Latency: time.Millisecond, Jitter: 5 * time.Millisecond})
+		defer simulator.Close()
+		clientTransport, serverTransport := simulator.NewTransportPair()
+		ctx := context.Background()
+
+		server := NewProtocol(ctx)
+		server.SetRequestHandler("echo", func(ctx context.Context, request *JSONRPCRequest, extra *RequestHandlerExtra) (Result, error) {
+			return Result{AdditionalProperties: map[string]any{"method": request.Method}}, nil
+		})
+		require.NoError(t, server.Connect(ctx, serverTransport))
+		client := NewProtocol(ctx)
+		require.NoError(t, client.Connect(ctx, clientTransport))
+
+		result := &Result{AdditionalProperties: &map[string]any{}}
+		require.NoError(t, client.SendRequest(ctx, "echo", nil, result))
+		assert.Equal(t, "echo", (*result.AdditionalProperties.(*map[string]any))["method"])
+	})
+
+	t.Run("should reproduce the trace of a client and server exchange from the same seed", func(t *testing.T) {
+		exchange := func(seed uint64) []NetworkEvent {
+			simulator := NewNetworkSimulator(seed, NetworkConditions{Latency: time.Millisecond, Jitter: 5 * time.Millisecond})
+			defer simulator.Close()
+			clientTransport, serverTransport := simulator.NewTransportPair()
+			ctx := context.Background()
+
+			server := NewProtocol(ctx)
+			server.SetRequestHandler("echo", func(ctx context.Context, request *JSONRPCRequest, extra *RequestHandlerExtra) (Result, error) {
+				return Result{AdditionalProperties: map[string]any{"id": request.Id}}, nil
+			})
+			require.NoError(t, server.Connect(ctx, serverTransport))
+			client := NewProtocol(ctx)
+			require.NoError(t, client.Connect(ctx, clientTransport))
+
+			for i := 0; i < 5; i++ {
+				require.NoError(t, client.SendRequest(ctx, "echo", nil, nil))
+			}
+			return simulator.Trace()
+		}
+
+		first := exchange(11)
+		second := exchange(11)
+		other := exchange(12)
+
+		require.Len(t, first, 10)
+		assert.Equal(t, first, second)
+		assert.NotEqual(t, first, other)
+	})
+}
@@ -8,9 +8,8 @@ import (
 )
 
 type (
-	RequestHandlerExtra any
 	ResponseOrError     any
-	RequestHandler      func(ctx context.Context, request *JSONRPCRequest, extra RequestHandlerExtra) (Result, error)
+	RequestHandler      func(ctx context.Context, request *JSONRPCRequest, extra *RequestHandlerExtra) (Result, error)
 	ResponseHandler     func(response *JSONRPCResponse, err error)
 	NotificationHandler func(notification *JSONRPCNotification) error
 )
@@ -74,16 +73,26 @@ func (p *Protocol) Connect(ctx context.Context, transport Transport) error {
 		}
 	})
 
+	// ParseJSONRPCMessage() returns requests & notifications by pointer and responses & errors by value,
+	// while in-process transports may send either form.
 	p.transport.SetOnMessage(func(message JSONRPCMessage) {
 		switch message := message.(type) {
 		case JSONRPCRequest:
 			p.OnRequest(ctx, &message, nil)
+		case *JSONRPCRequest:
+			p.OnRequest(ctx, message, nil)
 		case JSONRPCResponse:
 			p.onResponse(&message, nil)
+		case *JSONRPCResponse:
+			p.onResponse(message, nil)
+		case JSONRPCError:
+			p.onResponse(nil, &message)
 		case *JSONRPCError:
 			p.onResponse(nil, message)
 		case JSONRPCNotification:
 			p.onNotification(&message)
+		case *JSONRPCNotification:
+			p.onNotification(message)
 		default:
 			p.OnError(fmt.Errorf("unknown message type: %T", message))
 		}
@@ -92,6 +101,11 @@ func (p *Protocol) Connect(ctx context.Context, transport Transport) error {
 	return p.transport.Start()
 }
 
+// SetOnClose sets a callback for when the connection is closed for any reason, including by Close().
+func (p *Protocol) SetOnClose(onClose func()) {
+	p.onClose = onClose
+}
+
 func (p *Protocol) IsConnected() bool {
 	return p.transport != nil
 }
@@ -99,10 +113,10 @@ func (p *Protocol) IsConnected() bool {
 func (p *Protocol) Close() error {
 	// avoid infinite loop. we tell the transport to call onCloseImpl() from t.Close().
 	if p.transport == nil {
-		p.transport.Close()
-	} else {
-		p.onCloseImpl()
+		// already closed, eg: the transport closed while a request was in progress
+		return nil
 	}
+	p.onCloseImpl()
 	return nil
 }
 
@@ -194,11 +208,11 @@ func (p *Protocol) SendRequestInternal(
 			// }
 			resChan <- response
 		}
+	}
 
-		if cancelTimeout != nil {
-			Logger.Println("Cancelling timeout")
-			cancelTimeout()
-		}
+	// the timeout is not cancelled by the response handler, as ctx.Done() could then be selected instead of the response
+	if cancelTimeout != nil {
+		defer cancelTimeout()
 	}
 
 	cancel := func(reason string) {
@@ -257,26 +271,17 @@ func (p *Protocol) SendInternal(jsonrpcMessage JSONRPCMessage) error {
 // parseResponse should only be called (and JSONRPCResponse unmarshalled) after verifying that the message is not a JSONRPCError.
 // messageResult _may_ be provided if the result type is known in advance.
 func parseResponse(response *JSONRPCResponse, messageResult *Result) error {
-	content, err := json.Marshal(response.Result.AdditionalProperties)
-	if err != nil {
-		return err
-	}
-
-	if messageResult != nil {
-		err = json.Unmarshal(content, messageResult.AdditionalProperties)
-		if err != nil {
-			return err
-		}
-
+	if messageResult == nil {
+		// the caller is not interested in the result, eg: ping
 		return nil
 	}
 
-	err = ParseResult(content, messageResult)
+	content, err := json.Marshal(response.Result.AdditionalProperties)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return json.Unmarshal(content, messageResult.AdditionalProperties)
 }
 
 func (p *Protocol) SendNotification(method Method, params *JSONRPCNotificationParams) error {
@@ -320,6 +325,12 @@ func (p *Protocol) RemoveNotificationHandler(method Method) {
 	delete(p.notificationHandlers, method)
 }
 
+// HandleRequest dispatches a request to its handler, and sends the response.
+// This is the default OnRequest, for use by protocols which override OnRequest.
+func (p *Protocol) HandleRequest(ctx context.Context, request *JSONRPCRequest, onDone func()) {
+	p.onRequest(ctx, request, onDone)
+}
+
 // onRequest is called by the Transport when a JSONRPCRequest is received.
 // mcp.Protocol calls this with a cancelable ctx.
 func (p *Protocol) onRequest(ctx context.Context, request *JSONRPCRequest, onDone func()) {
@@ -329,6 +340,9 @@ func (p *Protocol) onRequest(ctx context.Context, request *JSONRPCRequest, onDon
 	}
 
 	if handler == nil {
+		if onDone != nil {
+			defer onDone()
+		}
 		err := p.transport.Send(NewJSONRPCError(
 			request.Id,
 			JSONRPCErrorError{
@@ -347,7 +361,7 @@ func (p *Protocol) onRequest(ctx context.Context, request *JSONRPCRequest, onDon
 			defer onDone()
 		}
 
-		result, err := handler(ctx, request, nil)
+		result, err := handler(ctx, request, p.newRequestHandlerExtra(ctx, request))
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				return
@@ -14,7 +14,7 @@ func TestRemoveResponseHandler(t *testing.T) {
 	ctx := context.Background()
 	p := NewProtocol(ctx)
 	p.requestHandlers = map[Method]RequestHandler{
-		"foo": func(ctx context.Context, request *JSONRPCRequest, extra RequestHandlerExtra) (Result, error) {
+		"foo": func(ctx context.Context, request *JSONRPCRequest, extra *RequestHandlerExtra) (Result, error) {
 			return Result{}, nil
 		},
 	}
@@ -55,3 +55,66 @@ func TestRemoveResponseHandler(t *testing.T) {
 	// then the result is populated with the Result
 	require.Equal(t, map[string]interface{}{"foo": "bar"}, result.AdditionalProperties)
 }
+
+func TestProtocolRequests(t *testing.T) {
+	connect := func(t *testing.T) (*Protocol, *Protocol) {
+		ctx := context.Background()
+		clientTransport, serverTransport := NewClientServerInMemoryTransports()
+
+		server := NewProtocol(ctx)
+		server.SetRequestHandler("ping", func(ctx context.Context, request *JSONRPCRequest, extra *RequestHandlerExtra) (Result, error) {
+			return Result{}, nil
+		})
+		server.SetRequestHandler("echo", func(ctx context.Context, request *JSONRPCRequest, extra *RequestHandlerExtra) (Result, error) {
+			return Result{AdditionalProperties: map[string]any{"echo": "hello"}}, nil
+		})
+		require.NoError(t, server.Connect(ctx, serverTransport))
+
+		client := NewProtocol(ctx)
+		require.NoError(t, client.Connect(ctx, clientTransport))
+
+		return client, server
+	}
+
+	t.Run("should not require a result for requests such as ping", func(t *testing.T) {
+		// given
+		client, _ := connect(t)
+
+		// when
+		err := client.SendRequest(context.Background(), "ping", nil, nil)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("should return the result of repeated requests with a timeout", func(t *testing.T) {
+		// given
+		client, _ := connect(t)
+
+		for i := 0; i < 20; i++ {
+			ctx, cancelTimeout := context.WithTimeout(context.Background(), time.Second)
+			request, messageID := client.NewRequest("echo", nil)
+			result := Result{AdditionalProperties: &map[string]any{}}
+
+			// when
+			err := client.SendRequestInternal(ctx, request, messageID, &result, cancelTimeout, nil)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, &map[string]any{"echo": "hello"}, result.AdditionalProperties)
+		}
+	})
+
+	t.Run("should allow Close to be called more than once", func(t *testing.T) {
+		// given
+		client, _ := connect(t)
+		require.NoError(t, client.Close())
+
+		// when
+		err := client.Close()
+
+		// then
+		require.NoError(t, err)
+		require.False(t, client.IsConnected())
+	})
+}
@@ -0,0 +1,481 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrBufferFull = errors.New("too many messages buffered while reconnecting")
+
+type ConnectionState int
+
+const (
+	ConnectionStateConnecting ConnectionState = iota
+	ConnectionStateConnected
+	ConnectionStateReconnecting
+	ConnectionStateClosed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionStateConnecting:
+		return "connecting"
+	case ConnectionStateConnected:
+		return "connected"
+	case ConnectionStateReconnecting:
+		return "reconnecting"
+	case ConnectionStateClosed:
+		return "closed"
+	}
+	return fmt.Sprintf("ConnectionState(%d)", int(s))
+}
+
+// Handshake re-establishes the session on a new connection, eg: by repeating the MCP initialize request.
+// Messages received while the handshake is running are delivered to `receive` rather than OnMessage,
+// so the handshake should ignore any that it does not expect. `ctx` is cancelled if the connection closes.
+type Handshake func(ctx context.Context, send func(message JSONRPCMessage) error, receive <-chan JSONRPCMessage) error
+
+type ReconnectOptions struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter randomises each delay by up to this fraction, so that clients do not all reconnect at once.
+	Jitter float64
+	// MaxAttempts is the number of consecutive failed attempts before giving up, or 0 to keep trying until closed.
+	MaxAttempts int
+	// MaxBufferedMessages is the number of messages which may be sent while reconnecting before Send() fails with ErrBufferFull.
+	MaxBufferedMessages int
+	// IsIdempotent reports whether a request which was in flight when the connection dropped may be sent again.
+	// Other requests fail with ConnectionClosed. By default no requests are retried.
+	IsIdempotent func(request *JSONRPCRequest) bool
+	// Handshake is run on each new connection after reconnecting, before any buffered messages are sent.
+	Handshake Handshake
+}
+
+func NewReconnectOptions() ReconnectOptions {
+	return ReconnectOptions{
+		InitialBackoff:      100 * time.Millisecond,
+		MaxBackoff:          30 * time.Second,
+		Jitter:              0.2,
+		MaxBufferedMessages: 100,
+	}
+}
+
+// ReconnectingTransport wraps the transports created by `dial`, transparently creating a new one when
+// the current transport closes. Messages sent while reconnecting are buffered and sent once the handshake completes.
+type ReconnectingTransport struct {
+	BaseTransport
+	// OnStateChange is called when the connection state changes, with the error which caused a disconnection if known.
+	OnStateChange func(state ConnectionState, err error)
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	dial    func(ctx context.Context) (Transport, error)
+	options ReconnectOptions
+
+	state     ConnectionState
+	transport Transport
+	lastError error
+	inflight  map[RequestId]*JSONRPCRequest
+	outbox    []JSONRPCMessage
+	job       sync.WaitGroup
+	mu        sync.Mutex
+}
+
+// NewReconnectingTransport reconnects with `options`. An InitialBackoff, MaxBackoff or MaxBufferedMessages which
+// is not positive takes the value from NewReconnectOptions(), so that a zero value neither rejects every message
+// sent while reconnecting nor redials without a pause.
+func NewReconnectingTransport(ctx context.Context, dial func(ctx context.Context) (Transport, error), options ReconnectOptions) *ReconnectingTransport {
+	defaults := NewReconnectOptions()
+	if options.InitialBackoff <= 0 {
+		options.InitialBackoff = defaults.InitialBackoff
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = defaults.MaxBackoff
+	}
+	if options.MaxBufferedMessages <= 0 {
+		options.MaxBufferedMessages = defaults.MaxBufferedMessages
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &ReconnectingTransport{
+		ctx:      ctx,
+		cancel:   cancel,
+		dial:     dial,
+		options:  options,
+		inflight: make(map[RequestId]*JSONRPCRequest),
+	}
+}
+
+// Start makes the initial connection. If this fails, no attempt is made to reconnect.
+func (t *ReconnectingTransport) Start() error {
+	t.mu.Lock()
+	if t.transport != nil || t.state != ConnectionStateConnecting {
+		t.mu.Unlock()
+		return errors.New("already started")
+	}
+	t.mu.Unlock()
+
+	transport, err := t.connect(nil)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	if t.state == ConnectionStateClosed {
+		t.mu.Unlock()
+		transport.Close()
+		return errors.New("transport closed")
+	}
+	t.transport = transport
+	t.mu.Unlock()
+
+	t.setState(ConnectionStateConnected, nil)
+	return nil
+}
+
+// State returns the current connection state.
+func (t *ReconnectingTransport) State() ConnectionState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state
+}
+
+func (t *ReconnectingTransport) Send(message JSONRPCMessage) error {
+	request := asRequest(message)
+
+	t.mu.Lock()
+	switch t.state {
+	case ConnectionStateClosed:
+		t.mu.Unlock()
+		return errors.New("transport closed")
+	case ConnectionStateConnected:
+	default:
+		if len(t.outbox) >= t.options.MaxBufferedMessages {
+			t.mu.Unlock()
+			return ErrBufferFull
+		}
+		t.outbox = append(t.outbox, message)
+		t.mu.Unlock()
+		return nil
+	}
+	transport := t.transport
+	if request != nil {
+		t.inflight[request.Id] = request
+	}
+	t.mu.Unlock()
+
+	err := transport.Send(message)
+	if err != nil && request != nil {
+		t.mu.Lock()
+		delete(t.inflight, request.Id)
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// Close closes the current transport and stops reconnecting.
+func (t *ReconnectingTransport) Close() error {
+	t.mu.Lock()
+	if t.state == ConnectionStateClosed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.state = ConnectionStateClosed
+	transport := t.transport
+	t.transport = nil
+	t.mu.Unlock()
+
+	t.notifyStateChange(ConnectionStateClosed, nil)
+	t.cancel()
+
+	var err error
+	if transport != nil {
+		err = transport.Close()
+	}
+	t.job.Wait()
+
+	t.mu.Lock()
+	onClose := t.OnClose
+	t.mu.Unlock()
+	if onClose != nil {
+		onClose()
+	}
+	return err
+}
+
+// connect dials and starts a new transport. If `handshake` is provided, it is run before OnMessage receives any messages.
+func (t *ReconnectingTransport) connect(handshake Handshake) (Transport, error) {
+	transport, err := t.dial(t.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var handshakeMessages chan JSONRPCMessage
+	var handshaking atomic.Bool
+	handshakeCtx, handshakeCancel := context.WithCancel(t.ctx)
+	defer handshakeCancel()
+	if handshake != nil {
+		handshakeMessages = make(chan JSONRPCMessage, 16)
+		handshaking.Store(true)
+	}
+
+	transport.SetOnMessage(func(message JSONRPCMessage) {
+		if handshaking.Load() {
+			select {
+			case handshakeMessages <- message:
+			default:
+				t.onError(fmt.Errorf("dropped %T received during handshake", message))
+			}
+			return
+		}
+		t.onMessage(message)
+	})
+	transport.SetOnError(t.onError)
+	transport.SetOnClose(func() {
+		if handshaking.Load() {
+			handshakeCancel()
+			return
+		}
+		t.disconnected(transport)
+	})
+
+	if err := transport.Start(); err != nil {
+		return nil, err
+	}
+
+	if handshake != nil {
+		err := handshake(handshakeCtx, transport.Send, handshakeMessages)
+		handshaking.Store(false)
+		if err != nil {
+			t.discard(transport)
+			return nil, fmt.Errorf("handshake failed: %w", err)
+		}
+	}
+
+	return transport, nil
+}
+
+// discard closes a transport without triggering a reconnection.
+func (t *ReconnectingTransport) discard(transport Transport) {
+	transport.SetOnClose(nil)
+	transport.Close()
+}
+
+func (t *ReconnectingTransport) onMessage(message JSONRPCMessage) {
+	var id *RequestId
+	switch message := message.(type) {
+	case JSONRPCResponse:
+		id = &message.Id
+	case *JSONRPCResponse:
+		id = &message.Id
+	case JSONRPCError:
+		id = &message.Id
+	case *JSONRPCError:
+		id = &message.Id
+	}
+
+	t.mu.Lock()
+	if id != nil {
+		delete(t.inflight, *id)
+	}
+	onMessage := t.OnMessage
+	t.mu.Unlock()
+
+	if onMessage != nil {
+		onMessage(message)
+	}
+}
+
+func (t *ReconnectingTransport) onError(err error) {
+	t.mu.Lock()
+	t.lastError = err
+	onError := t.OnError
+	t.mu.Unlock()
+
+	if onError != nil {
+		onError(err)
+	}
+}
+
+// disconnected is called when a transport closes. If it is the current transport, in-flight requests
+// are queued to be sent again or failed, and reconnection starts.
+func (t *ReconnectingTransport) disconnected(transport Transport) {
+	t.mu.Lock()
+	if t.transport != transport || t.state != ConnectionStateConnected {
+		t.mu.Unlock()
+		return
+	}
+	t.transport = nil
+	cause := t.lastError
+	t.lastError = nil
+
+	var retry []JSONRPCMessage
+	var failed []*JSONRPCRequest
+	for _, request := range t.inflight {
+		if t.options.IsIdempotent != nil && t.options.IsIdempotent(request) {
+			retry = append(retry, request)
+		} else {
+			failed = append(failed, request)
+		}
+	}
+	clear(t.inflight)
+	// re-send requests in the order they were originally sent, before anything that was buffered
+	slices.SortFunc(retry, func(a, b JSONRPCMessage) int {
+		return int(a.(*JSONRPCRequest).Id - b.(*JSONRPCRequest).Id)
+	})
+	t.outbox = append(retry, t.outbox...)
+	onMessage := t.OnMessage
+	t.job.Add(1)
+	t.mu.Unlock()
+
+	for _, request := range failed {
+		if onMessage != nil {
+			onMessage(NewJSONRPCError(request.Id, *NewJSONRPCErrorError(request.Id, ConnectionClosed, "Connection closed", nil)))
+		}
+	}
+
+	t.setState(ConnectionStateReconnecting, cause)
+	go func() {
+		defer t.job.Done()
+		t.reconnect()
+	}()
+}
+
+func (t *ReconnectingTransport) reconnect() {
+	for attempt := 0; t.options.MaxAttempts == 0 || attempt < t.options.MaxAttempts; attempt++ {
+		select {
+		case <-time.After(t.backoff(attempt)):
+		case <-t.ctx.Done():
+			return
+		}
+
+		transport, err := t.connect(t.options.Handshake)
+		if err != nil {
+			Logger.Printf("reconnection attempt %d failed: %v\n", attempt+1, err)
+			t.onError(err)
+			continue
+		}
+
+		if err := t.flush(transport); err != nil {
+			Logger.Printf("failed to send buffered messages: %v\n", err)
+			t.discard(transport)
+			continue
+		}
+		return
+	}
+
+	t.setState(ConnectionStateClosed, fmt.Errorf("gave up reconnecting after %d attempts", t.options.MaxAttempts))
+
+	t.mu.Lock()
+	onClose := t.OnClose
+	t.mu.Unlock()
+	if onClose != nil {
+		onClose()
+	}
+}
+
+// flush sends the buffered messages, and then marks the transport as connected.
+func (t *ReconnectingTransport) flush(transport Transport) error {
+	for {
+		t.mu.Lock()
+		if t.state == ConnectionStateClosed {
+			t.mu.Unlock()
+			t.discard(transport)
+			return nil
+		}
+		if len(t.outbox) == 0 {
+			t.transport = transport
+			t.state = ConnectionStateConnected
+			t.mu.Unlock()
+			t.notifyStateChange(ConnectionStateConnected, nil)
+			return nil
+		}
+		message := t.outbox[0]
+		t.outbox = t.outbox[1:]
+		request := asRequest(message)
+		if request != nil {
+			t.inflight[request.Id] = request
+		}
+		t.mu.Unlock()
+
+		if err := transport.Send(message); err != nil {
+			t.mu.Lock()
+			t.outbox = append([]JSONRPCMessage{message}, t.outbox...)
+			if request != nil {
+				delete(t.inflight, request.Id)
+			}
+			t.mu.Unlock()
+			return err
+		}
+	}
+}
+
+func (t *ReconnectingTransport) backoff(attempt int) time.Duration {
+	backoff := t.options.InitialBackoff << attempt
+	if backoff > t.options.MaxBackoff || backoff <= 0 {
+		backoff = t.options.MaxBackoff
+	}
+	if t.options.Jitter > 0 {
+		backoff += time.Duration((rand.Float64()*2 - 1) * t.options.Jitter * float64(backoff))
+	}
+	return backoff
+}
+
+func (t *ReconnectingTransport) setState(state ConnectionState, err error) {
+	t.mu.Lock()
+	if t.state == state || (t.state == ConnectionStateClosed && state != ConnectionStateClosed) {
+		t.mu.Unlock()
+		return
+	}
+	t.state = state
+	t.mu.Unlock()
+
+	t.notifyStateChange(state, err)
+}
+
+func (t *ReconnectingTransport) notifyStateChange(state ConnectionState, err error) {
+	t.mu.Lock()
+	onStateChange := t.OnStateChange
+	t.mu.Unlock()
+
+	if onStateChange != nil {
+		onStateChange(state, err)
+	}
+}
+
+// the callbacks may be replaced after Start(), eg: by mcp/shared.Protocol.Connect()
+
+func (t *ReconnectingTransport) SetOnClose(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnClose = f
+}
+
+func (t *ReconnectingTransport) SetOnError(f func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnError = f
+}
+
+func (t *ReconnectingTransport) SetOnMessage(f func(message JSONRPCMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnMessage = f
+}
+
+func asRequest(message JSONRPCMessage) *JSONRPCRequest {
+	switch message := message.(type) {
+	case *JSONRPCRequest:
+		return message
+	case JSONRPCRequest:
+		return &message
+	}
+	return nil
+}
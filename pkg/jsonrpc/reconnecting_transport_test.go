@@ -0,0 +1,242 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConnection is a transport which records the messages sent on it, and can be dropped by the test.
+type fakeConnection struct {
+	BaseTransport
+	sent chan JSONRPCMessage
+	mu   sync.Mutex
+}
+
+func (c *fakeConnection) Send(message JSONRPCMessage) error {
+	c.sent <- message
+	return nil
+}
+
+func (c *fakeConnection) Close() error {
+	return nil
+}
+
+func (c *fakeConnection) SetOnClose(f func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.OnClose = f
+}
+
+func (c *fakeConnection) receive(message JSONRPCMessage) {
+	c.mu.Lock()
+	onMessage := c.OnMessage
+	c.mu.Unlock()
+	onMessage(message)
+}
+
+func (c *fakeConnection) drop() {
+	c.mu.Lock()
+	onClose := c.OnClose
+	c.mu.Unlock()
+	onClose()
+}
+
+// fakeDialer returns a dial func which creates fakeConnections, and a channel which receives each connection.
+// The first attempt succeeds, then the next `failures` attempts fail, or all of them if `failures` is negative.
+func fakeDialer(failures int) (func(ctx context.Context) (Transport, error), <-chan *fakeConnection) {
+	connections := make(chan *fakeConnection, 10)
+	attempts := 0
+	var mu sync.Mutex
+	return func(ctx context.Context) (Transport, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts > 1 && failures != 0 {
+			failures--
+			return nil, errors.New("connection refused")
+		}
+		connection := &fakeConnection{sent: make(chan JSONRPCMessage, 10)}
+		connections <- connection
+		return connection, nil
+	}, connections
+}
+
+func nextValue[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out")
+	}
+	var zero T
+	return zero
+}
+
+func fastReconnectOptions() ReconnectOptions {
+	options := NewReconnectOptions()
+	options.InitialBackoff = time.Millisecond
+	options.MaxBackoff = 10 * time.Millisecond
+	return options
+}
+
+func TestReconnectingTransport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should buffer messages while reconnecting and report state changes", func(t *testing.T) {
+		// given a server which refuses the first two reconnection attempts
+		dial, connections := fakeDialer(2)
+		transport := NewReconnectingTransport(ctx, dial, fastReconnectOptions())
+		states := make(chan ConnectionState, 10)
+		transport.OnStateChange = func(state ConnectionState, err error) {
+			states <- state
+		}
+		require.NoError(t, transport.Start())
+		connection := nextValue(t, connections)
+
+		// when the connection drops
+		connection.drop()
+		require.NoError(t, transport.Send(NewJSONRPCNotification("buffered", nil)))
+
+		// then the message is sent on the new connection
+		connection = nextValue(t, connections)
+		sent := nextValue(t, connection.sent)
+		assert.Equal(t, "buffered", sent.(*JSONRPCNotification).Method)
+		assert.Equal(t, ConnectionStateConnected, nextValue(t, states))
+		assert.Equal(t, ConnectionStateReconnecting, nextValue(t, states))
+		assert.Equal(t, ConnectionStateConnected, nextValue(t, states))
+
+		require.NoError(t, transport.Close())
+		assert.Equal(t, ConnectionStateClosed, nextValue(t, states))
+	})
+
+	t.Run("should retry idempotent requests and fail others with ConnectionClosed", func(t *testing.T) {
+		// given
+		dial, connections := fakeDialer(0)
+		options := fastReconnectOptions()
+		options.IsIdempotent = func(request *JSONRPCRequest) bool {
+			return request.Method == "ping"
+		}
+		transport := NewReconnectingTransport(ctx, dial, options)
+		received := make(chan JSONRPCMessage, 10)
+		transport.SetOnMessage(func(message JSONRPCMessage) {
+			received <- message
+		})
+		require.NoError(t, transport.Start())
+		defer transport.Close()
+		connection := nextValue(t, connections)
+
+		require.NoError(t, transport.Send(&JSONRPCRequest{Jsonrpc: "2.0", Id: 1, Method: "tools/call"}))
+		require.NoError(t, transport.Send(&JSONRPCRequest{Jsonrpc: "2.0", Id: 2, Method: "ping"}))
+		require.NoError(t, transport.Send(&JSONRPCRequest{Jsonrpc: "2.0", Id: 3, Method: "ping"}))
+		nextValue(t, connection.sent)
+		nextValue(t, connection.sent)
+		nextValue(t, connection.sent)
+		connection.receive(&JSONRPCResponse{Jsonrpc: "2.0", Id: 3})
+		nextValue(t, received)
+
+		// when
+		connection.drop()
+
+		// then
+		failed := nextValue(t, received).(*JSONRPCError)
+		assert.Equal(t, RequestId(1), failed.Id)
+		assert.Equal(t, int(ConnectionClosed), failed.Error.Code)
+
+		connection = nextValue(t, connections)
+		retried := nextValue(t, connection.sent).(*JSONRPCRequest)
+		assert.Equal(t, RequestId(2), retried.Id)
+		assert.Empty(t, connection.sent)
+	})
+
+	t.Run("should run the handshake before sending buffered messages", func(t *testing.T) {
+		// given
+		dial, connections := fakeDialer(0)
+		options := fastReconnectOptions()
+		handshakes := 0
+		options.Handshake = func(ctx context.Context, send func(message JSONRPCMessage) error, receive <-chan JSONRPCMessage) error {
+			handshakes++
+			if err := send(&JSONRPCRequest{Jsonrpc: "2.0", Id: 0, Method: "initialize"}); err != nil {
+				return err
+			}
+			select {
+			case response := <-receive:
+				assert.Equal(t, RequestId(0), response.(*JSONRPCResponse).Id)
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		transport := NewReconnectingTransport(ctx, dial, options)
+		received := make(chan JSONRPCMessage, 10)
+		transport.SetOnMessage(func(message JSONRPCMessage) {
+			received <- message
+		})
+		require.NoError(t, transport.Start())
+		defer transport.Close()
+		connection := nextValue(t, connections)
+
+		// when
+		connection.drop()
+		require.NoError(t, transport.Send(NewJSONRPCNotification("buffered", nil)))
+
+		// then
+		connection = nextValue(t, connections)
+		assert.Equal(t, "initialize", nextValue(t, connection.sent).(*JSONRPCRequest).Method)
+		connection.receive(&JSONRPCResponse{Jsonrpc: "2.0", Id: 0})
+		assert.Equal(t, "buffered", nextValue(t, connection.sent).(*JSONRPCNotification).Method)
+		assert.Empty(t, received, "the handshake response should not be passed to OnMessage")
+		assert.Equal(t, 1, handshakes)
+	})
+
+	t.Run("should give up after MaxAttempts", func(t *testing.T) {
+		// given
+		dial, connections := fakeDialer(-1)
+		options := fastReconnectOptions()
+		options.MaxAttempts = 3
+		transport := NewReconnectingTransport(ctx, dial, options)
+		closed := make(chan struct{})
+		transport.SetOnClose(func() { close(closed) })
+		require.NoError(t, transport.Start())
+		connection := nextValue(t, connections)
+
+		// when
+		connection.drop()
+
+		// then
+		nextValue(t, closed)
+		assert.Equal(t, ConnectionStateClosed, transport.State())
+		assert.Error(t, transport.Send(NewJSONRPCNotification("too late", nil)))
+	})
+
+	t.Run("should fail when the buffer is full", func(t *testing.T) {
+		options := fastReconnectOptions()
+		options.MaxBufferedMessages = 1
+		transport := NewReconnectingTransport(ctx, nil, options)
+
+		require.NoError(t, transport.Send(NewJSONRPCNotification("first", nil)))
+		assert.ErrorIs(t, transport.Send(NewJSONRPCNotification("second", nil)), ErrBufferFull)
+	})
+
+	t.Run("should apply the default options to zero fields", func(t *testing.T) {
+		// given
+		defaults := NewReconnectOptions()
+
+		// when
+		transport := NewReconnectingTransport(ctx, nil, ReconnectOptions{})
+
+		// then
+		for i := 0; i < defaults.MaxBufferedMessages; i++ {
+			require.NoError(t, transport.Send(NewJSONRPCNotification("buffered", nil)))
+		}
+		assert.ErrorIs(t, transport.Send(NewJSONRPCNotification("too many", nil)), ErrBufferFull)
+		assert.Equal(t, defaults.InitialBackoff, transport.backoff(0))
+		assert.Equal(t, defaults.MaxBackoff, transport.backoff(20))
+	})
+}
@@ -0,0 +1,82 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RequestHandlerExtra is passed to each RequestHandler with details of the request being handled,
+// and can be used to send notifications and requests to the peer while the request is in flight.
+type RequestHandlerExtra struct {
+	RequestId RequestId
+	// Meta contains the "_meta" params of the request, eg: the progressToken
+	Meta JSONRPCRequestParamsMeta
+	// SessionId identifies the connection, for transports which provide a SessionId() method
+	SessionId string
+	// AuthInfo is set if the peer was authenticated by the transport, see WithAuthInfo()
+	AuthInfo *AuthInfo
+
+	protocol *Protocol
+}
+
+// AuthInfo describes how the peer was authenticated.
+type AuthInfo struct {
+	// Token is the access token presented by the peer, eg: in an "Authorization: Bearer" header
+	Token    string
+	ClientId string
+	Scopes   []string
+	// ExpiresAt is zero if the token does not expire, or the expiry is not known
+	ExpiresAt time.Time
+	// Extra contains transport or provider specific details
+	Extra map[string]any
+}
+
+type authInfoKey struct{}
+
+// WithAuthInfo returns a context carrying the authentication of the peer,
+// so that it is available to request handlers as RequestHandlerExtra.AuthInfo.
+func WithAuthInfo(ctx context.Context, authInfo *AuthInfo) context.Context {
+	return context.WithValue(ctx, authInfoKey{}, authInfo)
+}
+
+// AuthInfoFromContext returns the authentication of the peer that sent the request being handled, if known.
+func AuthInfoFromContext(ctx context.Context) (*AuthInfo, bool) {
+	authInfo, ok := ctx.Value(authInfoKey{}).(*AuthInfo)
+	return authInfo, ok && authInfo != nil
+}
+
+// newRequestHandlerExtra is called for each request received.
+func (p *Protocol) newRequestHandlerExtra(ctx context.Context, request *JSONRPCRequest) *RequestHandlerExtra {
+	extra := &RequestHandlerExtra{
+		RequestId: request.Id,
+		protocol:  p,
+	}
+	if request.Params != nil && request.Params.Meta != nil {
+		extra.Meta = *request.Params.Meta
+	}
+	if transport, ok := p.transport.(interface{ SessionId() string }); ok {
+		extra.SessionId = transport.SessionId()
+	}
+	if authInfo, ok := AuthInfoFromContext(ctx); ok {
+		extra.AuthInfo = authInfo
+	}
+	return extra
+}
+
+// SendNotification sends a notification to the peer which sent the request.
+func (e *RequestHandlerExtra) SendNotification(method Method, params *JSONRPCNotificationParams) error {
+	if e == nil || e.protocol == nil {
+		return errors.New("not connected")
+	}
+	return e.protocol.SendNotification(method, params)
+}
+
+// SendRequest sends a request to the peer which sent the request, and waits for the response.
+// ctx should be the context passed to the handler, so that the nested request is abandoned if the request is cancelled.
+func (e *RequestHandlerExtra) SendRequest(ctx context.Context, method Method, params *JSONRPCRequestParams, result *Result) error {
+	if e == nil || e.protocol == nil {
+		return errors.New("not connected")
+	}
+	return e.protocol.SendRequest(ctx, method, params, result)
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package server
+
+import (
+	"net"
+	"syscall"
+)
+
+func getPeerCredentials(conn *net.UnixConn) (*PeerCredentials, error) {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var ucred *syscall.Ucred
+	var sockErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		ucred, sockErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+	if sockErr != nil {
+		return nil, sockErr
+	}
+
+	return &PeerCredentials{
+		Pid: ucred.Pid,
+		Uid: ucred.Uid,
+		Gid: ucred.Gid,
+	}, nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package server
+
+import "net"
+
+func getPeerCredentials(conn *net.UnixConn) (*PeerCredentials, error) {
+	return nil, ErrPeerCredentialsUnsupported
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+)
+
+var ErrPeerCredentialsUnsupported = errors.New("peer credentials are not supported on this platform")
+
+// PeerCredentials identifies the process on the other end of a Unix domain socket.
+type PeerCredentials struct {
+	Pid int32
+	Uid uint32
+	Gid uint32
+}
+
+// Server transport for a connection accepted from a TCP or Unix domain socket listener,
+// exchanging newline-delimited JSON-RPC messages.
+type SocketServerTransport struct {
+	*jsonrpc.ConnTransport
+	conn            net.Conn
+	peerCredentials *PeerCredentials
+}
+
+// NewSocketServerTransport wraps an accepted connection.
+// For Unix domain sockets, the credentials of the connecting process are read (using SO_PEERCRED on Linux).
+func NewSocketServerTransport(ctx context.Context, conn net.Conn) *SocketServerTransport {
+	t := &SocketServerTransport{
+		ConnTransport: jsonrpc.NewConnTransport(ctx, conn),
+		conn:          conn,
+	}
+
+	if unixConn, ok := conn.(*net.UnixConn); ok {
+		if creds, err := getPeerCredentials(unixConn); err == nil {
+			t.peerCredentials = creds
+		} else if !errors.Is(err, ErrPeerCredentialsUnsupported) {
+			jsonrpc.Logger.Printf("failed to read peer credentials: %v\n", err)
+		}
+	}
+
+	return t
+}
+
+// PeerCredentials returns the credentials of the connected process, or nil for TCP connections
+// and platforms where they are not available.
+func (t *SocketServerTransport) PeerCredentials() *PeerCredentials {
+	return t.peerCredentials
+}
+
+func (t *SocketServerTransport) RemoteAddr() net.Addr {
+	return t.conn.RemoteAddr()
+}
+
+type peerCredentialsKey struct{}
+
+// WithPeerCredentials returns a context carrying the credentials of the connected process,
+// so that they are available to request handlers.
+func WithPeerCredentials(ctx context.Context, creds *PeerCredentials) context.Context {
+	return context.WithValue(ctx, peerCredentialsKey{}, creds)
+}
+
+// PeerCredentialsFromContext returns the credentials of the process that sent the request being handled, if known.
+func PeerCredentialsFromContext(ctx context.Context) (*PeerCredentials, bool) {
+	creds, ok := ctx.Value(peerCredentialsKey{}).(*PeerCredentials)
+	return creds, ok && creds != nil
+}
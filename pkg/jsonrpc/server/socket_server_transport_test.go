@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/jsonrpc/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveMessage(t *testing.T, messages <-chan jsonrpc.JSONRPCMessage) jsonrpc.JSONRPCMessage {
+	t.Helper()
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	return nil
+}
+
+func TestSocketServerTransport(t *testing.T) {
+	ctx := context.Background()
+
+	// unix socket paths are limited to ~100 characters, so t.TempDir() may be too long
+	dir, err := os.MkdirTemp("", "mcp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("should exchange messages over a Unix domain socket", func(t *testing.T) {
+		// given
+		listener, err := net.Listen("unix", filepath.Join(dir, "test.sock"))
+		require.NoError(t, err)
+		defer listener.Close()
+
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				accepted <- conn
+			}
+		}()
+
+		clientTransport := client.NewSocketClientTransport(ctx, "unix", listener.Addr().String())
+		clientMessages := make(chan jsonrpc.JSONRPCMessage, 1)
+		clientTransport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
+			clientMessages <- message
+		})
+		require.NoError(t, clientTransport.Start())
+
+		serverTransport := NewSocketServerTransport(ctx, <-accepted)
+		serverMessages := make(chan jsonrpc.JSONRPCMessage, 1)
+		serverTransport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
+			serverMessages <- message
+		})
+		require.NoError(t, serverTransport.Start())
+
+		// when
+		require.NoError(t, clientTransport.Send(&jsonrpc.JSONRPCRequest{Jsonrpc: "2.0", Id: 1, Method: "ping"}))
+		request := receiveMessage(t, serverMessages)
+		require.NoError(t, serverTransport.Send(jsonrpc.NewJSONRPCError(1, jsonrpc.JSONRPCErrorError{Code: -1, Message: "pong"})))
+		response := receiveMessage(t, clientMessages)
+
+		// then
+		assert.Equal(t, "ping", request.(*jsonrpc.JSONRPCRequest).Method)
+		assert.Equal(t, "pong", response.(jsonrpc.JSONRPCError).Error.Message)
+
+		if runtime.GOOS == "linux" {
+			require.NotNil(t, serverTransport.PeerCredentials())
+			assert.Equal(t, int32(os.Getpid()), serverTransport.PeerCredentials().Pid)
+			assert.Equal(t, uint32(os.Getuid()), serverTransport.PeerCredentials().Uid)
+		}
+
+		// and when the client disconnects, the server transport is closed
+		serverClosed := make(chan struct{})
+		serverTransport.SetOnClose(func() { close(serverClosed) })
+		require.NoError(t, clientTransport.Close())
+
+		select {
+		case <-serverClosed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for server transport to close")
+		}
+	})
+
+	t.Run("should not have peer credentials for TCP connections", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		go func() {
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err == nil {
+				defer conn.Close()
+			}
+		}()
+		conn, err := listener.Accept()
+		require.NoError(t, err)
+
+		transport := NewSocketServerTransport(ctx, conn)
+		defer transport.Close()
+
+		assert.Nil(t, transport.PeerCredentials())
+	})
+
+	t.Run("should make peer credentials available from the context", func(t *testing.T) {
+		creds := &PeerCredentials{Pid: 1}
+
+		actual, ok := PeerCredentialsFromContext(WithPeerCredentials(ctx, creds))
+		assert.True(t, ok)
+		assert.Same(t, creds, actual)
+
+		_, ok = PeerCredentialsFromContext(ctx)
+		assert.False(t, ok)
+	})
+}
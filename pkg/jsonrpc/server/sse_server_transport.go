@@ -23,9 +23,12 @@ type SSEServerTransport struct {
 	cancel      context.CancelFunc
 	endpoint    string
 	session     *sse.ServerSSESession
+	eventStore  sse.EventStore
 	initialized bool
 	sessionId   string
-	mu          sync.Mutex
+	// detached is closed when the session attached by Resume() is replaced by a later Resume()
+	detached chan struct{}
+	mu       sync.Mutex
 }
 
 func NewSSEServerTransport(ctx context.Context, endpoint string, session *sse.ServerSSESession) *SSEServerTransport {
@@ -41,6 +44,18 @@ func NewSSEServerTransport(ctx context.Context, endpoint string, session *sse.Se
 	}
 }
 
+// WithEventStore enables resumable streams: each message is given an event ID and kept in the store
+// so that it can be replayed by Resume() when the client reconnects with a Last-Event-ID header.
+func (s *SSEServerTransport) WithEventStore(eventStore sse.EventStore) *SSEServerTransport {
+	s.eventStore = eventStore
+	return s
+}
+
+// SessionId identifies this transport in the endpoint URL, and in the IDs of the events it sends.
+func (s *SSEServerTransport) SessionId() string {
+	return s.sessionId
+}
+
 // Handles the initial SSE connection request
 // This should be called when a GET request is made to establish the SSE stream
 func (s *SSEServerTransport) Start() error {
@@ -49,25 +64,99 @@ func (s *SSEServerTransport) Start() error {
 		s.mu.Unlock()
 		return errors.New("SSEServerTransport already started")
 	}
+	// the endpoint is sent before releasing the lock so that it is always the first event on the stream
+	if err := s.sendEndpoint(s.session); err != nil {
+		s.mu.Unlock()
+		return err
+	}
 	s.initialized = true
 	s.mu.Unlock()
 
+	<-s.ctx.Done()
+	if s.OnClose != nil {
+		s.OnClose()
+	}
+	return nil
+}
+
+func (s *SSEServerTransport) sendEndpoint(session *sse.ServerSSESession) error {
 	data := fmt.Sprintf("%s?%s=%s", s.endpoint, SESSION_ID_PARAM, s.sessionId)
-	if err := s.session.Send(
+	return session.Send(
 		sse.NewServerSentEvent().
 			WithEvent("endpoint").
 			WithData(data),
-	); err != nil {
+	)
+}
+
+// Resume attaches a new SSE connection for a client that reconnected with a Last-Event-ID header,
+// replays the messages sent after that event and continues sending on the new connection.
+// EventStore.StreamIDForEventID() returns the SessionId() of the transport that a Last-Event-ID belongs to.
+// Resume returns once the connection is attached, use HandleResume() to keep an HTTP handler open.
+func (s *SSEServerTransport) Resume(session *sse.ServerSSESession, lastEventID string) error {
+	_, err := s.resume(session, lastEventID)
+	return err
+}
+
+// HandleResume resumes the stream on session, as Resume() does, and then blocks until ctx is done,
+// the transport is closed or the client reconnects again.
+// This should be called from the handler of the reconnected GET request with the request's context.
+func (s *SSEServerTransport) HandleResume(ctx context.Context, session *sse.ServerSSESession, lastEventID string) error {
+	detached, err := s.resume(session, lastEventID)
+	if err != nil {
 		return err
 	}
 
-	<-s.ctx.Done()
-	if s.OnClose != nil {
-		s.OnClose()
+	select {
+	case <-ctx.Done():
+	case <-s.ctx.Done():
+	case <-detached:
 	}
 	return nil
 }
 
+func (s *SSEServerTransport) resume(session *sse.ServerSSESession, lastEventID string) (<-chan struct{}, error) {
+	if s.eventStore == nil {
+		return nil, errors.New("SSEServerTransport is not resumable, no EventStore was provided")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.initialized {
+		return nil, errors.New("not connected")
+	}
+
+	if streamId, err := s.eventStore.StreamIDForEventID(lastEventID); err != nil {
+		return nil, err
+	} else if streamId != s.sessionId {
+		return nil, fmt.Errorf("event %s does not belong to session %s", lastEventID, s.sessionId)
+	}
+
+	if err := s.sendEndpoint(session); err != nil {
+		return nil, err
+	}
+
+	if err := s.eventStore.ReplayEventsAfter(lastEventID, func(eventID string, data string) error {
+		return session.Send(sse.NewServerSentEvent().
+			WithID(eventID).
+			WithEvent("message").
+			WithData(data))
+	}); err != nil {
+		return nil, err
+	}
+
+	previous := s.session
+	s.session = session
+	if previous != nil {
+		previous.Close()
+	}
+
+	if s.detached != nil {
+		close(s.detached)
+	}
+	s.detached = make(chan struct{})
+	return s.detached, nil
+}
+
 // HandlePostMessage handles POST requests to the SSE endpoint.
 // This assumes that the body of the POST request is a JSONRPC message.
 func (s *SSEServerTransport) HandlePostMessage(w http.ResponseWriter, r *http.Request) {
@@ -143,7 +232,20 @@ func (s *SSEServerTransport) handleMessage(message []byte) error {
 
 func (s *SSEServerTransport) Close() error {
 	s.cancel()
-	if err := s.session.Close(); err != nil {
+
+	// Resume() may replace the session concurrently, and Send() may be writing to it
+	s.mu.Lock()
+	session := s.session
+	streamId := s.sessionId
+	err := session.Close()
+	s.mu.Unlock()
+
+	if s.eventStore != nil {
+		if err := s.eventStore.RemoveStream(streamId); err != nil {
+			return err
+		}
+	}
+	if err != nil {
 		return err
 	}
 	if s.OnClose != nil {
@@ -164,7 +266,18 @@ func (s *SSEServerTransport) Send(message jsonrpc.JSONRPCMessage) error {
 		return err
 	}
 
-	return s.session.Send(sse.NewServerSentEvent().
+	event := sse.NewServerSentEvent().
 		WithEvent("message").
-		WithData(string(data)))
+		WithData(string(data))
+
+	if s.eventStore != nil {
+		// the message is stored before it is sent, so that it can be replayed if the client never receives it
+		eventID, err := s.eventStore.StoreEvent(s.sessionId, string(data))
+		if err != nil {
+			return err
+		}
+		event.WithID(eventID)
+	}
+
+	return s.session.Send(event)
 }
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/sse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startSSEServerTransport(t *testing.T, transport *SSEServerTransport) {
+	t.Helper()
+	go transport.Start()
+	require.Eventually(t, func() bool {
+		transport.mu.Lock()
+		defer transport.mu.Unlock()
+		return transport.initialized
+	}, time.Second, time.Millisecond)
+}
+
+func TestSSEServerTransport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should not send event IDs without an EventStore", func(t *testing.T) {
+		session, _ := sse.NewServerSSESession(&sse.SSESessionOptions{Buffered: true})
+		transport := NewSSEServerTransport(ctx, "/messages", session)
+		startSSEServerTransport(t, transport)
+		defer transport.Close()
+
+		err := transport.Send(jsonrpc.NewJSONRPCNotification("test", nil))
+
+		require.NoError(t, err)
+		assert.Contains(t, session.String(), "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"test\"}\n\n")
+		assert.NotContains(t, session.String(), "id:")
+	})
+
+	t.Run("should replay missed messages when resumed", func(t *testing.T) {
+		// given a resumable transport which has sent two messages
+		store := sse.NewInMemoryEventStore(100)
+		session, _ := sse.NewServerSSESession(&sse.SSESessionOptions{Buffered: true})
+		transport := NewSSEServerTransport(ctx, "/messages", session).WithEventStore(store)
+		startSSEServerTransport(t, transport)
+		defer transport.Close()
+
+		require.NoError(t, transport.Send(jsonrpc.NewJSONRPCNotification("first", nil)))
+		require.NoError(t, transport.Send(jsonrpc.NewJSONRPCNotification("second", nil)))
+
+		firstID := fmt.Sprintf("%s_1", transport.SessionId())
+		assert.Contains(t, session.String(), "id: "+firstID+"\n")
+
+		// when the client reconnects having only received the first message
+		streamID, err := store.StreamIDForEventID(firstID)
+		require.NoError(t, err)
+		assert.Equal(t, transport.SessionId(), streamID)
+
+		resumed, _ := sse.NewServerSSESession(&sse.SSESessionOptions{Buffered: true})
+		err = transport.Resume(resumed, firstID)
+		require.NoError(t, err)
+
+		// then the endpoint and the second message are sent on the new connection
+		output := resumed.String()
+		assert.Contains(t, output, "event: endpoint\ndata: /messages?sessionId="+transport.SessionId()+"\n\n")
+		assert.Contains(t, output, "id: "+transport.SessionId()+"_2\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"second\"}\n\n")
+		assert.NotContains(t, output, "first")
+
+		// and subsequent messages are sent on the new connection
+		require.NoError(t, transport.Send(jsonrpc.NewJSONRPCNotification("third", nil)))
+		assert.Contains(t, resumed.String(), "id: "+transport.SessionId()+"_3\n")
+	})
+
+	t.Run("should reject event IDs from another session", func(t *testing.T) {
+		store := sse.NewInMemoryEventStore(100)
+		session, _ := sse.NewServerSSESession(&sse.SSESessionOptions{Buffered: true})
+		transport := NewSSEServerTransport(ctx, "/messages", session).WithEventStore(store)
+		startSSEServerTransport(t, transport)
+		defer transport.Close()
+
+		resumed, _ := sse.NewServerSSESession(&sse.SSESessionOptions{Buffered: true})
+		err := transport.Resume(resumed, "other-session_1")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should not be resumable without an EventStore", func(t *testing.T) {
+		session, _ := sse.NewServerSSESession(&sse.SSESessionOptions{Buffered: true})
+		transport := NewSSEServerTransport(ctx, "/messages", session)
+
+		resumed, _ := sse.NewServerSSESession(&sse.SSESessionOptions{Buffered: true})
+		err := transport.Resume(resumed, "x_1")
+
+		assert.Error(t, err)
+	})
+	t.Run("should resume the stream when the client reconnects over HTTP", func(t *testing.T) {
+		// given an SSE endpoint which resumes the stream when a Last-Event-ID header is sent
+		store := sse.NewInMemoryEventStore(100)
+		transports := make(chan *SSEServerTransport, 1)
+		var transport *SSEServerTransport
+		resumeDone := make(chan error, 1)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+
+			session, reader := sse.NewServerSSESession(&sse.SSESessionOptions{Buffered: false})
+			copied := make(chan struct{})
+			defer func() {
+				// the handler must not return while the response is still being written
+				reader.(*io.PipeReader).Close()
+				<-copied
+			}()
+			go func() {
+				defer close(copied)
+				buf := make([]byte, 1024)
+				for {
+					n, err := reader.Read(buf)
+					if err != nil {
+						return
+					}
+					if _, err := w.Write(buf[:n]); err != nil {
+						reader.(*io.PipeReader).CloseWithError(err)
+						return
+					}
+					w.(http.Flusher).Flush()
+				}
+			}()
+
+			if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+				resumeDone <- transport.HandleResume(r.Context(), session, lastEventID)
+				return
+			}
+
+			created := NewSSEServerTransport(ctx, "/messages", session).WithEventStore(store)
+			transports <- created
+			created.Start()
+		}))
+		defer server.Close()
+
+		connect := func(lastEventID string) (*http.Response, *sse.EventReader) {
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+			if lastEventID != "" {
+				req.Header.Set("Last-Event-ID", lastEventID)
+			}
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			return res, sse.NewEventReader(res.Body)
+		}
+
+		readEvent := func(reader *sse.EventReader) *sse.ServerSentEvent {
+			event, err := reader.ReadEvent()
+			require.NoError(t, err)
+			return event
+		}
+
+		res, reader := connect("")
+		transport = <-transports
+		defer transport.Close()
+
+		endpoint := readEvent(reader)
+		assert.Equal(t, "endpoint", *endpoint.Event)
+		require.NoError(t, transport.Send(jsonrpc.NewJSONRPCNotification("first", nil)))
+		first := readEvent(reader)
+		assert.Contains(t, *first.Data, "first")
+
+		// when the connection drops, and a message is sent while the client is disconnected
+		res.Body.Close()
+		_ = transport.Send(jsonrpc.NewJSONRPCNotification("second", nil))
+
+		res, reader = connect(*first.ID)
+
+		// then the endpoint and the missed message are sent on the new connection
+		endpoint = readEvent(reader)
+		assert.Equal(t, "endpoint", *endpoint.Event)
+		assert.True(t, strings.HasSuffix(*endpoint.Data, transport.SessionId()))
+
+		second := readEvent(reader)
+		assert.Equal(t, transport.SessionId()+"_2", *second.ID)
+		assert.Contains(t, *second.Data, "second")
+
+		// and later messages are sent on the new connection
+		require.NoError(t, transport.Send(jsonrpc.NewJSONRPCNotification("third", nil)))
+		third := readEvent(reader)
+		assert.Contains(t, *third.Data, "third")
+
+		// and the handler returns when the client disconnects again
+		res.Body.Close()
+		select {
+		case err := <-resumeDone:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("HandleResume did not return after the client disconnected")
+		}
+	})
+}
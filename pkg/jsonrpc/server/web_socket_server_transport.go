@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/websocket"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// Server transport for WebSocket, created by upgrading an HTTP request which offers the `mcp` subprotocol.
+type WebSocketServerTransport struct {
+	*shared.WebSocketTransport
+}
+
+// NewWebSocketServerTransport upgrades the request to a WebSocket connection.
+// If the client does not offer the `mcp` subprotocol or the upgrade fails, an HTTP error has been written to `w`.
+// `upgrader` may be nil, or may be provided to check the Origin header of browser clients, set buffer sizes etc.
+func NewWebSocketServerTransport(
+	ctx context.Context,
+	w http.ResponseWriter,
+	r *http.Request,
+	upgrader *websocket.Upgrader,
+	options shared.WebSocketOptions,
+) (*WebSocketServerTransport, error) {
+	if !slices.Contains(websocket.Subprotocols(r), shared.MCP_SUBPROTOCOL) {
+		http.Error(w, "WebSocket subprotocol "+shared.MCP_SUBPROTOCOL+" is required", http.StatusBadRequest)
+		return nil, fmt.Errorf("client did not offer the %s WebSocket subprotocol", shared.MCP_SUBPROTOCOL)
+	}
+
+	if upgrader == nil {
+		upgrader = &websocket.Upgrader{}
+	}
+	mcpUpgrader := *upgrader
+	mcpUpgrader.Subprotocols = []string{shared.MCP_SUBPROTOCOL}
+
+	conn, err := mcpUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebSocketServerTransport{
+		WebSocketTransport: shared.NewWebSocketTransport(ctx, conn, options),
+	}, nil
+}
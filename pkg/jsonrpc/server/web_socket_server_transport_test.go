@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/jsonrpc/client"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startWebSocketServer serves a single WebSocket connection and returns the URL and the server transport.
+func startWebSocketServer(t *testing.T, options shared.WebSocketOptions) (string, <-chan *WebSocketServerTransport) {
+	t.Helper()
+	transports := make(chan *WebSocketServerTransport, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transport, err := NewWebSocketServerTransport(context.Background(), w, r, nil, options)
+		if err != nil {
+			return
+		}
+		transports <- transport
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), transports
+}
+
+func receiveServerTransport(t *testing.T, transports <-chan *WebSocketServerTransport) *WebSocketServerTransport {
+	t.Helper()
+	select {
+	case transport := <-transports:
+		return transport
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for WebSocket connection")
+	}
+	return nil
+}
+
+func waitForDone(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the transport to close")
+	}
+}
+
+func TestWebSocketServerTransport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should exchange messages using the mcp subprotocol", func(t *testing.T) {
+		// given
+		url, transports := startWebSocketServer(t, shared.NewWebSocketOptions())
+		clientTransport := client.NewWebSocketClientTransport(ctx, url, nil, shared.NewWebSocketOptions())
+		clientMessages := make(chan jsonrpc.JSONRPCMessage, 1)
+		clientTransport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
+			clientMessages <- message
+		})
+		require.NoError(t, clientTransport.Start())
+		defer clientTransport.Close()
+
+		serverTransport := receiveServerTransport(t, transports)
+		serverMessages := make(chan jsonrpc.JSONRPCMessage, 1)
+		serverTransport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
+			serverMessages <- message
+		})
+		require.NoError(t, serverTransport.Start())
+
+		// when
+		require.NoError(t, clientTransport.Send(&jsonrpc.JSONRPCRequest{Jsonrpc: "2.0", Id: 1, Method: "ping"}))
+		request := receiveMessage(t, serverMessages)
+		require.NoError(t, serverTransport.Send(jsonrpc.NewJSONRPCNotification("pong", nil)))
+		notification := receiveMessage(t, clientMessages)
+
+		// then
+		assert.Equal(t, shared.MCP_SUBPROTOCOL, serverTransport.Subprotocol())
+		assert.Equal(t, "ping", request.(*jsonrpc.JSONRPCRequest).Method)
+		assert.Equal(t, "pong", notification.(*jsonrpc.JSONRPCNotification).Method)
+	})
+
+	t.Run("should reject clients which do not offer the mcp subprotocol", func(t *testing.T) {
+		url, _ := startWebSocketServer(t, shared.NewWebSocketOptions())
+
+		_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+
+		assert.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("should send the close code to the other side", func(t *testing.T) {
+		url, transports := startWebSocketServer(t, shared.NewWebSocketOptions())
+		conn, _, err := (&websocket.Dialer{Subprotocols: []string{shared.MCP_SUBPROTOCOL}}).Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		serverTransport := receiveServerTransport(t, transports)
+		require.NoError(t, serverTransport.Start())
+
+		require.NoError(t, serverTransport.CloseWithCode(websocket.ClosePolicyViolation, "unauthorized"))
+
+		_, _, err = conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "unexpected error: %v", err)
+	})
+
+	t.Run("should close the connection when a message is too big", func(t *testing.T) {
+		options := shared.NewWebSocketOptions()
+		options.MaxMessageSize = 100
+		url, transports := startWebSocketServer(t, options)
+		conn, _, err := (&websocket.Dialer{Subprotocols: []string{shared.MCP_SUBPROTOCOL}}).Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		serverTransport := receiveServerTransport(t, transports)
+		errs := make(chan error, 1)
+		serverTransport.SetOnError(func(err error) { errs <- err })
+		require.NoError(t, serverTransport.Start())
+
+		// when
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"`+strings.Repeat("x", 100)+`"}`)))
+
+		// then
+		_, _, err = conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), "unexpected error: %v", err)
+		waitForDone(t, serverTransport.Done())
+		assert.ErrorContains(t, <-errs, "exceeds 100 bytes")
+	})
+
+	t.Run("should close the connection if the client does not respond to pings", func(t *testing.T) {
+		options := shared.NewWebSocketOptions()
+		options.PingInterval = 20 * time.Millisecond
+		options.PongTimeout = 20 * time.Millisecond
+		url, transports := startWebSocketServer(t, options)
+
+		// a client which never reads, so never responds to pings
+		conn, _, err := (&websocket.Dialer{Subprotocols: []string{shared.MCP_SUBPROTOCOL}}).Dial(url, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		serverTransport := receiveServerTransport(t, transports)
+		require.NoError(t, serverTransport.Start())
+
+		waitForDone(t, serverTransport.Done())
+	})
+
+	t.Run("should stay connected while the client responds to pings", func(t *testing.T) {
+		options := shared.NewWebSocketOptions()
+		options.PingInterval = 20 * time.Millisecond
+		options.PongTimeout = 20 * time.Millisecond
+		url, transports := startWebSocketServer(t, options)
+
+		clientTransport := client.NewWebSocketClientTransport(ctx, url, nil, options)
+		require.NoError(t, clientTransport.Start())
+		defer clientTransport.Close()
+
+		serverTransport := receiveServerTransport(t, transports)
+		require.NoError(t, serverTransport.Start())
+
+		select {
+		case <-serverTransport.Done():
+			t.Fatal("server transport closed while the client was responding to pings")
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type CircuitState int
+
+const (
+	// CircuitClosed allows all requests.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests fast until the cooldown has elapsed.
+	CircuitOpen
+	// CircuitHalfOpen allows a single probe request, which closes the circuit if it succeeds or re-opens it if it fails.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures which open the circuit.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a probe request is allowed.
+	Cooldown time.Duration
+	// IsFailure reports whether an error indicates that the server is unhealthy, defaults to IsTransientError.
+	// Other errors, eg: InvalidParams, show that the server is responding and count as a success.
+	IsFailure func(err error) bool
+}
+
+func NewCircuitBreakerOptions() CircuitBreakerOptions {
+	return CircuitBreakerOptions{
+		FailureThreshold: 5,
+		Cooldown:         30 * time.Second,
+	}
+}
+
+// CircuitBreaker stops requests being sent to a server which keeps failing, so that callers fail fast.
+type CircuitBreaker struct {
+	options  CircuitBreakerOptions
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+func NewCircuitBreaker(options CircuitBreakerOptions) *CircuitBreaker {
+	return &CircuitBreaker{
+		options: options,
+		now:     time.Now,
+	}
+}
+
+// State returns the current state, which moves from open to half-open once the cooldown has elapsed.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.options.Cooldown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Allow returns ErrCircuitOpen if a request should not be sent.
+// Every allowed request must be followed by a call to Record() with its result.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.options.Cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record updates the circuit with the result of a request.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !b.isFailure(err) {
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.options.FailureThreshold {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+func (b *CircuitBreaker) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if b.options.IsFailure != nil {
+		return b.options.IsFailure(err)
+	}
+	return IsTransientError(err)
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	serverError := jsonrpc.NewJSONRPCErrorError(0, jsonrpc.InternalError, "unavailable", nil)
+
+	newCircuitBreaker := func() (*CircuitBreaker, *time.Time) {
+		now := time.Now()
+		breaker := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 3, Cooldown: time.Minute})
+		breaker.now = func() time.Time { return now }
+		return breaker, &now
+	}
+
+	t.Run("should open after consecutive failures", func(t *testing.T) {
+		breaker, _ := newCircuitBreaker()
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, breaker.Allow())
+			breaker.Record(serverError)
+		}
+
+		assert.Equal(t, CircuitOpen, breaker.State())
+		assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+	})
+
+	t.Run("should not count errors which show the server is responding", func(t *testing.T) {
+		breaker, _ := newCircuitBreaker()
+
+		for i := 0; i < 5; i++ {
+			breaker.Record(serverError)
+			breaker.Record(jsonrpc.NewJSONRPCErrorError(0, jsonrpc.InvalidParams, "bad", nil))
+		}
+
+		assert.Equal(t, CircuitClosed, breaker.State())
+	})
+
+	t.Run("should allow a single probe after the cooldown", func(t *testing.T) {
+		// given
+		breaker, now := newCircuitBreaker()
+		for i := 0; i < 3; i++ {
+			breaker.Record(serverError)
+		}
+
+		// when
+		*now = now.Add(time.Minute)
+
+		// then
+		assert.Equal(t, CircuitHalfOpen, breaker.State())
+		require.NoError(t, breaker.Allow())
+		assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen, "only one probe should be allowed")
+	})
+
+	t.Run("should close when the probe succeeds", func(t *testing.T) {
+		breaker, now := newCircuitBreaker()
+		for i := 0; i < 3; i++ {
+			breaker.Record(serverError)
+		}
+		*now = now.Add(time.Minute)
+		require.NoError(t, breaker.Allow())
+
+		breaker.Record(nil)
+
+		assert.Equal(t, CircuitClosed, breaker.State())
+		assert.NoError(t, breaker.Allow())
+	})
+
+	t.Run("should re-open when the probe fails", func(t *testing.T) {
+		breaker, now := newCircuitBreaker()
+		for i := 0; i < 3; i++ {
+			breaker.Record(serverError)
+		}
+		*now = now.Add(time.Minute)
+		require.NoError(t, breaker.Allow())
+
+		breaker.Record(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+
+		assert.Equal(t, CircuitOpen, breaker.State())
+		assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+	})
+}
@@ -2,8 +2,11 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"slices"
+	"sync"
+	"time"
 
 	"github.com/nalbion/go-mcp/pkg/jsonrpc"
 	"github.com/nalbion/go-mcp/pkg/mcp"
@@ -16,6 +19,21 @@ type ClientOptions struct {
 	// Whether to strictly enforce capabilities when interacting with the server
 	// defaults to true
 	EnforceStrictCapabilities *bool
+	// RetryPolicies are applied to failed requests by method, eg: DefaultRetryPolicies().
+	// Requests for other methods are not retried.
+	RetryPolicies map[jsonrpc.Method]RetryPolicy
+	// IsIdempotentTool reports whether a call to the named tool may be retried using the tools/call RetryPolicy.
+	// Tool calls are never retried without it, as tools may have side effects, unless TrustToolAnnotations is set.
+	IsIdempotentTool func(name string) bool
+	// TrustToolAnnotations allows the annotations of tools returned by ListTools() to be used for client-side policies,
+	// eg: tools/call is retried for tools which are read-only or idempotent if IsIdempotentTool is not set.
+	// Annotations are only hints, so should only be trusted from trusted servers.
+	TrustToolAnnotations bool
+	// CircuitBreaker fails requests fast after consecutive failures, until the server responds to a probe request.
+	CircuitBreaker *CircuitBreakerOptions
+	// ValidateToolOutput checks the structuredContent of CallTool() results against the tool's OutputSchema,
+	// for tools returned by ListTools(). An invalid result is returned with an *mcp.OutputValidationError.
+	ValidateToolOutput bool
 }
 
 // An MCP client on top of a pluggable transport.
@@ -31,9 +49,20 @@ type Client struct {
 	ctx          context.Context
 	clientInfo   mcp.Implementation
 	capabilities mcp.ClientCapabilities
+	// retryPolicies and isIdempotentTool are from ClientOptions
+	retryPolicies    map[jsonrpc.Method]RetryPolicy
+	isIdempotentTool func(name string) bool
+	circuitBreaker   *CircuitBreaker
+	// validateToolOutput and trustToolAnnotations are from ClientOptions, and tools are those returned by ListTools(), by name
+	validateToolOutput   bool
+	trustToolAnnotations bool
+	tools                map[string]mcp.Tool
+	toolsMutex           sync.Mutex
 	// after the initialization process completes, this will contain the server's capabilities
 	ServerCapabilities *mcp.ServerCapabilities
 	ServerVersion      string
+	// ServerInstructions describe how to use the server, if it sent any
+	ServerInstructions string
 }
 
 func NewClient(
@@ -53,9 +82,17 @@ func NewClient(
 				EnforceStrictCapabilities: enforceStrictCapabilities,
 			},
 		),
-		ctx:          ctx,
-		clientInfo:   clientInfo,
-		capabilities: options.Capabilities,
+		ctx:                  ctx,
+		clientInfo:           clientInfo,
+		capabilities:         options.Capabilities,
+		retryPolicies:        options.RetryPolicies,
+		isIdempotentTool:     options.IsIdempotentTool,
+		validateToolOutput:   options.ValidateToolOutput,
+		trustToolAnnotations: options.TrustToolAnnotations,
+		tools:                map[string]mcp.Tool{},
+	}
+	if options.CircuitBreaker != nil {
+		c.circuitBreaker = NewCircuitBreaker(*options.CircuitBreaker)
 	}
 
 	// c.Protocol.SetContext(ctx)
@@ -79,13 +116,7 @@ func (c *Client) Connect(transport jsonrpc.Transport) error {
 	result := &mcp.InitializeResult{}
 	err := c.SendRequest(
 		shared.InitializeMethod,
-		&jsonrpc.JSONRPCRequestParams{
-			AdditionalProperties: mcp.InitializeRequestParams{
-				ProtocolVersion: shared.LatestProtocolVersion,
-				Capabilities:    c.capabilities,
-				ClientInfo:      c.clientInfo,
-			},
-		},
+		c.initializeParams(),
 		&jsonrpc.Result{
 			AdditionalProperties: result,
 		},
@@ -94,21 +125,104 @@ func (c *Client) Connect(transport jsonrpc.Transport) error {
 		return err
 	}
 
+	if err := c.initialized(result); err != nil {
+		return err
+	}
+	connected = true
+
+	err = c.SendNotification(shared.NotificationsInitializedMethod, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Handshake repeats the initialization handshake on a new connection. It can be used as jsonrpc.ReconnectOptions.Handshake
+// when connecting through a jsonrpc.ReconnectingTransport to a server which does not keep the session when the connection drops.
+func (c *Client) Handshake(ctx context.Context, send func(message jsonrpc.JSONRPCMessage) error, receive <-chan jsonrpc.JSONRPCMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, shared.DEFAULT_REQUEST_TIMEOUT)
+	defer cancel()
+
+	// nothing else is sent on the new connection until the handshake is complete, so the ID can not clash with other requests
+	var id jsonrpc.RequestId = 0
+	if err := send(&jsonrpc.JSONRPCRequest{
+		Jsonrpc: "2.0",
+		Id:      id,
+		Method:  string(shared.InitializeMethod),
+		Params:  c.initializeParams(),
+	}); err != nil {
+		return err
+	}
+
+	for {
+		var message jsonrpc.JSONRPCMessage
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case message = <-receive:
+		}
+
+		switch message := message.(type) {
+		case jsonrpc.JSONRPCError:
+			if message.Id == id {
+				return &message.Error
+			}
+		case *jsonrpc.JSONRPCError:
+			if message.Id == id {
+				return &message.Error
+			}
+		case jsonrpc.JSONRPCResponse:
+			if message.Id == id {
+				return c.handshakeResponse(&message, send)
+			}
+		case *jsonrpc.JSONRPCResponse:
+			if message.Id == id {
+				return c.handshakeResponse(message, send)
+			}
+		}
+	}
+}
+
+func (c *Client) handshakeResponse(response *jsonrpc.JSONRPCResponse, send func(message jsonrpc.JSONRPCMessage) error) error {
+	content, err := json.Marshal(response.Result.AdditionalProperties)
+	if err != nil {
+		return err
+	}
+	result := &mcp.InitializeResult{}
+	if err := json.Unmarshal(content, result); err != nil {
+		return err
+	}
+
+	if err := c.initialized(result); err != nil {
+		return err
+	}
+
+	return send(jsonrpc.NewJSONRPCNotification(shared.NotificationsInitializedMethod, nil))
+}
+
+func (c *Client) initializeParams() *jsonrpc.JSONRPCRequestParams {
+	return &jsonrpc.JSONRPCRequestParams{
+		AdditionalProperties: mcp.InitializeRequestParams{
+			ProtocolVersion: shared.LatestProtocolVersion,
+			Capabilities:    c.capabilities,
+			ClientInfo:      c.clientInfo,
+		},
+	}
+}
+
+func (c *Client) initialized(result *mcp.InitializeResult) error {
 	if !slices.Contains(shared.SupportedProtocolVersions, result.ProtocolVersion) {
 		return fmt.Errorf("server's protocol version is not supported: %s", result.ProtocolVersion)
 	}
 
-	connected = true
 	shared.Logger.Printf("Connected to MCP server: %s\n", result.ServerInfo.Name)
 
 	c.ServerCapabilities = &result.Capabilities
 	c.ServerVersion = result.ServerInfo.Version
-
-	err = c.SendNotification(shared.NotificationsInitializedMethod, nil)
-	if err != nil {
-		return err
+	if result.Instructions != nil {
+		c.ServerInstructions = *result.Instructions
 	}
-
 	return nil
 }
 
@@ -220,7 +334,7 @@ func (c *Client) UnsubscribeResources(params mcp.UnsubscribeRequestParams, optio
 }
 
 func (c *Client) ListTools(params mcp.ListToolsRequestParams, result *mcp.ListToolsResult, options *mcp.RequestOptions) error {
-	return c.SendRequest(
+	err := c.SendRequest(
 		shared.ToolsListMethod,
 		&jsonrpc.JSONRPCRequestParams{
 			AdditionalProperties: params,
@@ -229,10 +343,23 @@ func (c *Client) ListTools(params mcp.ListToolsRequestParams, result *mcp.ListTo
 			AdditionalProperties: result,
 		},
 		options)
+	if err != nil {
+		return err
+	}
+
+	// remember the tools for CallTool(), adding to those from previous pages
+	c.toolsMutex.Lock()
+	defer c.toolsMutex.Unlock()
+	for _, tool := range result.Tools {
+		c.tools[tool.Name] = tool
+	}
+	return nil
 }
 
+// CallTool calls a tool on the server.
+// If ClientOptions.ValidateToolOutput is set, the structuredContent of the result is checked against the tool's OutputSchema.
 func (c *Client) CallTool(params mcp.CallToolRequestParams, result *mcp.CallToolResult, options *mcp.RequestOptions) error {
-	return c.SendRequest(
+	err := c.SendRequest(
 		shared.ToolsCallMethod,
 		&jsonrpc.JSONRPCRequestParams{
 			AdditionalProperties: params,
@@ -241,6 +368,61 @@ func (c *Client) CallTool(params mcp.CallToolRequestParams, result *mcp.CallTool
 			AdditionalProperties: result,
 		},
 		options)
+	if err != nil || !c.validateToolOutput {
+		return err
+	}
+
+	tool, ok := c.Tool(params.Name)
+	if !ok {
+		return nil
+	}
+	return tool.ValidateStructuredContent(*result)
+}
+
+// Tool returns a tool returned by ListTools(), eg: to check its annotations before calling it.
+func (c *Client) Tool(name string) (mcp.Tool, bool) {
+	c.toolsMutex.Lock()
+	defer c.toolsMutex.Unlock()
+	tool, ok := c.tools[name]
+	return tool, ok
+}
+
+// ToolRequiresConfirmation reports whether the user should confirm a call to the tool before it is made.
+// Unless ClientOptions.TrustToolAnnotations is set, or the tool is unknown, every tool requires confirmation.
+func (c *Client) ToolRequiresConfirmation(name string) bool {
+	tool, ok := c.Tool(name)
+	return !ok || !c.trustToolAnnotations || tool.RequiresConfirmation()
+}
+
+// IsIdempotentTool reports whether a call to the tool may be retried,
+// using ClientOptions.IsIdempotentTool if set, otherwise the tool's annotations if ClientOptions.TrustToolAnnotations is set.
+func (c *Client) IsIdempotentTool(name string) bool {
+	if c.isIdempotentTool != nil {
+		return c.isIdempotentTool(name)
+	}
+	if !c.trustToolAnnotations {
+		return false
+	}
+	tool, ok := c.Tool(name)
+	return ok && tool.IsSafeToRetry()
+}
+
+// CallToolStructured calls a tool on the server and decodes the structuredContent of the result into Out.
+// The result is also returned, eg: for its text content.
+func CallToolStructured[Out any](c *Client, params mcp.CallToolRequestParams, options *mcp.RequestOptions) (Out, *mcp.CallToolResult, error) {
+	var out Out
+	result := &mcp.CallToolResult{}
+	if err := c.CallTool(params, result, options); err != nil {
+		return out, result, err
+	}
+	if result.IsError != nil && *result.IsError {
+		return out, result, fmt.Errorf("tool %s returned an error: %s", params.Name, mcp.JoinText(result.Content))
+	}
+
+	if err := result.DecodeStructuredContent(&out); err != nil {
+		return out, result, fmt.Errorf("failed to decode the result of tool %s: %w", params.Name, err)
+	}
+	return out, result, nil
 }
 
 func (c *Client) SendRootsListChangedNotification(params mcp.RootsListChangedNotification) error {
@@ -281,11 +463,15 @@ func (c *Client) AssertCapabilityForMethod(method jsonrpc.Method) error {
 			return fmt.Errorf("server does not support logging (required for %s)", method)
 		}
 	case shared.GetPromptsMethod,
-		shared.ListPromptsMethod,
-		shared.CompletionCompleteMethod:
+		shared.ListPromptsMethod:
 		if c.ServerCapabilities.Prompts == nil {
 			return fmt.Errorf("server does not support prompts (required for %s)", method)
 		}
+	case shared.CompletionCompleteMethod:
+		// servers implementing versions of the protocol before the completions capability only declare prompts or resources
+		if c.ServerCapabilities.Completions == nil && c.ServerCapabilities.Prompts == nil && c.ServerCapabilities.Resources == nil {
+			return fmt.Errorf("server does not support completions (required for %s)", method)
+		}
 	case shared.ListResourcesMethod,
 		shared.ListResourcesTemplatesMethod,
 		shared.ReadResourcesMethod,
@@ -329,18 +515,59 @@ func (c *Client) AssertRequestHandlerCapability(method jsonrpc.Method) error {
 		if c.capabilities.Roots == nil {
 			return fmt.Errorf("client does not support roots capability (required for %s)", method)
 		}
+	case shared.ElicitationCreateMethod:
+		if c.capabilities.Elicitation == nil {
+			return fmt.Errorf("client does not support elicitation capability (required for %s)", method)
+		}
 	}
 
 	return nil
 }
 
+// CircuitBreaker returns the circuit breaker configured by ClientOptions, or nil.
+func (c *Client) CircuitBreaker() *CircuitBreaker {
+	return c.circuitBreaker
+}
+
+// SendRequest sends a request, retrying it according to the RetryPolicy for the method
+// unless the circuit breaker is open.
 func (c *Client) SendRequest(
 	method jsonrpc.Method,
 	params *jsonrpc.JSONRPCRequestParams,
 	result *jsonrpc.Result,
 	options *mcp.RequestOptions,
 ) error {
-	return c.Protocol.SendRequest(
+	policy, retry := c.retryPolicy(method, params)
+
+	for attempt := 1; ; attempt++ {
+		err := c.sendRequest(method, params, result, options)
+		if err == nil || !retry || attempt >= policy.MaxAttempts || !policy.isRetryable(err) {
+			return err
+		}
+
+		backoff := policy.backoff(attempt - 1)
+		shared.Logger.Printf("%s failed, retrying in %v: %v\n", method, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-c.ctx.Done():
+			return err
+		}
+	}
+}
+
+func (c *Client) sendRequest(
+	method jsonrpc.Method,
+	params *jsonrpc.JSONRPCRequestParams,
+	result *jsonrpc.Result,
+	options *mcp.RequestOptions,
+) error {
+	if c.circuitBreaker != nil {
+		if err := c.circuitBreaker.Allow(); err != nil {
+			return err
+		}
+	}
+
+	err := c.Protocol.SendRequest(
 		c.ctx,
 		method,
 		&jsonrpc.JSONRPCRequestParams{
@@ -349,6 +576,11 @@ func (c *Client) SendRequest(
 		//
 		result,
 		options)
+
+	if c.circuitBreaker != nil {
+		c.circuitBreaker.Record(err)
+	}
+	return err
 }
 
 // func RunClient(ctx context.Context, urlOrCommand string, args []string) {
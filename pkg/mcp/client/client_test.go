@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
 	jsonrpc_client "github.com/nalbion/go-mcp/pkg/jsonrpc/client"
 	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -66,12 +70,110 @@ func TestIntegrationClient(t *testing.T) {
 		// then
 		require.NoError(t, err)
 		require.Len(t, result.Content, 1)
-		if content, ok := result.Content[0].(map[string]any); !ok {
+		if content, ok := result.Content[0].(mcp.TextContent); !ok {
 			t.Fatalf("unexpected content type: %T", result.Content[0])
 		} else {
-			text := content["text"]
+			text := content.Text
 			require.NotEmpty(t, text)
 			// fmt.Println(text)
 		}
 	})
 }
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should call a tool after initializing", func(t *testing.T) {
+		// given
+		transport := &jsonrpc.MockTransport{}
+		transport.ExpectRequest(shared.InitializeMethod).Reply(mcp.InitializeResult{
+			ProtocolVersion: shared.LatestProtocolVersion,
+			Capabilities:    mcp.ServerCapabilities{Tools: &mcp.ServerCapabilitiesTools{}},
+			ServerInfo:      mcp.Implementation{Name: "mock-server", Version: "1.0.0"},
+		})
+		transport.ExpectRequest(shared.ToolsCallMethod).
+			WithParams(map[string]any{"name": "echo", "arguments": map[string]any{"text": "hello"}}).
+			Reply(map[string]any{"content": []any{map[string]any{"type": "text", "text": "hello"}}})
+		client := NewClient(ctx, mcp.Implementation{Name: "test-client", Version: "1.0.0"}, ClientOptions{})
+		require.NoError(t, client.Connect(transport))
+
+		// when
+		result := &mcp.CallToolResult{}
+		err := client.CallTool(mcp.CallToolRequestParams{
+			Name:      "echo",
+			Arguments: map[string]any{"text": "hello"},
+		}, result, nil)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, result.Content, 1)
+		transport.AssertExpectations(t)
+		_, err = transport.WaitForNotification(shared.NotificationsInitializedMethod, time.Second)
+		assert.NoError(t, err)
+	})
+
+	connectWithWeatherTool := func(t *testing.T, options ClientOptions, structuredContent map[string]any) *Client {
+		transport := &jsonrpc.MockTransport{}
+		transport.ExpectRequest(shared.InitializeMethod).Reply(mcp.InitializeResult{
+			ProtocolVersion: shared.LatestProtocolVersion,
+			Capabilities:    mcp.ServerCapabilities{Tools: &mcp.ServerCapabilitiesTools{}},
+			ServerInfo:      mcp.Implementation{Name: "mock-server", Version: "1.0.0"},
+		})
+		transport.ExpectRequest(shared.ToolsListMethod).Reply(map[string]any{"tools": []any{map[string]any{
+			"name":        "weather",
+			"inputSchema": map[string]any{"type": "object"},
+			"outputSchema": map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"temperature": map[string]any{"type": "number"}},
+				"required":   []any{"temperature"},
+			},
+		}}})
+		transport.ExpectRequest(shared.ToolsCallMethod).Reply(map[string]any{
+			"content":           []any{map[string]any{"type": "text", "text": "weather"}},
+			"structuredContent": structuredContent,
+		})
+		client := NewClient(ctx, mcp.Implementation{Name: "test-client", Version: "1.0.0"}, options)
+		require.NoError(t, client.Connect(transport))
+		require.NoError(t, client.ListTools(mcp.ListToolsRequestParams{}, &mcp.ListToolsResult{}, nil))
+		return client
+	}
+
+	t.Run("should decode structured content", func(t *testing.T) {
+		// given
+		client := connectWithWeatherTool(t, ClientOptions{ValidateToolOutput: true}, map[string]any{"temperature": 21.5})
+
+		// when
+		out, result, err := CallToolStructured[struct {
+			Temperature float64 `json:"temperature"`
+		}](client, mcp.CallToolRequestParams{Name: "weather"}, nil)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 21.5, out.Temperature)
+		assert.Len(t, result.Content, 1)
+	})
+
+	t.Run("should validate structured content against the output schema", func(t *testing.T) {
+		// given
+		client := connectWithWeatherTool(t, ClientOptions{ValidateToolOutput: true}, map[string]any{"temperature": "hot"})
+
+		// when
+		err := client.CallTool(mcp.CallToolRequestParams{Name: "weather"}, &mcp.CallToolResult{}, nil)
+
+		// then
+		var validationErr *mcp.OutputValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []mcp.ValidationError{{Path: "temperature", Message: "must be of type number"}}, validationErr.Errors)
+	})
+
+	t.Run("should not validate structured content unless enabled", func(t *testing.T) {
+		// given
+		client := connectWithWeatherTool(t, ClientOptions{}, map[string]any{"temperature": "hot"})
+
+		// when
+		err := client.CallTool(mcp.CallToolRequestParams{Name: "weather"}, &mcp.CallToolResult{}, nil)
+
+		// then
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// ElicitationHandler asks the user for the information the server requested with elicitation/create,
+// eg: by showing params.Message and a form for the properties of params.RequestedSchema.
+// It returns the action the user took, with the content of the form if they accepted.
+type ElicitationHandler func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error)
+
+// SetElicitationHandler handles elicitation/create requests from the server, and advertises the elicitation capability.
+// It should be called before Connect(), so that the capability is sent to the server.
+//
+// Requests with a schema which is not allowed for elicitation are rejected without calling the handler,
+// and accepted content which does not match the schema is not sent to the server.
+func (c *Client) SetElicitationHandler(handler ElicitationHandler) {
+	if c.capabilities.Elicitation == nil {
+		c.capabilities.Elicitation = mcp.ClientCapabilitiesElicitation{}
+	}
+
+	c.SetRequestHandler(shared.ElicitationCreateMethod, func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+		var params mcp.ElicitRequestParams
+		if err := shared.DecodeParams(request, &params); err != nil {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid elicitation request parameters", nil)
+		}
+		if err := mcp.ValidateElicitationSchema(params.RequestedSchema); err != nil {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, err.Error(), nil)
+		}
+
+		result, err := handler(ctx, params)
+		if err != nil {
+			return jsonrpc.Result{}, err
+		}
+		if err := result.Validate(params.RequestedSchema); err != nil {
+			return jsonrpc.Result{}, fmt.Errorf("the elicitation handler returned an invalid result: %w", err)
+		}
+		return jsonrpc.Result{AdditionalProperties: result}, nil
+	})
+}
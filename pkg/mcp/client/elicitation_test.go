@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElicitationHandler(t *testing.T) {
+	ctx := context.Background()
+	requestedSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":    map[string]any{"type": "string", "minLength": 1},
+			"confirm": map[string]any{"type": "boolean"},
+		},
+		"required": []any{"name", "confirm"},
+	}
+
+	// connectWithElicitation connects a client which answers elicitation/create with the handler to a MockTransport
+	connectWithElicitation := func(t *testing.T, handler ElicitationHandler) (*Client, *jsonrpc.MockTransport) {
+		transport := &jsonrpc.MockTransport{}
+		transport.ExpectRequest(shared.InitializeMethod).Reply(mcp.InitializeResult{
+			ProtocolVersion: shared.LatestProtocolVersion,
+			ServerInfo:      mcp.Implementation{Name: "mock-server", Version: "1.0.0"},
+		})
+		client := NewClient(ctx, mcp.Implementation{Name: "test-client", Version: "1.0.0"}, ClientOptions{})
+		client.SetElicitationHandler(handler)
+		require.NoError(t, client.Connect(transport))
+		return client, transport
+	}
+
+	elicit := func(transport *jsonrpc.MockTransport, schema map[string]any) (*jsonrpc.JSONRPCResponse, error) {
+		transport.ReceiveRequest(1, shared.ElicitationCreateMethod, map[string]any{
+			"message":         "Name the report",
+			"requestedSchema": schema,
+		})
+		return transport.WaitForResponse(1, time.Second)
+	}
+
+	t.Run("should advertise the elicitation capability", func(t *testing.T) {
+		// when
+		_, transport := connectWithElicitation(t, func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error) {
+			return mcp.ElicitResult{Action: mcp.ElicitActionCancel}, nil
+		})
+
+		// then
+		request, err := transport.WaitForRequest(shared.InitializeMethod, time.Second)
+		require.NoError(t, err)
+		params := request.Params.AdditionalProperties.(*jsonrpc.JSONRPCRequestParams).AdditionalProperties.(mcp.InitializeRequestParams)
+		assert.NotNil(t, params.Capabilities.Elicitation)
+	})
+
+	t.Run("should send the content entered by the user", func(t *testing.T) {
+		// given
+		var received mcp.ElicitRequestParams
+		_, transport := connectWithElicitation(t, func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error) {
+			received = params
+			return mcp.ElicitResult{Action: mcp.ElicitActionAccept, Content: map[string]interface{}{"name": "Q3", "confirm": true}}, nil
+		})
+
+		// when
+		response, err := elicit(transport, requestedSchema)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "Name the report", received.Message)
+		assert.Equal(t, []string{"name", "confirm"}, received.RequestedSchema.Required)
+		result := response.Result.AdditionalProperties.(mcp.ElicitResult)
+		assert.Equal(t, mcp.ElicitActionAccept, result.Action)
+		assert.Equal(t, "Q3", result.Content["name"])
+	})
+
+	t.Run("should not send content which does not match the requested schema", func(t *testing.T) {
+		// given
+		_, transport := connectWithElicitation(t, func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error) {
+			return mcp.ElicitResult{Action: mcp.ElicitActionAccept, Content: map[string]interface{}{"name": ""}}, nil
+		})
+
+		// when
+		_, err := elicit(transport, requestedSchema)
+
+		// then
+		assert.ErrorContains(t, err, "the elicitation handler returned an invalid result")
+	})
+
+	t.Run("should reject a schema which is not allowed for elicitation", func(t *testing.T) {
+		// given
+		called := false
+		_, transport := connectWithElicitation(t, func(ctx context.Context, params mcp.ElicitRequestParams) (mcp.ElicitResult, error) {
+			called = true
+			return mcp.ElicitResult{Action: mcp.ElicitActionCancel}, nil
+		})
+
+		// when
+		_, err := elicit(transport, map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}}},
+		})
+
+		// then
+		assert.ErrorContains(t, err, "nested schemas are not allowed")
+		assert.False(t, called)
+	})
+
+	t.Run("should not handle elicitation without a handler", func(t *testing.T) {
+		// given
+		transport := &jsonrpc.MockTransport{}
+		transport.ExpectRequest(shared.InitializeMethod).Reply(mcp.InitializeResult{
+			ProtocolVersion: shared.LatestProtocolVersion,
+			ServerInfo:      mcp.Implementation{Name: "mock-server", Version: "1.0.0"},
+		})
+		client := NewClient(ctx, mcp.Implementation{Name: "test-client", Version: "1.0.0"}, ClientOptions{})
+		require.NoError(t, client.Connect(transport))
+
+		// when
+		_, err := elicit(transport, requestedSchema)
+
+		// then
+		assert.ErrorContains(t, err, "Method not found")
+	})
+}
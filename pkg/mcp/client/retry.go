@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// RetryPolicy configures how a failed request is retried, with exponential backoff and jitter between attempts.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter randomises each delay by up to this fraction.
+	Jitter float64
+	// IsRetryable reports whether a failed attempt should be retried, defaults to IsTransientError.
+	IsRetryable func(err error) bool
+}
+
+func NewRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// DefaultRetryPolicies retries the methods which do not change the state of the server.
+// tools/call is not included, as tools may have side effects.
+func DefaultRetryPolicies() map[jsonrpc.Method]RetryPolicy {
+	policy := NewRetryPolicy()
+	return map[jsonrpc.Method]RetryPolicy{
+		shared.PingMethod:                   policy,
+		shared.ListResourcesMethod:          policy,
+		shared.ListResourcesTemplatesMethod: policy,
+		shared.ReadResourcesMethod:          policy,
+		shared.ListPromptsMethod:            policy,
+		shared.GetPromptsMethod:             policy,
+		shared.ToolsListMethod:              policy,
+		shared.CompletionCompleteMethod:     policy,
+	}
+}
+
+// IsTransientError reports whether an error may succeed if retried: connection failures, timeouts and internal server errors.
+// Errors such as MethodNotFound or InvalidParams, or a result which can not be parsed, will fail again.
+func IsTransientError(err error) bool {
+	var jsonrpcErr *jsonrpc.JSONRPCErrorError
+	if errors.As(err, &jsonrpcErr) {
+		switch jsonrpc.ErrorCode(jsonrpcErr.Code) {
+		case jsonrpc.ConnectionClosed, jsonrpc.RequestTimeout, jsonrpc.InternalError:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
+
+func (p RetryPolicy) isRetryable(err error) bool {
+	if p.IsRetryable != nil {
+		return p.IsRetryable(err)
+	}
+	return IsTransientError(err)
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff << attempt
+	if backoff > p.MaxBackoff || backoff <= 0 {
+		backoff = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		backoff += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(backoff))
+	}
+	return backoff
+}
+
+// retryPolicy returns the policy for a request, if it may be retried.
+func (c *Client) retryPolicy(method jsonrpc.Method, params *jsonrpc.JSONRPCRequestParams) (RetryPolicy, bool) {
+	policy, ok := c.retryPolicies[method]
+	if !ok || policy.MaxAttempts <= 1 {
+		return policy, false
+	}
+
+	if method == shared.ToolsCallMethod {
+		if params == nil {
+			return policy, false
+		}
+		switch params := params.AdditionalProperties.(type) {
+		case mcp.CallToolRequestParams:
+			return policy, c.IsIdempotentTool(params.Name)
+		case *mcp.CallToolRequestParams:
+			return policy, c.IsIdempotentTool(params.Name)
+		}
+		return policy, false
+	}
+
+	return policy, true
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newInMemoryClient connects a client to a minimal in-memory server with the given request handlers.
+func newInMemoryClient(t *testing.T, options ClientOptions, handlers map[jsonrpc.Method]jsonrpc.RequestHandler) *Client {
+	ctx := context.Background()
+	clientTransport, serverTransport := jsonrpc.NewClientServerInMemoryTransports()
+
+	server := jsonrpc.NewProtocol(ctx)
+	server.SetRequestHandler(shared.InitializeMethod, func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+		return jsonrpc.Result{AdditionalProperties: mcp.InitializeResult{
+			ProtocolVersion: shared.LatestProtocolVersion,
+			ServerInfo:      mcp.Implementation{Name: "test-server", Version: "1.0.0"},
+		}}, nil
+	})
+	for method, handler := range handlers {
+		server.SetRequestHandler(method, handler)
+	}
+	require.NoError(t, server.Connect(ctx, serverTransport))
+
+	client := NewClient(ctx, mcp.Implementation{Name: "test-client", Version: "1.0.0"}, options)
+	require.NoError(t, client.Connect(clientTransport))
+	return client
+}
+
+// flakyHandler fails with an internal error `failures` times before succeeding, counting the attempts.
+func flakyHandler(failures int32, attempts *atomic.Int32) jsonrpc.RequestHandler {
+	return func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+		if attempts.Add(1) <= failures {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InternalError, "temporarily unavailable", nil)
+		}
+		return jsonrpc.Result{AdditionalProperties: map[string]any{"tools": []any{}, "content": []any{}}}, nil
+	}
+}
+
+func fastRetryPolicies() map[jsonrpc.Method]RetryPolicy {
+	policies := DefaultRetryPolicies()
+	for method, policy := range policies {
+		policy.InitialBackoff = time.Millisecond
+		policies[method] = policy
+	}
+	policies[shared.ToolsCallMethod] = policies[shared.ToolsListMethod]
+	return policies
+}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("should retry transient failures", func(t *testing.T) {
+		var attempts atomic.Int32
+		client := newInMemoryClient(t, ClientOptions{RetryPolicies: fastRetryPolicies()}, map[jsonrpc.Method]jsonrpc.RequestHandler{
+			shared.ToolsListMethod: flakyHandler(2, &attempts),
+		})
+
+		err := client.ListTools(mcp.ListToolsRequestParams{}, &mcp.ListToolsResult{}, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("should give up after MaxAttempts", func(t *testing.T) {
+		var attempts atomic.Int32
+		client := newInMemoryClient(t, ClientOptions{RetryPolicies: fastRetryPolicies()}, map[jsonrpc.Method]jsonrpc.RequestHandler{
+			shared.ToolsListMethod: flakyHandler(5, &attempts),
+		})
+
+		err := client.ListTools(mcp.ListToolsRequestParams{}, &mcp.ListToolsResult{}, nil)
+
+		assert.ErrorContains(t, err, "temporarily unavailable")
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("should not retry errors which are not transient", func(t *testing.T) {
+		var attempts atomic.Int32
+		client := newInMemoryClient(t, ClientOptions{RetryPolicies: fastRetryPolicies()}, map[jsonrpc.Method]jsonrpc.RequestHandler{
+			shared.ToolsListMethod: func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+				attempts.Add(1)
+				return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "invalid cursor", nil)
+			},
+		})
+
+		err := client.ListTools(mcp.ListToolsRequestParams{}, &mcp.ListToolsResult{}, nil)
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("should only retry tool calls for idempotent tools", func(t *testing.T) {
+		var attempts atomic.Int32
+		client := newInMemoryClient(t, ClientOptions{
+			RetryPolicies:    fastRetryPolicies(),
+			IsIdempotentTool: func(name string) bool { return name == "read_file" },
+		}, map[jsonrpc.Method]jsonrpc.RequestHandler{
+			shared.ToolsCallMethod: flakyHandler(1, &attempts),
+		})
+
+		err := client.CallTool(mcp.CallToolRequestParams{Name: "write_file"}, &mcp.CallToolResult{}, nil)
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), attempts.Load())
+
+		attempts.Store(0)
+		err = client.CallTool(mcp.CallToolRequestParams{Name: "read_file"}, &mcp.CallToolResult{}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("should retry tool calls for tools annotated as idempotent by a trusted server", func(t *testing.T) {
+		// given
+		var attempts atomic.Int32
+		listTools := func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+			return jsonrpc.Result{AdditionalProperties: mcp.ListToolsResult{Tools: []mcp.Tool{
+				{Name: "read_file", InputSchema: mcp.ToolInputSchema{Type: "object"}, Annotations: mcp.NewToolAnnotations().WithReadOnlyHint(true)},
+				{Name: "write_file", InputSchema: mcp.ToolInputSchema{Type: "object"}},
+			}}}, nil
+		}
+		client := newInMemoryClient(t, ClientOptions{
+			RetryPolicies:        fastRetryPolicies(),
+			TrustToolAnnotations: true,
+		}, map[jsonrpc.Method]jsonrpc.RequestHandler{
+			shared.ToolsListMethod: listTools,
+			shared.ToolsCallMethod: flakyHandler(1, &attempts),
+		})
+		require.NoError(t, client.ListTools(mcp.ListToolsRequestParams{}, &mcp.ListToolsResult{}, nil))
+
+		// when
+		err := client.CallTool(mcp.CallToolRequestParams{Name: "write_file"}, &mcp.CallToolResult{}, nil)
+
+		// then
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), attempts.Load())
+		assert.True(t, client.ToolRequiresConfirmation("write_file"))
+		assert.False(t, client.ToolRequiresConfirmation("read_file"))
+
+		// when
+		attempts.Store(0)
+		err = client.CallTool(mcp.CallToolRequestParams{Name: "read_file"}, &mcp.CallToolResult{}, nil)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("should not use the annotations of untrusted servers", func(t *testing.T) {
+		client := NewClient(context.Background(), mcp.Implementation{Name: "test-client", Version: "1.0.0"}, ClientOptions{})
+		client.tools["read_file"] = mcp.Tool{Name: "read_file", Annotations: mcp.NewToolAnnotations().WithReadOnlyHint(true)}
+
+		assert.False(t, client.IsIdempotentTool("read_file"))
+		assert.True(t, client.ToolRequiresConfirmation("read_file"))
+	})
+
+	t.Run("should fail fast when the circuit breaker is open", func(t *testing.T) {
+		// given
+		var attempts atomic.Int32
+		client := newInMemoryClient(t, ClientOptions{
+			CircuitBreaker: &CircuitBreakerOptions{FailureThreshold: 2, Cooldown: time.Minute},
+		}, map[jsonrpc.Method]jsonrpc.RequestHandler{
+			shared.ToolsListMethod: flakyHandler(5, &attempts),
+		})
+
+		// when
+		for i := 0; i < 2; i++ {
+			client.ListTools(mcp.ListToolsRequestParams{}, &mcp.ListToolsResult{}, nil)
+		}
+		err := client.ListTools(mcp.ListToolsRequestParams{}, &mcp.ListToolsResult{}, nil)
+
+		// then
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, int32(2), attempts.Load())
+		assert.Equal(t, CircuitOpen, client.CircuitBreaker().State())
+	})
+}
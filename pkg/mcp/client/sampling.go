@@ -0,0 +1,294 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// The reasons for sampling to stop which are set by the sampling handler
+const (
+	StopReasonStopSequence = "stopSequence"
+	StopReasonMaxTokens    = "maxTokens"
+)
+
+// ModelInfo describes a model of a ModelProvider, to choose between them with the server's mcp.ModelPreferences.
+// The ratings are from 0 to 1, where 1 is best: Cost is 1 for the cheapest models, Speed is 1 for the fastest
+// and Intelligence is 1 for the most capable.
+type ModelInfo struct {
+	Name         string
+	Cost         float64
+	Speed        float64
+	Intelligence float64
+}
+
+// ModelProvider samples from an LLM for sampling/createMessage requests from the server.
+type ModelProvider interface {
+	// Models returns the models which may be chosen for a request. The first is used if the server has no preferences.
+	Models() []ModelInfo
+	// CreateMessage samples from the model chosen for the request. params.SystemPrompt includes any context
+	// which the server asked for with includeContext.
+	CreateMessage(ctx context.Context, model string, params mcp.CreateMessageRequestParams) (mcp.CreateMessageResult, error)
+}
+
+type SamplingOptions struct {
+	// MaxTokens limits the tokens sampled for every request, whatever the server asks for, or 0 for no limit.
+	MaxTokens int
+	// CountTokens counts the tokens in the sampled text, to enforce maxTokens. It defaults to counting words,
+	// so should be set to the tokenizer of the provider if it is available.
+	CountTokens func(text string) int
+	// IncludeContext returns the context which is added to the system prompt when the server asks for it with includeContext,
+	// eg: a host connected to several servers may describe each of them for "allServers".
+	// It defaults to the instructions the server sent when initializing.
+	IncludeContext func(ctx context.Context, includeContext mcp.CreateMessageRequestParamsIncludeContext) (string, error)
+}
+
+// SetSamplingHandler answers sampling/createMessage requests from the server with the provider, and advertises the
+// sampling capability. It should be called before Connect(), so that the capability is sent to the server.
+//
+// The model is chosen with SelectModel(), and the sampled text is truncated at the first of the request's stopSequences
+// and at maxTokens, in case the provider does not support them.
+func (c *Client) SetSamplingHandler(provider ModelProvider, options *SamplingOptions) {
+	if c.capabilities.Sampling == nil {
+		c.capabilities.Sampling = mcp.ClientCapabilitiesSampling{}
+	}
+	if options == nil {
+		options = &SamplingOptions{}
+	}
+
+	c.SetRequestHandler(shared.SamplingCreateMessageMethod, func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+		var params mcp.CreateMessageRequestParams
+		if err := shared.DecodeParams(request, &params); err != nil || params.MaxTokens <= 0 {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid create message request parameters", nil)
+		}
+
+		result, err := c.createMessage(ctx, provider, options, params)
+		if err != nil {
+			return jsonrpc.Result{}, err
+		}
+		return jsonrpc.Result{AdditionalProperties: result}, nil
+	})
+}
+
+func (c *Client) createMessage(ctx context.Context, provider ModelProvider, options *SamplingOptions, params mcp.CreateMessageRequestParams) (mcp.CreateMessageResult, error) {
+	models := provider.Models()
+	if len(models) == 0 {
+		return mcp.CreateMessageResult{}, fmt.Errorf("the model provider has no models")
+	}
+	model := SelectModel(models, params.ModelPreferences)
+
+	if options.MaxTokens > 0 && params.MaxTokens > options.MaxTokens {
+		params.MaxTokens = options.MaxTokens
+	}
+	if err := c.includeContext(ctx, options, &params); err != nil {
+		return mcp.CreateMessageResult{}, err
+	}
+
+	result, err := provider.CreateMessage(ctx, model.Name, params)
+	if err != nil {
+		return mcp.CreateMessageResult{}, err
+	}
+	if result.Model == "" {
+		result.Model = model.Name
+	}
+	if result.Role == "" {
+		result.Role = mcp.RoleAssistant
+	}
+
+	countTokens := options.CountTokens
+	if countTokens == nil {
+		countTokens = countWords
+	}
+	limitText(&result, params.StopSequences, params.MaxTokens, countTokens)
+	return result, nil
+}
+
+// includeContext adds the context which the server asked for to the system prompt.
+func (c *Client) includeContext(ctx context.Context, options *SamplingOptions, params *mcp.CreateMessageRequestParams) error {
+	if params.IncludeContext == nil || *params.IncludeContext == mcp.CreateMessageRequestParamsIncludeContextNone {
+		return nil
+	}
+
+	serverContext := c.ServerInstructions
+	if options.IncludeContext != nil {
+		var err error
+		if serverContext, err = options.IncludeContext(ctx, *params.IncludeContext); err != nil {
+			return err
+		}
+	}
+	if serverContext == "" {
+		return nil
+	}
+
+	systemPrompt := serverContext
+	if params.SystemPrompt != nil && *params.SystemPrompt != "" {
+		systemPrompt = *params.SystemPrompt + "\n\n" + serverContext
+	}
+	params.SystemPrompt = &systemPrompt
+	return nil
+}
+
+// SelectModel chooses the model for a request. The hints are evaluated in order, and the first which is a substring
+// of the name of any model, ignoring case, is used. Models which match the same hint, or all the models if none match,
+// are ranked by the cost, speed and intelligence priorities. The first model is chosen if there are no preferences,
+// or if models are ranked equally. `models` must not be empty.
+func SelectModel(models []ModelInfo, preferences *mcp.ModelPreferences) ModelInfo {
+	if preferences == nil {
+		return models[0]
+	}
+
+	candidates := models
+	for _, hint := range preferences.Hints {
+		if hint.Name == nil || *hint.Name == "" {
+			continue
+		}
+		var matches []ModelInfo
+		for _, model := range models {
+			if strings.Contains(strings.ToLower(model.Name), strings.ToLower(*hint.Name)) {
+				matches = append(matches, model)
+			}
+		}
+		if len(matches) > 0 {
+			candidates = matches
+			break
+		}
+	}
+
+	best, bestScore := candidates[0], modelScore(candidates[0], preferences)
+	for _, model := range candidates[1:] {
+		if score := modelScore(model, preferences); score > bestScore {
+			best, bestScore = model, score
+		}
+	}
+	return best
+}
+
+func modelScore(model ModelInfo, preferences *mcp.ModelPreferences) float64 {
+	priority := func(p *float64) float64 {
+		if p == nil {
+			return 0
+		}
+		return *p
+	}
+	return priority(preferences.CostPriority)*model.Cost +
+		priority(preferences.SpeedPriority)*model.Speed +
+		priority(preferences.IntelligencePriority)*model.Intelligence
+}
+
+// limitText truncates text content before the first stop sequence, and to at most maxTokens tokens.
+func limitText(result *mcp.CreateMessageResult, stopSequences []string, maxTokens int, countTokens func(text string) int) {
+	var content mcp.TextContent
+	switch c := result.Content.(type) {
+	case mcp.TextContent:
+		content = c
+	case *mcp.TextContent:
+		content = *c
+	default:
+		return
+	}
+	text := content.Text
+
+	stopAt := -1
+	for _, stop := range stopSequences {
+		if i := strings.Index(text, stop); stop != "" && i >= 0 && (stopAt < 0 || i < stopAt) {
+			stopAt = i
+		}
+	}
+	if stopAt >= 0 {
+		text = text[:stopAt]
+		stopReason := StopReasonStopSequence
+		result.StopReason = &stopReason
+	}
+
+	if countTokens(text) > maxTokens {
+		text = truncateTokens(text, maxTokens, countTokens)
+		stopReason := StopReasonMaxTokens
+		result.StopReason = &stopReason
+	}
+
+	content.Text = text
+	result.Content = content
+}
+
+// truncateTokens returns the longest prefix of the text with at most maxTokens tokens, without trailing whitespace.
+// countTokens must not decrease as the text gets longer.
+func truncateTokens(text string, maxTokens int, countTokens func(text string) int) string {
+	// binary search for the number of runes to keep
+	runes := []rune(text)
+	low, high := 0, len(runes)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if countTokens(string(runes[:mid])) <= maxTokens {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:low]), unicode.IsSpace)
+}
+
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// FakeModelProvider is a deterministic ModelProvider for tests. It replies to each request with the result of Reply,
+// or by echoing the text of the last message, and records the requests so that tests can check them.
+type FakeModelProvider struct {
+	ModelInfos []ModelInfo
+	// Reply returns the text of the reply to a request, eg: a canned response.
+	Reply func(model string, params mcp.CreateMessageRequestParams) string
+
+	mu       sync.Mutex
+	requests []FakeModelRequest
+}
+
+// FakeModelRequest is a request received by a FakeModelProvider.
+type FakeModelRequest struct {
+	Model  string
+	Params mcp.CreateMessageRequestParams
+}
+
+// NewFakeModelProvider creates a provider with the models, or a single model named "fake-model" if there are none.
+func NewFakeModelProvider(models ...ModelInfo) *FakeModelProvider {
+	if len(models) == 0 {
+		models = []ModelInfo{{Name: "fake-model"}}
+	}
+	return &FakeModelProvider{ModelInfos: models}
+}
+
+func (p *FakeModelProvider) Models() []ModelInfo {
+	return p.ModelInfos
+}
+
+func (p *FakeModelProvider) CreateMessage(ctx context.Context, model string, params mcp.CreateMessageRequestParams) (mcp.CreateMessageResult, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, FakeModelRequest{Model: model, Params: params})
+	p.mu.Unlock()
+
+	var text string
+	if p.Reply != nil {
+		text = p.Reply(model, params)
+	} else if len(params.Messages) > 0 {
+		text = mcp.JoinText([]mcp.Content{params.Messages[len(params.Messages)-1].Content})
+	}
+	stopReason := "endTurn"
+	return mcp.CreateMessageResult{
+		Content:    mcp.Text(text),
+		Model:      model,
+		Role:       mcp.RoleAssistant,
+		StopReason: &stopReason,
+	}, nil
+}
+
+// Requests returns the requests received by the provider, in order.
+func (p *FakeModelProvider) Requests() []FakeModelRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]FakeModelRequest(nil), p.requests...)
+}
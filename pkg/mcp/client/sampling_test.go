@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	ctx := context.Background()
+	instructions := "Use the weather tools for forecasts."
+
+	// connectWithSampling connects a client which answers sampling/createMessage with the provider to a MockTransport
+	connectWithSampling := func(t *testing.T, provider ModelProvider, options *SamplingOptions) *jsonrpc.MockTransport {
+		transport := &jsonrpc.MockTransport{}
+		transport.ExpectRequest(shared.InitializeMethod).Reply(mcp.InitializeResult{
+			ProtocolVersion: shared.LatestProtocolVersion,
+			ServerInfo:      mcp.Implementation{Name: "mock-server", Version: "1.0.0"},
+			Instructions:    &instructions,
+		})
+		client := NewClient(ctx, mcp.Implementation{Name: "test-client", Version: "1.0.0"}, ClientOptions{})
+		client.SetSamplingHandler(provider, options)
+		require.NoError(t, client.Connect(transport))
+		return transport
+	}
+
+	createMessage := func(t *testing.T, transport *jsonrpc.MockTransport, params map[string]any) (mcp.CreateMessageResult, error) {
+		params["messages"] = []any{map[string]any{"role": "user", "content": map[string]any{"type": "text", "text": "What is the forecast for Sydney?"}}}
+		transport.ReceiveRequest(1, shared.SamplingCreateMessageMethod, params)
+		response, err := transport.WaitForResponse(1, time.Second)
+		if err != nil {
+			return mcp.CreateMessageResult{}, err
+		}
+		return response.Result.AdditionalProperties.(mcp.CreateMessageResult), nil
+	}
+
+	t.Run("should advertise the sampling capability and reply with the provider", func(t *testing.T) {
+		// given
+		provider := NewFakeModelProvider()
+		transport := connectWithSampling(t, provider, nil)
+
+		// when
+		result, err := createMessage(t, transport, map[string]any{"maxTokens": 100})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "fake-model", result.Model)
+		assert.Equal(t, mcp.RoleAssistant, result.Role)
+		assert.Equal(t, mcp.Text("What is the forecast for Sydney?"), result.Content)
+		request, err := transport.WaitForRequest(shared.InitializeMethod, time.Second)
+		require.NoError(t, err)
+		params := request.Params.AdditionalProperties.(*jsonrpc.JSONRPCRequestParams).AdditionalProperties.(mcp.InitializeRequestParams)
+		assert.NotNil(t, params.Capabilities.Sampling)
+	})
+
+	t.Run("should choose the model with the hints", func(t *testing.T) {
+		// given
+		provider := NewFakeModelProvider(ModelInfo{Name: "large-2"}, ModelInfo{Name: "small-1"}, ModelInfo{Name: "small-2", Speed: 1})
+		transport := connectWithSampling(t, provider, nil)
+
+		// when
+		result, err := createMessage(t, transport, map[string]any{
+			"maxTokens": 100,
+			"modelPreferences": map[string]any{
+				"hints":         []any{map[string]any{"name": "tiny"}, map[string]any{"name": "SMALL"}},
+				"speedPriority": 0.8,
+			},
+		})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "small-2", result.Model)
+		assert.Equal(t, "small-2", provider.Requests()[0].Model)
+	})
+
+	t.Run("should truncate the reply at the first stop sequence", func(t *testing.T) {
+		// given
+		provider := NewFakeModelProvider()
+		provider.Reply = func(model string, params mcp.CreateMessageRequestParams) string {
+			return "Sunny.\nUser: and tomorrow?\nEND"
+		}
+		transport := connectWithSampling(t, provider, nil)
+
+		// when
+		result, err := createMessage(t, transport, map[string]any{"maxTokens": 100, "stopSequences": []any{"END", "\nUser:"}})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, mcp.Text("Sunny."), result.Content)
+		assert.Equal(t, StopReasonStopSequence, *result.StopReason)
+	})
+
+	t.Run("should enforce maxTokens and the client's limit", func(t *testing.T) {
+		// given
+		provider := NewFakeModelProvider()
+		provider.Reply = func(model string, params mcp.CreateMessageRequestParams) string {
+			return "one two three four five six"
+		}
+		transport := connectWithSampling(t, provider, &SamplingOptions{MaxTokens: 4})
+
+		// when
+		result, err := createMessage(t, transport, map[string]any{"maxTokens": 1000})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 4, provider.Requests()[0].Params.MaxTokens)
+		assert.Equal(t, mcp.Text("one two three four"), result.Content)
+		assert.Equal(t, StopReasonMaxTokens, *result.StopReason)
+	})
+
+	t.Run("should count tokens with the provider's tokenizer", func(t *testing.T) {
+		// given
+		provider := NewFakeModelProvider()
+		provider.Reply = func(model string, params mcp.CreateMessageRequestParams) string {
+			return "abcdefghij"
+		}
+		transport := connectWithSampling(t, provider, &SamplingOptions{CountTokens: func(text string) int { return (len(text) + 2) / 3 }})
+
+		// when
+		result, err := createMessage(t, transport, map[string]any{"maxTokens": 2})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, mcp.Text("abcdef"), result.Content)
+	})
+
+	t.Run("should add the server's instructions to the system prompt for includeContext", func(t *testing.T) {
+		// given
+		provider := NewFakeModelProvider()
+		transport := connectWithSampling(t, provider, nil)
+
+		// when
+		_, err := createMessage(t, transport, map[string]any{"maxTokens": 100, "systemPrompt": "Be brief.", "includeContext": "thisServer"})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "Be brief.\n\n"+instructions, *provider.Requests()[0].Params.SystemPrompt)
+	})
+
+	t.Run("should add the context of all servers from the host", func(t *testing.T) {
+		// given
+		provider := NewFakeModelProvider()
+		transport := connectWithSampling(t, provider, &SamplingOptions{
+			IncludeContext: func(ctx context.Context, includeContext mcp.CreateMessageRequestParamsIncludeContext) (string, error) {
+				return "context for " + string(includeContext), nil
+			},
+		})
+
+		// when
+		_, err := createMessage(t, transport, map[string]any{"maxTokens": 100, "includeContext": "allServers"})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "context for allServers", *provider.Requests()[0].Params.SystemPrompt)
+	})
+
+	t.Run("should not add context unless the server asks for it", func(t *testing.T) {
+		// given
+		provider := NewFakeModelProvider()
+		transport := connectWithSampling(t, provider, nil)
+
+		// when
+		_, err := createMessage(t, transport, map[string]any{"maxTokens": 100, "includeContext": "none"})
+
+		// then
+		require.NoError(t, err)
+		assert.Nil(t, provider.Requests()[0].Params.SystemPrompt)
+	})
+
+	t.Run("should reject requests without maxTokens", func(t *testing.T) {
+		// given
+		provider := NewFakeModelProvider()
+		transport := connectWithSampling(t, provider, nil)
+
+		// when
+		_, err := createMessage(t, transport, map[string]any{"maxTokens": 0})
+
+		// then
+		assert.ErrorContains(t, err, "Invalid create message request parameters")
+		assert.Empty(t, provider.Requests())
+	})
+}
+
+func TestSelectModel(t *testing.T) {
+	models := []ModelInfo{
+		{Name: "claude-3-5-sonnet-20241022", Cost: 0.4, Speed: 0.5, Intelligence: 0.8},
+		{Name: "claude-3-haiku-20240307", Cost: 0.9, Speed: 0.9, Intelligence: 0.4},
+		{Name: "claude-3-opus-20240229", Cost: 0.1, Speed: 0.2, Intelligence: 0.9},
+	}
+	hint := func(name string) mcp.ModelHint { return mcp.ModelHint{Name: &name} }
+	priority := func(value float64) *float64 { return &value }
+
+	tests := map[string]struct {
+		preferences *mcp.ModelPreferences
+		expected    string
+	}{
+		"no preferences":          {nil, "claude-3-5-sonnet-20241022"},
+		"first matching hint":     {&mcp.ModelPreferences{Hints: []mcp.ModelHint{hint("gpt-4"), hint("haiku"), hint("opus")}}, "claude-3-haiku-20240307"},
+		"hint ignoring case":      {&mcp.ModelPreferences{Hints: []mcp.ModelHint{hint("OPUS")}}, "claude-3-opus-20240229"},
+		"priorities within hint":  {&mcp.ModelPreferences{Hints: []mcp.ModelHint{hint("claude")}, IntelligencePriority: priority(1)}, "claude-3-opus-20240229"},
+		"priorities without hint": {&mcp.ModelPreferences{CostPriority: priority(0.8), IntelligencePriority: priority(0.2)}, "claude-3-haiku-20240307"},
+		"no hint matches":         {&mcp.ModelPreferences{Hints: []mcp.ModelHint{hint("gemini")}}, "claude-3-5-sonnet-20241022"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, SelectModel(models, test.preferences).Name)
+		})
+	}
+}
+
+func TestTruncateTokens(t *testing.T) {
+	t.Run("should keep whole words when counting words", func(t *testing.T) {
+		assert.Equal(t, "one two", truncateTokens("one two three", 2, countWords))
+		assert.Equal(t, "", truncateTokens("one", 0, countWords))
+		assert.Equal(t, "  naïve café", truncateTokens("  naïve café au lait", 2, countWords))
+	})
+}
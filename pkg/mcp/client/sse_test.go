@@ -2,8 +2,11 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,38 +14,72 @@ import (
 	jsonrpcclient "github.com/nalbion/go-mcp/pkg/jsonrpc/client"
 
 	"github.com/nalbion/go-mcp/pkg/mcp"
-	"github.com/nalbion/go-mcp/pkg/sse"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestSSEClientTransport(t *testing.T) {
-	// given
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// sseTestServer is an MCP server which sends the endpoint event on each SSE connection, answers the initialize
+// request on the stream and then sends the events written to `events`.
+type sseTestServer struct {
+	*httptest.Server
+	connections atomic.Int32
+	events      chan string
+	// closeStream ends the current SSE connection
+	closeStream chan struct{}
+}
 
-	// Create a test server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Create an SSE server session
-		session, _ := sse.NewServerSSESession(&sse.SSESessionOptions{Buffered: true})
+func newSSETestServer(t *testing.T) *sseTestServer {
+	server := &sseTestServer{events: make(chan string, 10), closeStream: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		server.connections.Add(1)
 
 		// Set headers for SSE
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
 		w.WriteHeader(http.StatusOK)
-
-		// Send a test event
-		err := session.Send(sse.NewServerSentEvent().
-			WithEvent("message").
-			WithData(`{"jsonrpc":"2.0","method":"test","params":{}}`))
-		if err != nil {
-			t.Logf("Error sending SSE event: %v", err)
+		fmt.Fprint(w, "event: endpoint\ndata: /messages\n\n")
+		w.(http.Flusher).Flush()
+
+		for {
+			select {
+			case event := <-server.events:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", event)
+				w.(http.Flusher).Flush()
+			case <-server.closeStream:
+				// Connection will be closed when the handler returns
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Id     *int   `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("invalid message: %v", err)
+		}
+		if request.Method == string(shared.InitializeMethod) {
+			server.events <- fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{"protocolVersion":%q,"capabilities":{},"serverInfo":{"name":"test-server","version":"1.0.0"}}}`,
+				*request.Id, shared.LatestProtocolVersion)
 		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
 
-		// Keep the connection open
-		<-ctx.Done()
-	}))
+func TestSSEClientTransport(t *testing.T) {
+	// given
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Create a test server
+	server := newSSETestServer(t)
 	defer server.Close()
 
 	// Create an SSE client
@@ -52,26 +89,27 @@ func TestSSEClientTransport(t *testing.T) {
 	}, ClientOptions{})
 
 	// Create an SSE client transport
-	transport, err := jsonrpcclient.NewDefaultSSEClientTransport(ctx, server.URL, 5*time.Second)
+	transport, err := jsonrpcclient.NewDefaultSSEClientTransport(ctx, server.URL+"/sse", 5*time.Second)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// Set up a notification handler to verify we receive the test event
-	receivedNotification := make(chan bool, 1)
-	transport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
-		if notification, ok := message.(jsonrpc.JSONRPCNotification); ok {
-			if notification.Method == "test" {
-				receivedNotification <- true
-			}
-		}
-	})
-
 	// when we connect to the SSE server
 	err = client.Connect(transport)
 
 	// then the connection is successful
 	require.NoError(t, err)
+	defer transport.Close()
+
+	// Set up a notification handler to verify we receive the test event
+	receivedNotification := make(chan bool, 1)
+	client.SetNotificationHandler("test", func(notification *jsonrpc.JSONRPCNotification) error {
+		receivedNotification <- true
+		return nil
+	})
+
+	// Send a test event
+	server.events <- `{"jsonrpc":"2.0","method":"test","params":{}}`
 
 	// Wait for the notification to be received
 	select {
@@ -87,36 +125,8 @@ func TestSSEClientReconnection(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Create a counter for connection attempts
-	connectionAttempts := 0
-
-	// Create a channel to signal when the server should close the connection
-	closeConnection := make(chan struct{})
-
-	// Create a test server that will close the connection after receiving a request
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		connectionAttempts++
-
-		// Set headers for SSE
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-		w.WriteHeader(http.StatusOK)
-
-		// Flush the headers
-		if f, ok := w.(http.Flusher); ok {
-			f.Flush()
-		}
-
-		// Close the connection after a short delay if requested
-		select {
-		case <-closeConnection:
-			// Connection will be closed when the handler returns
-			return
-		case <-ctx.Done():
-			return
-		}
-	}))
+	// Create a test server that will close the connection when requested
+	server := newSSETestServer(t)
 	defer server.Close()
 
 	// Create an SSE client
@@ -126,7 +136,7 @@ func TestSSEClientReconnection(t *testing.T) {
 	}, ClientOptions{})
 
 	// Create an SSE client transport with a short reconnect delay
-	transport, err := jsonrpcclient.NewDefaultSSEClientTransport(ctx, server.URL, 500*time.Millisecond)
+	transport, err := jsonrpcclient.NewDefaultSSEClientTransport(ctx, server.URL+"/sse", 100*time.Millisecond)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -136,15 +146,68 @@ func TestSSEClientReconnection(t *testing.T) {
 
 	// then the connection is successful
 	require.NoError(t, err)
-
-	// Initial connection
-	time.Sleep(500 * time.Millisecond)
-	assert.Equal(t, 1, connectionAttempts)
+	defer transport.Close()
+	assert.Equal(t, int32(1), server.connections.Load())
 
 	// when we close the connection
-	closeConnection <- struct{}{}
+	server.closeStream <- struct{}{}
 
 	// then the client should reconnect
-	time.Sleep(1 * time.Second)
-	assert.GreaterOrEqual(t, connectionAttempts, 2, "Expected at least one reconnection attempt")
+	assert.Eventually(t, func() bool { return server.connections.Load() >= 2 }, 2*time.Second, 10*time.Millisecond,
+		"Expected at least one reconnection attempt")
+}
+
+func TestSSEClientTransportEndpoint(t *testing.T) {
+	// given
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	posted := make(chan string, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("event: endpoint\ndata: /messages?sessionId=abc\n\n"))
+		w.Write([]byte("id: 1\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"test\",\"params\":{}}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		posted <- r.URL.Query().Get("sessionId")
+		w.WriteHeader(http.StatusAccepted)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	transport, err := jsonrpcclient.NewDefaultSSEClientTransport(ctx, server.URL+"/sse", 100*time.Millisecond)
+	require.NoError(t, err)
+
+	received := make(chan jsonrpc.JSONRPCMessage, 1)
+	transport.SetOnMessage(func(message jsonrpc.JSONRPCMessage) {
+		received <- message
+	})
+
+	// when we start the transport
+	err = transport.Start()
+
+	// then the endpoint is resolved against the SSE URL
+	require.NoError(t, err)
+	defer transport.Close()
+	assert.Equal(t, server.URL+"/messages?sessionId=abc", transport.Endpoint())
+
+	// and messages from the stream are parsed
+	select {
+	case message := <-received:
+		notification, ok := message.(*jsonrpc.JSONRPCNotification)
+		require.True(t, ok)
+		assert.Equal(t, "test", notification.Method)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for SSE message")
+	}
+	assert.Equal(t, "1", transport.LastEventID())
+
+	// when we send a message, it is POSTed to the endpoint
+	err = transport.Send(jsonrpc.NewJSONRPCNotification("ping", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", <-posted)
 }
@@ -0,0 +1,399 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	jsonrpc_client "github.com/nalbion/go-mcp/pkg/jsonrpc/client"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+var (
+	// ErrCrashLoop is returned once the server has crashed more than MaxRestarts times within RestartWindow.
+	ErrCrashLoop = errors.New("server is crash looping")
+	// ErrServerUnavailable is returned when a call can not be queued, or times out while the server is restarting.
+	ErrServerUnavailable = errors.New("server unavailable")
+	ErrSupervisorClosed  = errors.New("supervisor closed")
+)
+
+type SupervisorOptions struct {
+	// InitialBackoff is the delay before the first restart, doubling with each restart within RestartWindow.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxRestarts is the number of restarts allowed within RestartWindow before the supervisor gives up.
+	MaxRestarts   int
+	RestartWindow time.Duration
+	// QueueTimeout is how long a call waits for the server to restart before failing with ErrServerUnavailable.
+	QueueTimeout time.Duration
+	// MaxQueuedCalls is the number of calls which may wait for the server to restart.
+	MaxQueuedCalls int
+}
+
+func NewSupervisorOptions() SupervisorOptions {
+	return SupervisorOptions{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		MaxRestarts:    5,
+		RestartWindow:  time.Minute,
+		QueueTimeout:   30 * time.Second,
+		MaxQueuedCalls: 100,
+	}
+}
+
+// StdioSupervisor launches a stdio MCP server and restarts it if it exits unexpectedly,
+// repeating the initialization handshake and restoring the resource subscriptions and logging level
+// which were set through the supervisor.
+//
+// Calls made through Do() while the server is restarting wait for up to QueueTimeout.
+// Calls which were in progress when the server crashed fail with a ConnectionClosed error and are not retried.
+type StdioSupervisor struct {
+	client       *Client
+	serverParams jsonrpc_client.StdioServerParameters
+	options      SupervisorOptions
+
+	// connection is held for reading by calls, and for writing while (re)connecting
+	connection sync.RWMutex
+
+	mu            sync.Mutex
+	transport     *jsonrpc_client.StdioClientTransport
+	connected     bool
+	ready         chan struct{}
+	done          chan struct{}
+	err           error
+	queued        int
+	restarts      []time.Time
+	subscriptions map[string]struct{}
+	loggingLevel  *mcp.LoggingLevel
+	job           sync.WaitGroup
+}
+
+// NewStdioSupervisor supervises the server with `options`, any zero fields take the value from NewSupervisorOptions().
+func NewStdioSupervisor(client *Client, server jsonrpc_client.StdioServerParameters, options SupervisorOptions) *StdioSupervisor {
+	defaults := NewSupervisorOptions()
+	if options.InitialBackoff == 0 {
+		options.InitialBackoff = defaults.InitialBackoff
+	}
+	if options.MaxBackoff == 0 {
+		options.MaxBackoff = defaults.MaxBackoff
+	}
+	if options.MaxRestarts == 0 {
+		options.MaxRestarts = defaults.MaxRestarts
+	}
+	if options.RestartWindow == 0 {
+		options.RestartWindow = defaults.RestartWindow
+	}
+	if options.QueueTimeout == 0 {
+		options.QueueTimeout = defaults.QueueTimeout
+	}
+	if options.MaxQueuedCalls == 0 {
+		options.MaxQueuedCalls = defaults.MaxQueuedCalls
+	}
+
+	return &StdioSupervisor{
+		client:        client,
+		serverParams:  server,
+		options:       options,
+		ready:         make(chan struct{}),
+		done:          make(chan struct{}),
+		subscriptions: make(map[string]struct{}),
+	}
+}
+
+// Start launches the server and connects the client. If this fails the server is not restarted.
+func (s *StdioSupervisor) Start() error {
+	s.connection.Lock()
+	err := s.connect()
+	s.connection.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.setConnected()
+	s.job.Add(1)
+	go s.supervise()
+	return nil
+}
+
+// Client returns the supervised client. Calls made directly on the client are not queued while the server restarts.
+func (s *StdioSupervisor) Client() *Client {
+	return s.client
+}
+
+// Done is closed when the supervisor is closed or gives up restarting the server.
+func (s *StdioSupervisor) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns ErrSupervisorClosed or an error wrapping ErrCrashLoop once Done() is closed.
+func (s *StdioSupervisor) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Do calls `fn` with the client once the server is connected, waiting for up to QueueTimeout if it is restarting.
+func (s *StdioSupervisor) Do(fn func(client *Client) error) error {
+	s.mu.Lock()
+	if s.queued >= s.options.MaxQueuedCalls && !s.connected {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: too many calls waiting for the server to restart", ErrServerUnavailable)
+	}
+	s.queued++
+	s.mu.Unlock()
+
+	dequeue := sync.OnceFunc(func() {
+		s.mu.Lock()
+		s.queued--
+		s.mu.Unlock()
+	})
+	defer dequeue()
+
+	timeout := time.NewTimer(s.options.QueueTimeout)
+	defer timeout.Stop()
+
+	for {
+		s.mu.Lock()
+		ready := s.ready
+		err := s.err
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ready:
+		case <-s.done:
+			return s.Err()
+		case <-timeout.C:
+			return fmt.Errorf("%w: timed out waiting for the server to restart", ErrServerUnavailable)
+		}
+
+		s.connection.RLock()
+		s.mu.Lock()
+		connected := s.connected
+		transport := s.transport
+		s.mu.Unlock()
+		if connected && transport.Running() {
+			dequeue()
+			defer s.connection.RUnlock()
+			return fn(s.client)
+		}
+		// the server crashed before supervise() noticed, or again before we got the connection
+		s.connection.RUnlock()
+		s.setDisconnected(transport)
+	}
+}
+
+// SubscribeResources subscribes to a resource, and re-subscribes whenever the server is restarted.
+func (s *StdioSupervisor) SubscribeResources(params mcp.SubscribeRequestParams, options *mcp.RequestOptions) error {
+	return s.Do(func(client *Client) error {
+		if err := client.SubscribeResources(params, options); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.subscriptions[params.Uri] = struct{}{}
+		s.mu.Unlock()
+		return nil
+	})
+}
+
+func (s *StdioSupervisor) UnsubscribeResources(params mcp.UnsubscribeRequestParams, options *mcp.RequestOptions) error {
+	return s.Do(func(client *Client) error {
+		s.mu.Lock()
+		delete(s.subscriptions, params.Uri)
+		s.mu.Unlock()
+		return client.UnsubscribeResources(params, options)
+	})
+}
+
+// SetLoggingLevel sets the logging level on the server, and again whenever the server is restarted.
+func (s *StdioSupervisor) SetLoggingLevel(level mcp.LoggingLevel, options *mcp.RequestOptions) error {
+	return s.Do(func(client *Client) error {
+		if err := client.SetLogggingLevel(level, options); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.loggingLevel = &level
+		s.mu.Unlock()
+		return nil
+	})
+}
+
+// Close stops supervising and shuts the server down gracefully.
+func (s *StdioSupervisor) Close() error {
+	s.stop(ErrSupervisorClosed)
+
+	s.mu.Lock()
+	transport := s.transport
+	s.mu.Unlock()
+
+	var err error
+	if transport != nil {
+		err = transport.Close()
+	}
+	s.job.Wait()
+	return err
+}
+
+func (s *StdioSupervisor) stop(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.err = err
+	s.connected = false
+	close(s.done)
+}
+
+// connect must be called while holding the connection lock
+func (s *StdioSupervisor) connect() error {
+	transport := jsonrpc_client.NewStdioClientTransport(s.client.ctx, s.serverParams)
+	if err := s.client.Connect(transport); err != nil {
+		transport.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	s.transport = transport
+	stopped := s.err != nil
+	s.mu.Unlock()
+	if stopped {
+		// Close() was called while connecting
+		transport.Close()
+		return s.Err()
+	}
+	return nil
+}
+
+func (s *StdioSupervisor) setConnected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.connected = true
+		close(s.ready)
+	}
+}
+
+func (s *StdioSupervisor) setDisconnected(transport *jsonrpc_client.StdioClientTransport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.connected && s.transport == transport {
+		s.connected = false
+		s.ready = make(chan struct{})
+	}
+}
+
+func (s *StdioSupervisor) supervise() {
+	defer s.job.Done()
+
+	for {
+		s.mu.Lock()
+		transport := s.transport
+		s.mu.Unlock()
+
+		select {
+		case <-transport.Exited():
+		case <-s.done:
+			return
+		}
+
+		s.setDisconnected(transport)
+		if s.Err() != nil {
+			return
+		}
+		shared.DefaultLogger.Warn("MCP server %s exited: %v", s.serverParams.Command, transport.ExitError())
+
+		if !s.restart() {
+			return
+		}
+	}
+}
+
+// restart keeps trying to restart the server with exponential backoff, returning false if the supervisor gives up or is closed.
+func (s *StdioSupervisor) restart() bool {
+	for {
+		backoff, ok := s.nextBackoff()
+		if !ok {
+			shared.DefaultLogger.Error("MCP server %s is crash looping, giving up", s.serverParams.Command)
+			s.stop(fmt.Errorf("%w: %d restarts within %v", ErrCrashLoop, s.options.MaxRestarts, s.options.RestartWindow))
+			return false
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			return false
+		}
+
+		s.connection.Lock()
+		if s.Err() != nil {
+			s.connection.Unlock()
+			return false
+		}
+		err := s.connect()
+		if err == nil {
+			s.restoreState()
+		}
+		s.connection.Unlock()
+
+		if err == nil {
+			s.setConnected()
+			return true
+		}
+		if s.Err() != nil {
+			return false
+		}
+		shared.DefaultLogger.Warn("failed to restart MCP server %s: %v", s.serverParams.Command, err)
+	}
+}
+
+// nextBackoff records a restart, and returns false if the crash loop limit has been reached.
+func (s *StdioSupervisor) nextBackoff() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	recent := s.restarts[:0]
+	for _, restart := range s.restarts {
+		if now.Sub(restart) < s.options.RestartWindow {
+			recent = append(recent, restart)
+		}
+	}
+	if len(recent) >= s.options.MaxRestarts {
+		s.restarts = recent
+		return 0, false
+	}
+
+	backoff := s.options.InitialBackoff << len(recent)
+	if backoff > s.options.MaxBackoff || backoff <= 0 {
+		backoff = s.options.MaxBackoff
+	}
+	s.restarts = append(recent, now)
+	return backoff, true
+}
+
+// restoreState must be called while holding the connection lock
+func (s *StdioSupervisor) restoreState() {
+	s.mu.Lock()
+	level := s.loggingLevel
+	uris := make([]string, 0, len(s.subscriptions))
+	for uri := range s.subscriptions {
+		uris = append(uris, uri)
+	}
+	s.mu.Unlock()
+
+	if level != nil {
+		if err := s.client.SetLogggingLevel(*level, nil); err != nil {
+			shared.DefaultLogger.Warn("failed to restore logging level: %v", err)
+		}
+	}
+	for _, uri := range uris {
+		if err := s.client.SubscribeResources(mcp.SubscribeRequestParams{Uri: uri}, nil); err != nil {
+			shared.DefaultLogger.Warn("failed to restore subscription to %s: %v", uri, err)
+		}
+	}
+}
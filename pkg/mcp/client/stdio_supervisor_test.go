@@ -0,0 +1,208 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	jsonrpc_client "github.com/nalbion/go-mcp/pkg/jsonrpc/client"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperProcess is not a real test, it is run as a minimal MCP server by the tests below.
+// Each request is logged to stderr, and the "crash" method exits the process.
+// If GO_MCP_HELPER_MARKER is set, only the first process to be started completes initialization.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_MCP_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request struct {
+			Id     *int            `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil || request.Id == nil {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s %s\n", request.Method, request.Params)
+
+		result := map[string]any{}
+		switch request.Method {
+		case "initialize":
+			if marker := os.Getenv("GO_MCP_HELPER_MARKER"); marker != "" {
+				if _, err := os.Stat(marker); err == nil {
+					os.Exit(1)
+				}
+				os.WriteFile(marker, nil, 0o600)
+			}
+			result = map[string]any{
+				"protocolVersion": shared.LatestProtocolVersion,
+				"capabilities":    map[string]any{"logging": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "helper", "version": "1.0.0"},
+			}
+		case "crash":
+			os.Exit(2)
+		}
+
+		response, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": *request.Id, "result": result})
+		fmt.Println(string(response))
+	}
+	os.Exit(0)
+}
+
+type stderrLines struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *stderrLines) append(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, line)
+}
+
+func (l *stderrLines) count(line string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	count := 0
+	for _, l := range l.lines {
+		if l == line {
+			count++
+		}
+	}
+	return count
+}
+
+func newSupervisor(t *testing.T, env map[string]string, options SupervisorOptions) (*StdioSupervisor, *stderrLines) {
+	stderr := &stderrLines{}
+	env["GO_MCP_HELPER_PROCESS"] = "1"
+	client := NewClient(context.Background(), mcp.Implementation{Name: "test-client", Version: "1.0.0"}, ClientOptions{})
+	supervisor := NewStdioSupervisor(client, jsonrpc_client.StdioServerParameters{
+		Command:  os.Args[0],
+		Args:     []string{"-test.run=TestHelperProcess"},
+		Env:      env,
+		OnStderr: stderr.append,
+	}, options)
+	t.Cleanup(func() { supervisor.Close() })
+	return supervisor, stderr
+}
+
+func crash(client *Client) error {
+	return client.SendRequest("crash", nil, nil, nil)
+}
+
+func TestStdioSupervisor(t *testing.T) {
+	t.Run("should restart the server and restore subscriptions and logging level", func(t *testing.T) {
+		// given
+		options := NewSupervisorOptions()
+		options.InitialBackoff = 10 * time.Millisecond
+		supervisor, stderr := newSupervisor(t, map[string]string{}, options)
+		require.NoError(t, supervisor.Start())
+		require.NoError(t, supervisor.SubscribeResources(mcp.SubscribeRequestParams{Uri: "file:///a"}, nil))
+		require.NoError(t, supervisor.SetLoggingLevel(mcp.LoggingLevelWarning, nil))
+
+		// when the server crashes
+		err := supervisor.Do(crash)
+
+		// then the call fails, but later calls wait for the server to restart
+		var jsonrpcErr *jsonrpc.JSONRPCErrorError
+		require.ErrorAs(t, err, &jsonrpcErr)
+		assert.Equal(t, int(jsonrpc.ConnectionClosed), jsonrpcErr.Code)
+
+		require.NoError(t, supervisor.Do(func(client *Client) error {
+			return client.Ping(nil)
+		}))
+		assert.Equal(t, 2, stderr.count(`initialize {"capabilities":{},"clientInfo":{"name":"test-client","version":"1.0.0"},"protocolVersion":"`+shared.LatestProtocolVersion+`"}`))
+		assert.Equal(t, 2, stderr.count(`resources/subscribe {"uri":"file:///a"}`))
+		assert.Equal(t, 2, stderr.count(`logging/setLevel {"level":"warning"}`))
+	})
+
+	t.Run("should give up when the server is crash looping", func(t *testing.T) {
+		// given a server which can only be started once
+		options := NewSupervisorOptions()
+		options.InitialBackoff = 10 * time.Millisecond
+		options.MaxRestarts = 3
+		supervisor, stderr := newSupervisor(t, map[string]string{
+			"GO_MCP_HELPER_MARKER": filepath.Join(t.TempDir(), "started"),
+		}, options)
+		require.NoError(t, supervisor.Start())
+
+		// when
+		supervisor.Do(crash)
+
+		// then
+		select {
+		case <-supervisor.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the supervisor to give up")
+		}
+		assert.ErrorIs(t, supervisor.Err(), ErrCrashLoop)
+		assert.ErrorIs(t, supervisor.Do(crash), ErrCrashLoop)
+		assert.Equal(t, 1+3, stderr.count(`initialize {"capabilities":{},"clientInfo":{"name":"test-client","version":"1.0.0"},"protocolVersion":"`+shared.LatestProtocolVersion+`"}`))
+	})
+
+	t.Run("should time out calls waiting for the server to restart", func(t *testing.T) {
+		options := NewSupervisorOptions()
+		options.InitialBackoff = time.Minute
+		options.QueueTimeout = 50 * time.Millisecond
+		supervisor, _ := newSupervisor(t, map[string]string{}, options)
+		require.NoError(t, supervisor.Start())
+		supervisor.Do(crash)
+
+		err := supervisor.Do(func(client *Client) error {
+			return client.Ping(nil)
+		})
+
+		assert.ErrorIs(t, err, ErrServerUnavailable)
+	})
+
+	t.Run("should not restart the server after Close", func(t *testing.T) {
+		supervisor, _ := newSupervisor(t, map[string]string{}, NewSupervisorOptions())
+		require.NoError(t, supervisor.Start())
+
+		require.NoError(t, supervisor.Close())
+
+		assert.ErrorIs(t, supervisor.Err(), ErrSupervisorClosed)
+		assert.ErrorIs(t, supervisor.Do(crash), ErrSupervisorClosed)
+	})
+	t.Run("should apply the default options to zero fields", func(t *testing.T) {
+		// given
+		options := SupervisorOptions{InitialBackoff: 10 * time.Millisecond}
+
+		// when
+		supervisor, _ := newSupervisor(t, map[string]string{}, options)
+
+		// then
+		expected := NewSupervisorOptions()
+		expected.InitialBackoff = 10 * time.Millisecond
+		assert.Equal(t, expected, supervisor.options)
+	})
+
+	t.Run("should queue calls with zero value options", func(t *testing.T) {
+		// given
+		supervisor, _ := newSupervisor(t, map[string]string{}, SupervisorOptions{InitialBackoff: 10 * time.Millisecond})
+		require.NoError(t, supervisor.Start())
+		supervisor.Do(crash)
+
+		// when
+		err := supervisor.Do(func(client *Client) error {
+			return client.Ping(nil)
+		})
+
+		// then
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,279 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Content is a block of content in a CallToolResult, PromptMessage, SamplingMessage or CreateMessageResult.
+// It is one of TextContent, ImageContent, AudioContent, EmbeddedResource, ResourceLinkContent or,
+// for content types this package does not know about, UnknownContent.
+//
+//	result := mcp.CallToolResult{Content: []mcp.Content{
+//		mcp.Text("Found 1 file"),
+//		mcp.ResourceLink("file:///project/README.md", "README.md"),
+//	}}
+//
+// Use a type switch to handle the content received from the other side.
+type Content interface {
+	// ContentType returns the value of the "type" field, eg: "text" or "image".
+	ContentType() string
+	isContent()
+}
+
+const (
+	ContentTypeText         = "text"
+	ContentTypeImage        = "image"
+	ContentTypeAudio        = "audio"
+	ContentTypeResource     = "resource"
+	ContentTypeResourceLink = "resource_link"
+)
+
+func (TextContent) ContentType() string         { return ContentTypeText }
+func (ImageContent) ContentType() string        { return ContentTypeImage }
+func (AudioContent) ContentType() string        { return ContentTypeAudio }
+func (EmbeddedResource) ContentType() string    { return ContentTypeResource }
+func (ResourceLinkContent) ContentType() string { return ContentTypeResourceLink }
+
+func (TextContent) isContent()         {}
+func (ImageContent) isContent()        {}
+func (AudioContent) isContent()        {}
+func (EmbeddedResource) isContent()    {}
+func (ResourceLinkContent) isContent() {}
+func (UnknownContent) isContent()      {}
+
+// UnknownContent holds content with a type that this package does not recognise, so that it can be passed on unchanged.
+type UnknownContent struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+func (c UnknownContent) ContentType() string { return c.Type }
+
+// MarshalJSON implements json.Marshaler.
+func (c UnknownContent) MarshalJSON() ([]byte, error) {
+	if len(c.Raw) == 0 {
+		return json.Marshal(map[string]string{"type": c.Type})
+	}
+	return c.Raw, nil
+}
+
+// The MarshalJSON methods below fill in the "type" field, so that content built as a struct literal
+// without a Type is still valid on the wire.
+
+// MarshalJSON implements json.Marshaler.
+func (c TextContent) MarshalJSON() ([]byte, error) {
+	type plain TextContent
+	c.Type = ContentTypeText
+	return json.Marshal(plain(c))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c ImageContent) MarshalJSON() ([]byte, error) {
+	type plain ImageContent
+	c.Type = ContentTypeImage
+	return json.Marshal(plain(c))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c AudioContent) MarshalJSON() ([]byte, error) {
+	type plain AudioContent
+	c.Type = ContentTypeAudio
+	return json.Marshal(plain(c))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c EmbeddedResource) MarshalJSON() ([]byte, error) {
+	type plain EmbeddedResource
+	c.Type = ContentTypeResource
+	return json.Marshal(plain(c))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c ResourceLinkContent) MarshalJSON() ([]byte, error) {
+	type plain ResourceLinkContent
+	c.Type = ContentTypeResourceLink
+	return json.Marshal(plain(c))
+}
+
+// UnmarshalContent decodes a single content block, choosing the Go type from its "type" field.
+// JSON null decodes to a nil Content.
+func UnmarshalContent(b []byte) (Content, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || bytes.Equal(b, []byte("null")) {
+		return nil, nil
+	}
+
+	var header struct {
+		Type *string `json:"type"`
+	}
+	if err := json.Unmarshal(b, &header); err != nil {
+		return nil, err
+	}
+	if header.Type == nil {
+		return nil, fmt.Errorf("field type in content: required")
+	}
+
+	switch *header.Type {
+	case ContentTypeText:
+		var content TextContent
+		err := json.Unmarshal(b, &content)
+		return content, err
+	case ContentTypeImage:
+		var content ImageContent
+		err := json.Unmarshal(b, &content)
+		return content, err
+	case ContentTypeAudio:
+		var content AudioContent
+		err := json.Unmarshal(b, &content)
+		return content, err
+	case ContentTypeResource:
+		var content EmbeddedResource
+		err := json.Unmarshal(b, &content)
+		return content, err
+	case ContentTypeResourceLink:
+		var content ResourceLinkContent
+		err := json.Unmarshal(b, &content)
+		return content, err
+	default:
+		return UnknownContent{Type: *header.Type, Raw: append(json.RawMessage(nil), b...)}, nil
+	}
+}
+
+func unmarshalContentList(items []json.RawMessage) ([]Content, error) {
+	if items == nil {
+		return nil, nil
+	}
+	contents := make([]Content, len(items))
+	for i, item := range items {
+		content, err := UnmarshalContent(item)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		contents[i] = content
+	}
+	return contents, nil
+}
+
+// UnmarshalResourceContents decodes the contents of a resource as a *BlobResourceContents if it has a "blob" field,
+// otherwise as a *TextResourceContents. JSON null decodes to nil.
+func UnmarshalResourceContents(b []byte) (HasResourceContents, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || bytes.Equal(b, []byte("null")) {
+		return nil, nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	if _, ok := raw["blob"]; ok {
+		var contents BlobResourceContents
+		if err := json.Unmarshal(b, &contents); err != nil {
+			return nil, err
+		}
+		return &contents, nil
+	}
+	var contents TextResourceContents
+	if err := json.Unmarshal(b, &contents); err != nil {
+		return nil, err
+	}
+	return &contents, nil
+}
+
+// Text creates a text content block.
+func Text(text string) TextContent {
+	return TextContent{Type: ContentTypeText, Text: text}
+}
+
+// Image creates an image content block from base64-encoded data.
+func Image(data string, mimeType string) ImageContent {
+	return ImageContent{Type: ContentTypeImage, Data: data, MimeType: mimeType}
+}
+
+// ImageFromBytes creates an image content block from raw image data.
+// If `mimeType` is empty it is detected from the data, see http.DetectContentType().
+func ImageFromBytes(data []byte, mimeType string) ImageContent {
+	return Image(base64.StdEncoding.EncodeToString(data), detectMimeType(data, mimeType))
+}
+
+// Audio creates an audio content block from base64-encoded data.
+func Audio(data string, mimeType string) AudioContent {
+	return AudioContent{Type: ContentTypeAudio, Data: data, MimeType: mimeType}
+}
+
+// AudioFromBytes creates an audio content block from raw audio data.
+// If `mimeType` is empty it is detected from the data, see http.DetectContentType().
+func AudioFromBytes(data []byte, mimeType string) AudioContent {
+	return Audio(base64.StdEncoding.EncodeToString(data), detectMimeType(data, mimeType))
+}
+
+// ResourceLink creates a content block which refers to a resource by URI, without including its contents.
+func ResourceLink(uri string, name string) ResourceLinkContent {
+	return ResourceLinkContent{Type: ContentTypeResourceLink, Uri: uri, Name: name}
+}
+
+// EmbeddedTextResource creates a content block which includes the text of a resource.
+func EmbeddedTextResource(uri string, mimeType string, text string) EmbeddedResource {
+	return EmbeddedResource{
+		Type: ContentTypeResource,
+		Resource: &TextResourceContents{
+			ResourceContents: ResourceContents{Uri: uri, MimeType: mimeType},
+			Text:             text,
+		},
+	}
+}
+
+// EmbeddedBlobResource creates a content block which includes the binary data of a resource.
+func EmbeddedBlobResource(uri string, mimeType string, data []byte) EmbeddedResource {
+	return EmbeddedResource{
+		Type: ContentTypeResource,
+		Resource: &BlobResourceContents{
+			ResourceContents: ResourceContents{Uri: uri, MimeType: mimeType},
+			Blob:             base64.StdEncoding.EncodeToString(data),
+		},
+	}
+}
+
+// WithDescription sets the description of the linked resource.
+func (c ResourceLinkContent) WithDescription(description string) ResourceLinkContent {
+	c.Description = description
+	return c
+}
+
+// WithMimeType sets the MIME type of the linked resource.
+func (c ResourceLinkContent) WithMimeType(mimeType string) ResourceLinkContent {
+	c.MimeType = mimeType
+	return c
+}
+
+// WithSize sets the size of the linked resource in bytes.
+func (c ResourceLinkContent) WithSize(size int) ResourceLinkContent {
+	c.Size = &size
+	return c
+}
+
+// JoinText concatenates the text of all TextContent blocks, separated by newlines. Other content is ignored.
+func JoinText(contents []Content) string {
+	var texts []string
+	for _, content := range contents {
+		switch c := content.(type) {
+		case TextContent:
+			texts = append(texts, c.Text)
+		case *TextContent:
+			texts = append(texts, c.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func detectMimeType(data []byte, mimeType string) string {
+	if mimeType != "" {
+		return mimeType
+	}
+	return http.DetectContentType(data)
+}
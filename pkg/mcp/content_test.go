@@ -0,0 +1,98 @@
+package mcp_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContent(t *testing.T) {
+	t.Run("should round-trip each type of content in a tool result", func(t *testing.T) {
+		// given
+		png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+		result := mcp.CallToolResult{Content: []mcp.Content{
+			mcp.Text("hello"),
+			mcp.ImageFromBytes(png, ""),
+			mcp.Audio("UklGRg==", "audio/wav"),
+			mcp.EmbeddedTextResource("file:///notes.txt", "text/plain", "some notes"),
+			mcp.EmbeddedBlobResource("file:///data.bin", "application/octet-stream", []byte{1, 2, 3}),
+			mcp.ResourceLink("file:///README.md", "README.md").WithMimeType("text/markdown").WithSize(42),
+		}}
+
+		// when
+		data, err := json.Marshal(result)
+		require.NoError(t, err)
+		var decoded mcp.CallToolResult
+		err = json.Unmarshal(data, &decoded)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, result.Content, decoded.Content)
+		assert.Equal(t, "image/png", decoded.Content[1].(mcp.ImageContent).MimeType)
+		assert.Equal(t, "AQID", decoded.Content[4].(mcp.EmbeddedResource).Resource.GetValue())
+	})
+
+	t.Run("should set the type when marshalling content built without one", func(t *testing.T) {
+		// when
+		data, err := json.Marshal([]mcp.Content{mcp.TextContent{Text: "hi"}, mcp.ResourceLinkContent{Uri: "file:///a", Name: "a"}})
+
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"type":"text","text":"hi"},{"type":"resource_link","uri":"file:///a","name":"a"}]`, string(data))
+	})
+
+	t.Run("should keep content of an unknown type unchanged", func(t *testing.T) {
+		// given
+		data := []byte(`{"content":[{"type":"video","url":"https://example.com/a.mp4"}]}`)
+
+		// when
+		var result mcp.CallToolResult
+		err := json.Unmarshal(data, &result)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, result.Content, 1)
+		assert.Equal(t, "video", result.Content[0].ContentType())
+		remarshalled, err := json.Marshal(result)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"content":[{"type":"video","url":"https://example.com/a.mp4"}]}`, string(remarshalled))
+	})
+
+	t.Run("should reject content without a type", func(t *testing.T) {
+		// when
+		var result mcp.CallToolResult
+		err := json.Unmarshal([]byte(`{"content":[{"text":"hi"}]}`), &result)
+
+		// then
+		assert.ErrorContains(t, err, "field type in content: required")
+	})
+
+	t.Run("should decode the content of prompt and sampling messages", func(t *testing.T) {
+		// given
+		data := []byte(`{"role":"user","content":{"type":"audio","data":"UklGRg==","mimeType":"audio/wav"}}`)
+
+		// when
+		var prompt mcp.PromptMessage
+		require.NoError(t, json.Unmarshal(data, &prompt))
+		var sampling mcp.SamplingMessage
+		require.NoError(t, json.Unmarshal(data, &sampling))
+
+		// then
+		assert.Equal(t, mcp.Audio("UklGRg==", "audio/wav"), prompt.Content)
+		assert.Equal(t, mcp.Audio("UklGRg==", "audio/wav"), sampling.Content)
+	})
+
+	t.Run("should join the text content", func(t *testing.T) {
+		// given
+		contents := []mcp.Content{mcp.Text("one"), mcp.Image("AA==", "image/png"), mcp.Text("two")}
+
+		// when
+		text := mcp.JoinText(contents)
+
+		// then
+		assert.Equal(t, "one\ntwo", text)
+	})
+}
@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// elicitationStringFormats are the formats which clients are expected to support for string properties of a requestedSchema.
+var elicitationStringFormats = map[string]bool{"email": true, "uri": true, "date": true, "date-time": true}
+
+// ElicitationValidationError is returned when the content of an accepted ElicitResult does not match the requested schema.
+type ElicitationValidationError struct {
+	Errors []ValidationError
+}
+
+func (e *ElicitationValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.String()
+	}
+	return "invalid elicitation content: " + strings.Join(messages, "; ")
+}
+
+// ValidateElicitationSchema checks that a schema only uses the subset of JSON Schema allowed for elicitation/create,
+// so that clients can render it as a simple form: an object whose properties are strings, numbers, integers,
+// booleans or enums of strings, without nesting. eg:
+//
+//	mcp.NewObjectSchema().
+//		WithProperty("name", mcp.NewStringSchema().WithTitle("Name"), true).
+//		WithProperty("confirm", mcp.NewBooleanSchema(), true)
+func ValidateElicitationSchema(schema JSONSchema) error {
+	if schema.Type != "object" || len(schema.Types) > 0 {
+		return fmt.Errorf("the requested schema must have type \"object\"")
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := validateElicitationProperty(schema.Properties[name]); err != nil {
+			return fmt.Errorf("property %s of the requested schema: %w", name, err)
+		}
+	}
+	for _, name := range schema.Required {
+		if _, ok := schema.Properties[name]; !ok {
+			return fmt.Errorf("required property %s is not in the properties of the requested schema", name)
+		}
+	}
+	return nil
+}
+
+func validateElicitationProperty(property JSONSchema) error {
+	if property.Ref != "" || property.Items != nil || property.Properties != nil || property.AdditionalProperties != nil ||
+		len(property.AllOf) > 0 || len(property.AnyOf) > 0 || len(property.OneOf) > 0 || property.Not != nil {
+		return fmt.Errorf("nested schemas are not allowed")
+	}
+
+	switch property.Type {
+	case "string":
+		if property.Format != "" && !elicitationStringFormats[property.Format] {
+			return fmt.Errorf("unsupported format %q", property.Format)
+		}
+		for _, value := range property.Enum {
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("enum values must be strings")
+			}
+		}
+	case "number", "integer", "boolean":
+		if len(property.Enum) > 0 {
+			return fmt.Errorf("enum is only allowed for strings")
+		}
+	default:
+		return fmt.Errorf("type must be one of string, number, integer or boolean")
+	}
+	return nil
+}
+
+// IsAccepted returns true if the user submitted the requested information.
+func (r ElicitResult) IsAccepted() bool {
+	return r.Action == ElicitActionAccept
+}
+
+// Validate checks that the result has a valid action, and that the content of an accepted result matches the requested schema.
+func (r ElicitResult) Validate(requestedSchema JSONSchema) error {
+	switch r.Action {
+	case ElicitActionAccept:
+		content := r.Content
+		if content == nil {
+			content = map[string]interface{}{}
+		}
+		if errors := requestedSchema.Validate(normaliseJSON(content)); len(errors) > 0 {
+			return &ElicitationValidationError{Errors: errors}
+		}
+	case ElicitActionDecline, ElicitActionCancel:
+	default:
+		return fmt.Errorf("invalid elicitation action %q", r.Action)
+	}
+	return nil
+}
+
+// DecodeContent unmarshals the content of an accepted result into `v`, eg: a pointer to a struct.
+func (r ElicitResult) DecodeContent(v any) error {
+	if !r.IsAccepted() {
+		return fmt.Errorf("the elicitation was not accepted: %s", r.Action)
+	}
+	content, err := json.Marshal(r.Content)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElicitation(t *testing.T) {
+	confirmSchema := NewObjectSchema().
+		WithProperty("name", NewStringSchema().WithMinLength(1), true).
+		WithProperty("email", NewStringSchema().WithFormat("email"), false).
+		WithProperty("size", NewStringSchema().WithEnum("small", "large"), false).
+		WithProperty("count", NewIntegerSchema().WithMinimum(1), false).
+		WithProperty("confirm", NewBooleanSchema(), true)
+
+	t.Run("should allow a flat object of primitive properties", func(t *testing.T) {
+		assert.NoError(t, ValidateElicitationSchema(*confirmSchema))
+	})
+
+	t.Run("should reject schemas which can not be rendered as a form", func(t *testing.T) {
+		tests := map[string]struct {
+			schema   *JSONSchema
+			expected string
+		}{
+			"not an object":      {NewStringSchema(), `must have type "object"`},
+			"nested object":      {NewObjectSchema().WithProperty("address", NewObjectSchema(), false), "property address of the requested schema: nested schemas are not allowed"},
+			"array":              {NewObjectSchema().WithProperty("tags", NewArraySchema(NewStringSchema()), false), "nested schemas are not allowed"},
+			"unsupported format": {NewObjectSchema().WithProperty("ip", NewStringSchema().WithFormat("ipv4"), false), `unsupported format "ipv4"`},
+			"numeric enum":       {NewObjectSchema().WithProperty("size", NewNumberSchema().WithEnum(1, 2), false), "enum is only allowed for strings"},
+			"missing required":   {&JSONSchema{Type: "object", Required: []string{"name"}}, "required property name"},
+			"union of types":     {NewObjectSchema().WithProperty("name", NewStringSchema().Nullable(), false), "type must be one of"},
+		}
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				assert.ErrorContains(t, ValidateElicitationSchema(*test.schema), test.expected)
+			})
+		}
+	})
+
+	t.Run("should validate the content of an accepted result against the schema", func(t *testing.T) {
+		// given
+		valid := ElicitResult{Action: ElicitActionAccept, Content: map[string]interface{}{"name": "report.txt", "confirm": true, "count": 2}}
+		invalid := ElicitResult{Action: ElicitActionAccept, Content: map[string]interface{}{"name": "", "size": "medium"}}
+
+		// when
+		err := invalid.Validate(*confirmSchema)
+
+		// then
+		assert.NoError(t, valid.Validate(*confirmSchema))
+		var validationErr *ElicitationValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Len(t, validationErr.Errors, 3)
+	})
+
+	t.Run("should not validate the content of a declined or cancelled result", func(t *testing.T) {
+		assert.NoError(t, ElicitResult{Action: ElicitActionDecline}.Validate(*confirmSchema))
+		assert.NoError(t, ElicitResult{Action: ElicitActionCancel}.Validate(*confirmSchema))
+		assert.ErrorContains(t, ElicitResult{Action: "ignore"}.Validate(*confirmSchema), `invalid elicitation action "ignore"`)
+	})
+
+	t.Run("should decode the content of an accepted result", func(t *testing.T) {
+		// given
+		var result ElicitResult
+		require.NoError(t, json.Unmarshal([]byte(`{"action":"accept","content":{"name":"report.txt","confirm":true}}`), &result))
+
+		// when
+		var form struct {
+			Name    string `json:"name"`
+			Confirm bool   `json:"confirm"`
+		}
+		err := result.DecodeContent(&form)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, result.IsAccepted())
+		assert.Equal(t, "report.txt", form.Name)
+		assert.True(t, form.Confirm)
+	})
+
+	t.Run("should not decode the content of a declined result", func(t *testing.T) {
+		var form map[string]any
+		assert.ErrorContains(t, ElicitResult{Action: ElicitActionDecline}.DecodeContent(&form), "not accepted: decline")
+	})
+
+	t.Run("should reject unknown actions when unmarshalling", func(t *testing.T) {
+		var result ElicitResult
+		assert.ErrorContains(t, json.Unmarshal([]byte(`{"action":"ignore"}`), &result), "invalid value")
+		assert.ErrorContains(t, json.Unmarshal([]byte(`{}`), &result), "field action in ElicitResult: required")
+	})
+}
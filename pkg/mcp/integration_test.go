@@ -48,15 +48,10 @@ func TestIntegration(t *testing.T) {
 				},
 			},
 		},
-		func(params mcp.CallToolRequestParams) (mcp.CallToolResult, error) {
+		func(ctx context.Context, params mcp.CallToolRequestParams, extra *mcpserver.RequestHandlerExtra) (mcp.CallToolResult, error) {
 			message, _ := params.Arguments["message"].(string)
 			return mcp.CallToolResult{
-				Content: []interface{}{
-					map[string]interface{}{
-						"type": "text",
-						"text": message,
-					},
-				},
+				Content: []mcp.Content{mcp.Text(message)},
 			}, nil
 		},
 	)
@@ -118,10 +113,10 @@ func TestIntegration(t *testing.T) {
 		// then we get the expected response
 		require.NoError(t, err)
 		require.Len(t, result.Content, 1)
-		content, ok := result.Content[0].(map[string]interface{})
+		content, ok := result.Content[0].(mcp.TextContent)
 		require.True(t, ok)
-		assert.Equal(t, "text", content["type"])
-		assert.Equal(t, message, content["text"])
+		assert.Equal(t, "text", content.Type)
+		assert.Equal(t, message, content.Text)
 	})
 }
 
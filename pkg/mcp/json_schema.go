@@ -0,0 +1,444 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+)
+
+// JSONSchema is a JSON Schema, as used for the input schema of a Tool.
+//
+// Keywords without a field are kept in Extra, so that a schema received from a remote server
+// is sent on unchanged. So are keywords whose value does not fit the field, eg: the draft-04 boolean
+// "exclusiveMinimum" or the draft-07 array form of "items". A boolean schema, `true` or `false`, is represented by Boolean.
+type JSONSchema struct {
+	Schema  string                `json:"$schema,omitempty"`
+	Id      string                `json:"$id,omitempty"`
+	Ref     string                `json:"$ref,omitempty"`
+	Defs    map[string]JSONSchema `json:"$defs,omitempty"`
+	Comment string                `json:"$comment,omitempty"`
+
+	// Type is the type of the value, eg: "string". Use Types if more than one type is allowed, eg: ["string", "null"]
+	Type  string   `json:"-"`
+	Types []string `json:"-"`
+
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Default     any    `json:"default,omitempty"`
+	Examples    []any  `json:"examples,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+	ReadOnly    bool   `json:"readOnly,omitempty"`
+	WriteOnly   bool   `json:"writeOnly,omitempty"`
+
+	Enum  []any `json:"enum,omitempty"`
+	Const any   `json:"const,omitempty"`
+
+	// numbers
+	MultipleOf       *float64 `json:"multipleOf,omitempty"`
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+
+	// strings
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	Format    string `json:"format,omitempty"`
+	// ContentEncoding is the encoding of binary data in a string, eg: "base64"
+	ContentEncoding  string `json:"contentEncoding,omitempty"`
+	ContentMediaType string `json:"contentMediaType,omitempty"`
+
+	// arrays
+	Items       *JSONSchema  `json:"items,omitempty"`
+	PrefixItems []JSONSchema `json:"prefixItems,omitempty"`
+	MinItems    *int         `json:"minItems,omitempty"`
+	MaxItems    *int         `json:"maxItems,omitempty"`
+	UniqueItems bool         `json:"uniqueItems,omitempty"`
+
+	// objects
+	Properties           map[string]JSONSchema `json:"properties,omitempty"`
+	PatternProperties    map[string]JSONSchema `json:"patternProperties,omitempty"`
+	AdditionalProperties *JSONSchema           `json:"additionalProperties,omitempty"`
+	Required             []string              `json:"required,omitempty"`
+	MinProperties        *int                  `json:"minProperties,omitempty"`
+	MaxProperties        *int                  `json:"maxProperties,omitempty"`
+
+	// composition
+	AllOf []JSONSchema `json:"allOf,omitempty"`
+	AnyOf []JSONSchema `json:"anyOf,omitempty"`
+	OneOf []JSONSchema `json:"oneOf,omitempty"`
+	Not   *JSONSchema  `json:"not,omitempty"`
+
+	// HasDefault and HasConst are set with Default and Const, so that a value of null can be told from no value.
+	HasDefault bool `json:"-"`
+	HasConst   bool `json:"-"`
+	// Boolean is set for the schemas `true`, which allows any value, and `false`, which allows none.
+	Boolean *bool `json:"-"`
+	// Extra contains any other keywords, eg: "definitions" or vendor extensions
+	Extra map[string]any `json:"-"`
+}
+
+// Property definition for a tool input schema
+type ToolInputSchemaProperty = JSONSchema
+
+type ToolInputSchemaProperties = map[string]JSONSchema
+
+// jsonSchemaKeywords are the keywords which have a field in JSONSchema, and are not kept in Extra.
+var jsonSchemaKeywords = func() map[string]bool {
+	keywords := map[string]bool{"type": true}
+	t := reflect.TypeFor[JSONSchema]()
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "-" {
+			keywords[name] = true
+		}
+	}
+	return keywords
+}()
+
+// plainJSONSchema has the fields of JSONSchema without its methods, to avoid recursion when (un)marshalling.
+type plainJSONSchema JSONSchema
+
+type jsonSchemaWithType struct {
+	*plainJSONSchema
+	Type any `json:"type,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s JSONSchema) MarshalJSON() ([]byte, error) {
+	if s.Boolean != nil {
+		return json.Marshal(*s.Boolean)
+	}
+
+	withType := jsonSchemaWithType{plainJSONSchema: (*plainJSONSchema)(&s)}
+	if len(s.Types) > 0 {
+		withType.Type = s.Types
+	} else if s.Type != "" {
+		withType.Type = s.Type
+	}
+	b, err := json.Marshal(withType)
+
+	// omitempty drops a default or const of null, so they are added with the keywords in Extra
+	extra := s.Extra
+	if (s.HasDefault && s.Default == nil) || (s.HasConst && s.Const == nil) {
+		extra = maps.Clone(s.Extra)
+		if extra == nil {
+			extra = map[string]any{}
+		}
+		if s.HasDefault && s.Default == nil {
+			extra["default"] = nil
+		}
+		if s.HasConst && s.Const == nil {
+			extra["const"] = nil
+		}
+	}
+	if err != nil || len(extra) == 0 {
+		return b, err
+	}
+
+	var merged map[string]any
+	if err := json.Unmarshal(b, &merged); err != nil {
+		return nil, err
+	}
+	for keyword, value := range extra {
+		// a keyword in Extra did not fit its field when unmarshalled, so the field takes precedence if it is set
+		if _, ok := merged[keyword]; !ok {
+			merged[keyword] = value
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *JSONSchema) UnmarshalJSON(b []byte) error {
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) > 0 && trimmed[0] != '{' {
+		var boolean bool
+		if err := json.Unmarshal(trimmed, &boolean); err != nil {
+			return fmt.Errorf("a JSON Schema must be an object or a boolean: %w", err)
+		}
+		*s = JSONSchema{Boolean: &boolean}
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	// each keyword is decoded on its own, so that a value which does not fit its field, eg: from another draft,
+	// is kept in Extra rather than failing the whole schema
+	var plain plainJSONSchema
+	valid := make(map[string]json.RawMessage, len(raw))
+	for keyword, value := range raw {
+		switch {
+		case keyword == "type":
+			if schemaType, schemaTypes, ok := parseSchemaType(value); ok {
+				plain.Type, plain.Types = schemaType, schemaTypes
+				continue
+			}
+		case jsonSchemaKeywords[keyword]:
+			var field plainJSONSchema
+			if json.Unmarshal(singleKeyword(keyword, value), &field) == nil {
+				valid[keyword] = value
+				continue
+			}
+		}
+
+		var extra any
+		if err := json.Unmarshal(value, &extra); err != nil {
+			return err
+		}
+		if plain.Extra == nil {
+			plain.Extra = map[string]any{}
+		}
+		plain.Extra[keyword] = extra
+	}
+
+	if len(valid) > 0 {
+		content, err := json.Marshal(valid)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(content, &plain); err != nil {
+			return err
+		}
+		_, plain.HasDefault = valid["default"]
+		_, plain.HasConst = valid["const"]
+	}
+
+	*s = JSONSchema(plain)
+	return nil
+}
+
+// parseSchemaType parses the value of the "type" keyword, which is a type name or an array of them.
+func parseSchemaType(value json.RawMessage) (string, []string, bool) {
+	var schemaType string
+	if err := json.Unmarshal(value, &schemaType); err == nil {
+		return schemaType, nil, true
+	}
+	var schemaTypes []string
+	if err := json.Unmarshal(value, &schemaTypes); err == nil {
+		return "", schemaTypes, true
+	}
+	return "", nil, false
+}
+
+func singleKeyword(keyword string, value json.RawMessage) []byte {
+	content, _ := json.Marshal(map[string]json.RawMessage{keyword: value})
+	return content
+}
+
+// TypeNames returns the types allowed by the schema, from Types or Type.
+func (s JSONSchema) TypeNames() []string {
+	if len(s.Types) > 0 {
+		return s.Types
+	}
+	if s.Type != "" {
+		return []string{s.Type}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (j ToolInputSchema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(JSONSchema(j))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ToolInputSchema) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["type"]; raw != nil && !ok {
+		return fmt.Errorf("field type in ToolInputSchema: required")
+	}
+	var schema JSONSchema
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return err
+	}
+	*j = ToolInputSchema(schema)
+	return nil
+}
+
+// TrueSchema returns the schema `true`, which allows any value.
+func TrueSchema() *JSONSchema {
+	value := true
+	return &JSONSchema{Boolean: &value}
+}
+
+// FalseSchema returns the schema `false`, which allows no value, eg: for AdditionalProperties.
+func FalseSchema() *JSONSchema {
+	value := false
+	return &JSONSchema{Boolean: &value}
+}
+
+// NewObjectSchema starts building a schema for an object. Add properties with WithProperty().
+func NewObjectSchema() *JSONSchema {
+	return &JSONSchema{Type: "object", Properties: map[string]JSONSchema{}}
+}
+
+// NewStringSchema starts building a schema for a string.
+func NewStringSchema() *JSONSchema {
+	return &JSONSchema{Type: "string"}
+}
+
+// NewNumberSchema starts building a schema for a number.
+func NewNumberSchema() *JSONSchema {
+	return &JSONSchema{Type: "number"}
+}
+
+// NewIntegerSchema starts building a schema for an integer.
+func NewIntegerSchema() *JSONSchema {
+	return &JSONSchema{Type: "integer"}
+}
+
+// NewBooleanSchema starts building a schema for a boolean.
+func NewBooleanSchema() *JSONSchema {
+	return &JSONSchema{Type: "boolean"}
+}
+
+// NewArraySchema starts building a schema for an array whose elements match `items`.
+func NewArraySchema(items *JSONSchema) *JSONSchema {
+	return &JSONSchema{Type: "array", Items: items}
+}
+
+// NewRefSchema returns a schema which refers to a definition, eg: NewRefSchema("#/$defs/address").
+func NewRefSchema(ref string) *JSONSchema {
+	return &JSONSchema{Ref: ref}
+}
+
+// NewOneOfSchema returns a schema which matches exactly one of `schemas`.
+func NewOneOfSchema(schemas ...*JSONSchema) *JSONSchema {
+	return &JSONSchema{OneOf: derefSchemas(schemas)}
+}
+
+// NewAnyOfSchema returns a schema which matches at least one of `schemas`.
+func NewAnyOfSchema(schemas ...*JSONSchema) *JSONSchema {
+	return &JSONSchema{AnyOf: derefSchemas(schemas)}
+}
+
+func derefSchemas(schemas []*JSONSchema) []JSONSchema {
+	values := make([]JSONSchema, len(schemas))
+	for i, schema := range schemas {
+		values[i] = *schema
+	}
+	return values
+}
+
+// WithProperty adds a property to an object schema, and adds it to Required if `required` is true.
+func (s *JSONSchema) WithProperty(name string, property *JSONSchema, required bool) *JSONSchema {
+	if s.Properties == nil {
+		s.Properties = map[string]JSONSchema{}
+	}
+	s.Properties[name] = *property
+	if required {
+		s.Required = append(s.Required, name)
+	}
+	return s
+}
+
+// WithAdditionalProperties sets the schema of properties not listed in Properties.
+// Use FalseSchema() to disallow them.
+func (s *JSONSchema) WithAdditionalProperties(additionalProperties *JSONSchema) *JSONSchema {
+	s.AdditionalProperties = additionalProperties
+	return s
+}
+
+// WithDef adds a definition which can be referred to with NewRefSchema("#/$defs/" + name).
+func (s *JSONSchema) WithDef(name string, def *JSONSchema) *JSONSchema {
+	if s.Defs == nil {
+		s.Defs = map[string]JSONSchema{}
+	}
+	s.Defs[name] = *def
+	return s
+}
+
+func (s *JSONSchema) WithTitle(title string) *JSONSchema {
+	s.Title = title
+	return s
+}
+
+func (s *JSONSchema) WithDescription(description string) *JSONSchema {
+	s.Description = description
+	return s
+}
+
+func (s *JSONSchema) WithDefault(value any) *JSONSchema {
+	s.Default = value
+	s.HasDefault = true
+	return s
+}
+
+func (s *JSONSchema) WithEnum(values ...any) *JSONSchema {
+	s.Enum = append(s.Enum, values...)
+	return s
+}
+
+func (s *JSONSchema) WithConst(value any) *JSONSchema {
+	s.Const = value
+	s.HasConst = true
+	return s
+}
+
+func (s *JSONSchema) WithFormat(format string) *JSONSchema {
+	s.Format = format
+	return s
+}
+
+func (s *JSONSchema) WithPattern(pattern string) *JSONSchema {
+	s.Pattern = pattern
+	return s
+}
+
+func (s *JSONSchema) WithMinimum(minimum float64) *JSONSchema {
+	s.Minimum = &minimum
+	return s
+}
+
+func (s *JSONSchema) WithMaximum(maximum float64) *JSONSchema {
+	s.Maximum = &maximum
+	return s
+}
+
+func (s *JSONSchema) WithMinLength(minLength int) *JSONSchema {
+	s.MinLength = &minLength
+	return s
+}
+
+func (s *JSONSchema) WithMaxLength(maxLength int) *JSONSchema {
+	s.MaxLength = &maxLength
+	return s
+}
+
+func (s *JSONSchema) WithMinItems(minItems int) *JSONSchema {
+	s.MinItems = &minItems
+	return s
+}
+
+func (s *JSONSchema) WithMaxItems(maxItems int) *JSONSchema {
+	s.MaxItems = &maxItems
+	return s
+}
+
+// Nullable allows the value to also be null, eg: "type": ["string", "null"].
+func (s *JSONSchema) Nullable() *JSONSchema {
+	types := s.TypeNames()
+	for _, t := range types {
+		if t == "null" {
+			return s
+		}
+	}
+	s.Types = append(append([]string{}, types...), "null")
+	s.Type = ""
+	return s
+}
+
+// ToolInputSchema converts an object schema built with NewObjectSchema() to the input schema of a Tool.
+func (s *JSONSchema) ToolInputSchema() ToolInputSchema {
+	return ToolInputSchema(*s)
+}
@@ -0,0 +1,157 @@
+package mcp_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	t.Run("should round-trip a schema which uses every keyword", func(t *testing.T) {
+		// given
+		schema := `{
+			"type": "object",
+			"$defs": {"address": {"type": "object", "properties": {"street": {"type": "string"}}}},
+			"definitions": {"legacy": {"type": "string"}},
+			"properties": {
+				"name": {"type": "string", "minLength": 1, "maxLength": 20, "pattern": "^[a-z]+$", "format": "hostname"},
+				"age": {"type": ["integer", "null"], "minimum": 0, "exclusiveMaximum": 150, "default": 18},
+				"unit": {"enum": ["celsius", "fahrenheit"], "description": "Temperature unit"},
+				"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "uniqueItems": true},
+				"home": {"$ref": "#/$defs/address"},
+				"contact": {"oneOf": [{"type": "string", "format": "email"}, {"type": "object", "additionalProperties": {"type": "string"}}]},
+				"id": {"anyOf": [{"type": "string"}, {"type": "integer"}], "x-vendor": {"display": "ID"}}
+			},
+			"required": ["name"],
+			"additionalProperties": false
+		}`
+
+		// when
+		var tool mcp.Tool
+		require.NoError(t, json.Unmarshal([]byte(`{"name": "test", "inputSchema": `+schema+`}`), &tool))
+		data, err := json.Marshal(tool.InputSchema)
+
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, schema, string(data))
+		assert.Equal(t, []string{"integer", "null"}, tool.InputSchema.Properties["age"].Types)
+		assert.Equal(t, "#/$defs/address", tool.InputSchema.Properties["home"].Ref)
+		assert.Len(t, tool.InputSchema.Properties["contact"].OneOf, 2)
+		assert.False(t, *tool.InputSchema.AdditionalProperties.Boolean)
+		assert.Equal(t, map[string]any{"display": "ID"}, tool.InputSchema.Properties["id"].Extra["x-vendor"])
+	})
+
+	t.Run("should still require the type of a tool's input schema", func(t *testing.T) {
+		var tool mcp.Tool
+		err := json.Unmarshal([]byte(`{"name": "test", "inputSchema": {"properties": {}}}`), &tool)
+
+		assert.ErrorContains(t, err, "field type in ToolInputSchema: required")
+	})
+
+	t.Run("should reject an invalid schema", func(t *testing.T) {
+		var schema mcp.JSONSchema
+
+		assert.Error(t, json.Unmarshal([]byte(`"string"`), &schema))
+	})
+
+	t.Run("should keep keywords which do not fit their field in Extra", func(t *testing.T) {
+		for name, schema := range map[string]string{
+			"a draft-03 required property": `{"type": "object", "properties": {"name": {"type": "string", "required": true}}}`,
+			"a draft-04 exclusiveMinimum":  `{"type": "object", "properties": {"age": {"type": "integer", "minimum": 0, "exclusiveMinimum": true}}}`,
+			"a draft-07 tuple":             `{"type": "object", "properties": {"point": {"type": "array", "items": [{"type": "number"}, {"type": "number"}]}}}`,
+			"an invalid type":              `{"type": "object", "properties": {"id": {"type": 1}}}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				// given
+				listToolsResult := `{"tools": [{"name": "test", "inputSchema": ` + schema + `}]}`
+
+				// when
+				var result mcp.ListToolsResult
+				err := json.Unmarshal([]byte(listToolsResult), &result)
+
+				// then
+				require.NoError(t, err)
+				require.Len(t, result.Tools, 1)
+				data, err := json.Marshal(result.Tools[0].InputSchema)
+				require.NoError(t, err)
+				assert.JSONEq(t, schema, string(data))
+			})
+		}
+	})
+
+	t.Run("should prefer a field to a keyword of the same name in Extra", func(t *testing.T) {
+		// given
+		minimum := 1.0
+		schema := mcp.JSONSchema{Type: "integer", Minimum: &minimum, Extra: map[string]any{"minimum": "one", "exclusiveMinimum": true}}
+
+		// when
+		data, err := json.Marshal(schema)
+
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type": "integer", "minimum": 1, "exclusiveMinimum": true}`, string(data))
+	})
+
+	t.Run("should round-trip a const and default of null", func(t *testing.T) {
+		// given
+		schema := `{"type": "object", "properties": {"parent": {"type": ["string", "null"], "default": null}, "deleted": {"const": null}}}`
+
+		// when
+		var inputSchema mcp.JSONSchema
+		require.NoError(t, json.Unmarshal([]byte(schema), &inputSchema))
+		data, err := json.Marshal(inputSchema)
+
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, schema, string(data))
+		assert.True(t, inputSchema.Properties["parent"].HasDefault)
+		assert.True(t, inputSchema.Properties["deleted"].HasConst)
+		assert.False(t, inputSchema.Properties["parent"].HasConst)
+		assert.Empty(t, inputSchema.Properties["deleted"].Validate(nil))
+		assert.NotEmpty(t, inputSchema.Properties["deleted"].Validate("yes"))
+	})
+
+	t.Run("should marshal a const of null set in code", func(t *testing.T) {
+		// when
+		data, err := json.Marshal(mcp.NewObjectSchema().WithProperty("deleted", new(mcp.JSONSchema).WithConst(nil), false))
+
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"type": "object", "properties": {"deleted": {"const": null}}}`, string(data))
+	})
+
+	t.Run("should build schemas in code", func(t *testing.T) {
+		// when
+		schema := mcp.NewObjectSchema().
+			WithDef("point", mcp.NewObjectSchema().
+				WithProperty("x", mcp.NewNumberSchema(), true).
+				WithProperty("y", mcp.NewNumberSchema(), true)).
+			WithProperty("city", mcp.NewStringSchema().WithDescription("Name of the city").WithMinLength(1), true).
+			WithProperty("days", mcp.NewIntegerSchema().WithMinimum(1).WithMaximum(7).WithDefault(1), false).
+			WithProperty("unit", mcp.NewStringSchema().WithEnum("celsius", "fahrenheit").Nullable(), false).
+			WithProperty("location", mcp.NewOneOfSchema(mcp.NewRefSchema("#/$defs/point"), mcp.NewStringSchema()), false).
+			WithProperty("tags", mcp.NewArraySchema(mcp.NewStringSchema()).WithMaxItems(3), false).
+			WithAdditionalProperties(mcp.FalseSchema()).
+			ToolInputSchema()
+		data, err := json.Marshal(schema)
+
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"type": "object",
+			"$defs": {"point": {"type": "object", "properties": {"x": {"type": "number"}, "y": {"type": "number"}}, "required": ["x", "y"]}},
+			"properties": {
+				"city": {"type": "string", "description": "Name of the city", "minLength": 1},
+				"days": {"type": "integer", "minimum": 1, "maximum": 7, "default": 1},
+				"unit": {"type": ["string", "null"], "enum": ["celsius", "fahrenheit"]},
+				"location": {"oneOf": [{"$ref": "#/$defs/point"}, {"type": "string"}]},
+				"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 3}
+			},
+			"required": ["city"],
+			"additionalProperties": false
+		}`, string(data))
+	})
+}
@@ -0,0 +1,470 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes a value which does not match a JSONSchema.
+type ValidationError struct {
+	// Path to the invalid value, eg: "items[2].name", or "" for the value itself
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) String() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks a value, as parsed from JSON, against the schema and returns every error found.
+// Only $refs within the schema itself are supported, eg: "#/$defs/address".
+func (s JSONSchema) Validate(value any) []ValidationError {
+	validator := &schemaValidator{root: s}
+	return validator.validate(s, value, "", 0)
+}
+
+// Prepare returns a copy of a value, as parsed from JSON, with `default` values filled in for missing properties.
+// If `coerce` is true, values are also converted to the type the schema expects where that is unambiguous,
+// eg: "5" to 5 or "true" to true. The result should then be checked with Validate().
+func (s JSONSchema) Prepare(value any, coerce bool) any {
+	preparer := &schemaPreparer{validator: &schemaValidator{root: s}, coerce: coerce}
+	return preparer.prepare(s, normaliseJSON(value), 0)
+}
+
+// maxRefDepth limits how many $refs are followed without descending into the value, in case of a cycle.
+const maxRefDepth = 32
+
+// schemaValidator validates values against a schema and the definitions that it refers to.
+type schemaValidator struct {
+	root JSONSchema
+}
+
+func (v *schemaValidator) validate(schema JSONSchema, value any, path string, refDepth int) []ValidationError {
+	if schema.Boolean != nil {
+		if !*schema.Boolean {
+			return []ValidationError{{Path: path, Message: "is not allowed"}}
+		}
+		return nil
+	}
+
+	var errors []ValidationError
+	fail := func(format string, args ...any) {
+		errors = append(errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if schema.Ref != "" {
+		resolved, err := v.resolveRef(schema.Ref)
+		if err != nil {
+			fail("%s", err)
+		} else if refDepth >= maxRefDepth {
+			fail("too many nested $refs at %s", schema.Ref)
+		} else {
+			errors = append(errors, v.validate(resolved, value, path, refDepth+1)...)
+		}
+	}
+
+	if message := checkTypes(schema.TypeNames(), value); message != "" {
+		return append(errors, ValidationError{Path: path, Message: message})
+	}
+
+	if len(schema.Enum) > 0 && !containsJSONValue(schema.Enum, value) {
+		allowed, _ := json.Marshal(schema.Enum)
+		fail("must be one of %s", allowed)
+	}
+	if (schema.HasConst || schema.Const != nil) && !reflect.DeepEqual(normaliseJSON(schema.Const), value) {
+		expected, _ := json.Marshal(schema.Const)
+		fail("must be %s", expected)
+	}
+
+	switch value := value.(type) {
+	case float64:
+		if schema.Minimum != nil && value < *schema.Minimum {
+			fail("must be >= %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && value > *schema.Maximum {
+			fail("must be <= %v", *schema.Maximum)
+		}
+		if schema.ExclusiveMinimum != nil && value <= *schema.ExclusiveMinimum {
+			fail("must be > %v", *schema.ExclusiveMinimum)
+		}
+		if schema.ExclusiveMaximum != nil && value >= *schema.ExclusiveMaximum {
+			fail("must be < %v", *schema.ExclusiveMaximum)
+		}
+		if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+			if quotient := value / *schema.MultipleOf; quotient != math.Trunc(quotient) {
+				fail("must be a multiple of %v", *schema.MultipleOf)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(value)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			fail("must be at least %d characters", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			fail("must be at most %d characters", *schema.MaxLength)
+		}
+		if schema.Pattern != "" {
+			if matched, err := regexp.MatchString(schema.Pattern, value); err == nil && !matched {
+				fail("must match the pattern %q", schema.Pattern)
+			}
+		}
+	case []any:
+		if schema.MinItems != nil && len(value) < *schema.MinItems {
+			fail("must have at least %d items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(value) > *schema.MaxItems {
+			fail("must have at most %d items", *schema.MaxItems)
+		}
+		if schema.UniqueItems && !uniqueJSONValues(value) {
+			fail("must not contain duplicate items")
+		}
+		for i, item := range value {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if i < len(schema.PrefixItems) {
+				errors = append(errors, v.validate(schema.PrefixItems[i], item, itemPath, 0)...)
+			} else if schema.Items != nil {
+				errors = append(errors, v.validate(*schema.Items, item, itemPath, 0)...)
+			}
+		}
+	case map[string]any:
+		errors = append(errors, v.validateObject(schema, value, path)...)
+	}
+
+	for _, subschema := range schema.AllOf {
+		errors = append(errors, v.validate(subschema, value, path, refDepth)...)
+	}
+	if len(schema.AnyOf) > 0 && v.countMatches(schema.AnyOf, value, path, refDepth) == 0 {
+		fail("must match at least one schema in anyOf")
+	}
+	if len(schema.OneOf) > 0 {
+		if matches := v.countMatches(schema.OneOf, value, path, refDepth); matches != 1 {
+			fail("must match exactly one schema in oneOf, but matches %d", matches)
+		}
+	}
+	if schema.Not != nil && len(v.validate(*schema.Not, value, path, refDepth)) == 0 {
+		fail("must not match the schema in not")
+	}
+	return errors
+}
+
+func (v *schemaValidator) validateObject(schema JSONSchema, object map[string]any, path string) []ValidationError {
+	var errors []ValidationError
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			errors = append(errors, ValidationError{Path: joinPath(path, name), Message: "is required"})
+		}
+	}
+	if schema.MinProperties != nil && len(object) < *schema.MinProperties {
+		errors = append(errors, ValidationError{Path: path, Message: fmt.Sprintf("must have at least %d properties", *schema.MinProperties)})
+	}
+	if schema.MaxProperties != nil && len(object) > *schema.MaxProperties {
+		errors = append(errors, ValidationError{Path: path, Message: fmt.Sprintf("must have at most %d properties", *schema.MaxProperties)})
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPath := joinPath(path, name)
+		matched := false
+		if property, ok := schema.Properties[name]; ok {
+			matched = true
+			errors = append(errors, v.validate(property, object[name], propertyPath, 0)...)
+		}
+		for pattern, property := range schema.PatternProperties {
+			if ok, err := regexp.MatchString(pattern, name); err == nil && ok {
+				matched = true
+				errors = append(errors, v.validate(property, object[name], propertyPath, 0)...)
+			}
+		}
+		if !matched && schema.AdditionalProperties != nil {
+			if schema.AdditionalProperties.Boolean != nil && !*schema.AdditionalProperties.Boolean {
+				errors = append(errors, ValidationError{Path: propertyPath, Message: "is not an allowed property"})
+			} else {
+				errors = append(errors, v.validate(*schema.AdditionalProperties, object[name], propertyPath, 0)...)
+			}
+		}
+	}
+	return errors
+}
+
+func (v *schemaValidator) countMatches(schemas []JSONSchema, value any, path string, refDepth int) int {
+	matches := 0
+	for _, subschema := range schemas {
+		if len(v.validate(subschema, value, path, refDepth)) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// resolveRef finds the schema referred to by a $ref within the root schema, eg: "#/$defs/address".
+// References to other documents are not supported.
+func (v *schemaValidator) resolveRef(ref string) (JSONSchema, error) {
+	if ref == "#" {
+		return v.root, nil
+	}
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return JSONSchema{}, fmt.Errorf("unsupported $ref %q", ref)
+	}
+
+	var current any = v.root
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		var next any
+		switch node := current.(type) {
+		case JSONSchema:
+			next = schemaKeyword(node, token)
+		case map[string]JSONSchema:
+			if schema, ok := node[token]; ok {
+				next = schema
+			}
+		case map[string]any:
+			next = node[token]
+		case []JSONSchema:
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node) {
+				next = node[i]
+			}
+		}
+		if next == nil {
+			return JSONSchema{}, fmt.Errorf("$ref %q not found", ref)
+		}
+		current = next
+	}
+
+	switch resolved := current.(type) {
+	case JSONSchema:
+		return resolved, nil
+	case map[string]any:
+		// eg: a definition in "definitions", which is kept in Extra
+		var schema JSONSchema
+		content, _ := json.Marshal(resolved)
+		if err := json.Unmarshal(content, &schema); err != nil {
+			return JSONSchema{}, fmt.Errorf("$ref %q: %w", ref, err)
+		}
+		return schema, nil
+	}
+	return JSONSchema{}, fmt.Errorf("$ref %q is not a schema", ref)
+}
+
+// schemaKeyword returns the value of a keyword which may contain schemas, for resolving a $ref.
+func schemaKeyword(schema JSONSchema, keyword string) any {
+	switch keyword {
+	case "$defs":
+		return schema.Defs
+	case "properties":
+		return schema.Properties
+	case "patternProperties":
+		return schema.PatternProperties
+	case "items":
+		if schema.Items != nil {
+			return *schema.Items
+		}
+	case "additionalProperties":
+		if schema.AdditionalProperties != nil {
+			return *schema.AdditionalProperties
+		}
+	case "not":
+		if schema.Not != nil {
+			return *schema.Not
+		}
+	case "prefixItems":
+		return schema.PrefixItems
+	case "allOf":
+		return schema.AllOf
+	case "anyOf":
+		return schema.AnyOf
+	case "oneOf":
+		return schema.OneOf
+	default:
+		return schema.Extra[keyword]
+	}
+	return nil
+}
+
+// checkTypes returns an error message if the value is not one of the types, or "" if it is, or no types are given.
+func checkTypes(types []string, value any) string {
+	if len(types) == 0 {
+		return ""
+	}
+	var message string
+	for _, schemaType := range types {
+		if message = checkType(schemaType, value); message == "" {
+			return ""
+		}
+	}
+	if len(types) == 1 {
+		return message
+	}
+	return fmt.Sprintf("must be of type %s", strings.Join(types, " or "))
+}
+
+func checkType(schemaType string, value any) string {
+	var ok bool
+	switch schemaType {
+	case "string":
+		_, ok = value.(string)
+	case "boolean":
+		_, ok = value.(bool)
+	case "number":
+		_, ok = value.(float64)
+	case "integer":
+		var number float64
+		number, ok = value.(float64)
+		if ok && number != math.Trunc(number) {
+			return "must be an integer"
+		}
+	case "array":
+		_, ok = value.([]any)
+	case "object":
+		_, ok = value.(map[string]any)
+	case "null":
+		ok = value == nil
+	default:
+		return ""
+	}
+	if !ok {
+		return fmt.Sprintf("must be of type %s", schemaType)
+	}
+	return ""
+}
+
+func containsJSONValue(values []any, value any) bool {
+	for _, allowed := range values {
+		if reflect.DeepEqual(normaliseJSON(allowed), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normaliseJSON converts a value to the form that it would be parsed from JSON, eg: ints to float64.
+func normaliseJSON(value any) any {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalised any
+	if err := json.Unmarshal(content, &normalised); err != nil {
+		return value
+	}
+	return normalised
+}
+
+func uniqueJSONValues(values []any) bool {
+	for i := range values {
+		for j := i + 1; j < len(values); j++ {
+			if reflect.DeepEqual(values[i], values[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+type schemaPreparer struct {
+	validator *schemaValidator
+	coerce    bool
+}
+
+// prepare fills in defaults and coerces the values within `value`, and returns the coerced value itself.
+func (p *schemaPreparer) prepare(schema JSONSchema, value any, refDepth int) any {
+	if schema.Ref != "" && refDepth < maxRefDepth {
+		if resolved, err := p.validator.resolveRef(schema.Ref); err == nil {
+			value = p.prepare(resolved, value, refDepth+1)
+		}
+	}
+	if p.coerce {
+		value = coerceValue(schema.TypeNames(), value)
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		for name, property := range schema.Properties {
+			if existing, ok := value[name]; ok {
+				value[name] = p.prepare(property, existing, 0)
+			} else if property.HasDefault || property.Default != nil {
+				value[name] = normaliseJSON(property.Default)
+			}
+		}
+		if schema.AdditionalProperties != nil {
+			for name, existing := range value {
+				if _, ok := schema.Properties[name]; !ok {
+					value[name] = p.prepare(*schema.AdditionalProperties, existing, 0)
+				}
+			}
+		}
+	case []any:
+		for i, item := range value {
+			if i < len(schema.PrefixItems) {
+				value[i] = p.prepare(schema.PrefixItems[i], item, 0)
+			} else if schema.Items != nil {
+				value[i] = p.prepare(*schema.Items, item, 0)
+			}
+		}
+	}
+
+	for _, subschema := range schema.AllOf {
+		value = p.prepare(subschema, value, refDepth)
+	}
+	return value
+}
+
+// coerceValue converts a value which does not match any of the types to the first type that it can be converted to.
+func coerceValue(types []string, value any) any {
+	if len(types) == 0 || checkTypes(types, value) == "" {
+		return value
+	}
+
+	for _, schemaType := range types {
+		switch schemaType {
+		case "number", "integer":
+			if text, ok := value.(string); ok {
+				if number, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil && checkType(schemaType, number) == "" {
+					return number
+				}
+			}
+		case "boolean":
+			if text, ok := value.(string); ok {
+				if boolean, err := strconv.ParseBool(strings.TrimSpace(text)); err == nil {
+					return boolean
+				}
+			}
+		case "string":
+			switch value := value.(type) {
+			case float64:
+				return strconv.FormatFloat(value, 'f', -1, 64)
+			case bool:
+				return strconv.FormatBool(value)
+			}
+		case "array":
+			if value != nil {
+				if _, isObject := value.(map[string]any); !isObject {
+					// a single value where a list is expected
+					return []any{value}
+				}
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,32 @@
+package mcp
+
+// loggingLevelSeverities orders the levels from least to most severe, as in RFC 5424 (syslog).
+var loggingLevelSeverities = map[LoggingLevel]int{
+	LoggingLevelDebug:     0,
+	LoggingLevelInfo:      1,
+	LoggingLevelNotice:    2,
+	LoggingLevelWarning:   3,
+	LoggingLevelError:     4,
+	LoggingLevelCritical:  5,
+	LoggingLevelAlert:     6,
+	LoggingLevelEmergency: 7,
+}
+
+// Severity returns 0 for LoggingLevelDebug up to 7 for LoggingLevelEmergency, or -1 if the level is not valid.
+func (l LoggingLevel) Severity() int {
+	if severity, ok := loggingLevelSeverities[l]; ok {
+		return severity
+	}
+	return -1
+}
+
+// IsValid returns true if the level is one of the levels defined by the protocol.
+func (l LoggingLevel) IsValid() bool {
+	return l.Severity() >= 0
+}
+
+// IsAtLeast returns true if the level is as severe as, or more severe than, `min`.
+// eg: a client which set the level to "warning" should receive "warning" and "error" messages, but not "info".
+func (l LoggingLevel) IsAtLeast(min LoggingLevel) bool {
+	return l.Severity() >= min.Severity()
+}
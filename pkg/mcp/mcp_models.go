@@ -52,6 +52,46 @@ func (j *AnnotatedAnnotations) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// Audio provided to or from an LLM.
+type AudioContent struct {
+	// Annotations corresponds to the JSON schema field "annotations".
+	Annotations *AnnotatedAnnotations `json:"annotations,omitempty" yaml:"annotations,omitempty" mapstructure:"annotations,omitempty"`
+
+	// The base64-encoded audio data.
+	Data string `json:"data" yaml:"data" mapstructure:"data"`
+
+	// The MIME type of the audio. Different providers may support different audio
+	// types.
+	MimeType string `json:"mimeType" yaml:"mimeType" mapstructure:"mimeType"`
+
+	// Type corresponds to the JSON schema field "type".
+	Type string `json:"type" yaml:"type" mapstructure:"type"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *AudioContent) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["data"]; raw != nil && !ok {
+		return fmt.Errorf("field data in AudioContent: required")
+	}
+	if _, ok := raw["mimeType"]; raw != nil && !ok {
+		return fmt.Errorf("field mimeType in AudioContent: required")
+	}
+	if _, ok := raw["type"]; raw != nil && !ok {
+		return fmt.Errorf("field type in AudioContent: required")
+	}
+	type Plain AudioContent
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = AudioContent(plain)
+	return nil
+}
+
 type BlobResourceContents struct {
 	ResourceContents
 
@@ -75,12 +115,17 @@ func (j *BlobResourceContents) UnmarshalJSON(b []byte) error {
 	if _, ok := raw["uri"]; raw != nil && !ok {
 		return fmt.Errorf("field uri in BlobResourceContents: required")
 	}
-	type Plain BlobResourceContents
-	var plain Plain
+	// Plain would inherit ResourceContents.UnmarshalJSON from the embedded struct and drop blob
+	var plain struct {
+		Blob string `json:"blob"`
+	}
 	if err := json.Unmarshal(b, &plain); err != nil {
 		return err
 	}
-	*j = BlobResourceContents(plain)
+	if err := j.ResourceContents.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	j.Blob = plain.Blob
 	return nil
 }
 
@@ -158,12 +203,16 @@ type CallToolResult struct {
 	Meta CallToolResultMeta `json:"_meta,omitempty" yaml:"_meta,omitempty" mapstructure:"_meta,omitempty"`
 
 	// Content corresponds to the JSON schema field "content".
-	Content []interface{} `json:"content" yaml:"content" mapstructure:"content"`
+	Content []Content `json:"content" yaml:"content" mapstructure:"content"`
 
 	// Whether the tool call ended in an error.
 	//
 	// If not set, this is assumed to be false (the call was successful).
 	IsError *bool `json:"isError,omitempty" yaml:"isError,omitempty" mapstructure:"isError,omitempty"`
+
+	// An optional JSON object that represents the structured result of the tool call.
+	// If the tool defines an outputSchema, it must conform to that schema.
+	StructuredContent map[string]interface{} `json:"structuredContent,omitempty" yaml:"structuredContent,omitempty" mapstructure:"structuredContent,omitempty"`
 }
 
 // This result property is reserved by the protocol to allow clients and servers to
@@ -180,11 +229,19 @@ func (j *CallToolResult) UnmarshalJSON(b []byte) error {
 		return fmt.Errorf("field content in CallToolResult: required")
 	}
 	type Plain CallToolResult
-	var plain Plain
+	var plain struct {
+		Plain
+		Content []json.RawMessage `json:"content"`
+	}
 	if err := json.Unmarshal(b, &plain); err != nil {
 		return err
 	}
-	*j = CallToolResult(plain)
+	content, err := unmarshalContentList(plain.Content)
+	if err != nil {
+		return fmt.Errorf("field content in CallToolResult: %w", err)
+	}
+	plain.Plain.Content = content
+	*j = CallToolResult(plain.Plain)
 	return nil
 }
 
@@ -262,6 +319,9 @@ func (j *CancelledNotification) UnmarshalJSON(b []byte) error {
 // schema, but this is not a closed set: any client can define its own, additional
 // capabilities.
 type ClientCapabilities struct {
+	// Present if the client supports elicitation from the user.
+	Elicitation ClientCapabilitiesElicitation `json:"elicitation,omitempty" yaml:"elicitation,omitempty" mapstructure:"elicitation,omitempty"`
+
 	// Experimental, non-standard capabilities that the client supports.
 	Experimental ClientCapabilitiesExperimental `json:"experimental,omitempty" yaml:"experimental,omitempty" mapstructure:"experimental,omitempty"`
 
@@ -272,6 +332,9 @@ type ClientCapabilities struct {
 	Sampling ClientCapabilitiesSampling `json:"sampling,omitempty" yaml:"sampling,omitempty" mapstructure:"sampling,omitempty"`
 }
 
+// Present if the client supports elicitation from the user.
+type ClientCapabilitiesElicitation map[string]interface{}
+
 // Experimental, non-standard capabilities that the client supports.
 type ClientCapabilitiesExperimental map[string]map[string]interface{}
 
@@ -573,7 +636,7 @@ type CreateMessageResult struct {
 	Meta CreateMessageResultMeta `json:"_meta,omitempty" yaml:"_meta,omitempty" mapstructure:"_meta,omitempty"`
 
 	// Content corresponds to the JSON schema field "content".
-	Content interface{} `json:"content" yaml:"content" mapstructure:"content"`
+	Content Content `json:"content" yaml:"content" mapstructure:"content"`
 
 	// The name of the model that generated the message.
 	Model string `json:"model" yaml:"model" mapstructure:"model"`
@@ -605,17 +668,135 @@ func (j *CreateMessageResult) UnmarshalJSON(b []byte) error {
 		return fmt.Errorf("field role in CreateMessageResult: required")
 	}
 	type Plain CreateMessageResult
-	var plain Plain
+	var plain struct {
+		Plain
+		Content json.RawMessage `json:"content"`
+	}
 	if err := json.Unmarshal(b, &plain); err != nil {
 		return err
 	}
-	*j = CreateMessageResult(plain)
+	content, err := UnmarshalContent(plain.Content)
+	if err != nil {
+		return fmt.Errorf("field content in CreateMessageResult: %w", err)
+	}
+	plain.Plain.Content = content
+	*j = CreateMessageResult(plain.Plain)
 	return nil
 }
 
 // An opaque token used to represent a cursor for pagination.
 type Cursor string
 
+// A request from the server to elicit additional information from the user via the client.
+type ElicitRequest struct {
+	// Method corresponds to the JSON schema field "method".
+	Method string `json:"method" yaml:"method" mapstructure:"method"`
+
+	// Params corresponds to the JSON schema field "params".
+	Params ElicitRequestParams `json:"params" yaml:"params" mapstructure:"params"`
+}
+
+type ElicitRequestParams struct {
+	// The message to present to the user.
+	Message string `json:"message" yaml:"message" mapstructure:"message"`
+
+	// A restricted subset of JSON Schema. Only top-level properties are allowed,
+	// without nesting, see ValidateElicitationSchema().
+	RequestedSchema JSONSchema `json:"requestedSchema" yaml:"requestedSchema" mapstructure:"requestedSchema"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ElicitRequestParams) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["message"]; raw != nil && !ok {
+		return fmt.Errorf("field message in ElicitRequestParams: required")
+	}
+	if _, ok := raw["requestedSchema"]; raw != nil && !ok {
+		return fmt.Errorf("field requestedSchema in ElicitRequestParams: required")
+	}
+	type Plain ElicitRequestParams
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = ElicitRequestParams(plain)
+	return nil
+}
+
+// The client's response to an elicitation request.
+type ElicitResult struct {
+	// This result property is reserved by the protocol to allow clients and servers
+	// to attach additional metadata to their responses.
+	Meta ElicitResultMeta `json:"_meta,omitempty" yaml:"_meta,omitempty" mapstructure:"_meta,omitempty"`
+
+	// The user action in response to the elicitation.
+	// - "accept": User submitted the form/confirmed the action
+	// - "decline": User explicitly declined the action
+	// - "cancel": User dismissed without making an explicit choice
+	Action ElicitAction `json:"action" yaml:"action" mapstructure:"action"`
+
+	// The submitted form data, only present when action is "accept".
+	// Contains values matching the requested schema.
+	Content map[string]interface{} `json:"content,omitempty" yaml:"content,omitempty" mapstructure:"content,omitempty"`
+}
+
+// This result property is reserved by the protocol to allow clients and servers
+// to attach additional metadata to their responses.
+type ElicitResultMeta map[string]interface{}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ElicitResult) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["action"]; raw != nil && !ok {
+		return fmt.Errorf("field action in ElicitResult: required")
+	}
+	type Plain ElicitResult
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = ElicitResult(plain)
+	return nil
+}
+
+type ElicitAction string
+
+const ElicitActionAccept ElicitAction = "accept"
+const ElicitActionCancel ElicitAction = "cancel"
+const ElicitActionDecline ElicitAction = "decline"
+
+var enumValues_ElicitAction = []interface{}{
+	"accept",
+	"decline",
+	"cancel",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ElicitAction) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	var ok bool
+	for _, expected := range enumValues_ElicitAction {
+		if reflect.DeepEqual(v, expected) {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("invalid value (expected one of %#v): %#v", enumValues_ElicitAction, v)
+	}
+	*j = ElicitAction(v)
+	return nil
+}
+
 // The contents of a resource, embedded into a prompt or tool call result.
 //
 // It is up to the client how best to render embedded resources for the benefit
@@ -624,8 +805,8 @@ type EmbeddedResource struct {
 	// Annotations corresponds to the JSON schema field "annotations".
 	Annotations *EmbeddedResourceAnnotations `json:"annotations,omitempty" yaml:"annotations,omitempty" mapstructure:"annotations,omitempty"`
 
-	// Resource corresponds to the JSON schema field "resource".
-	Resource interface{} `json:"resource" yaml:"resource" mapstructure:"resource"`
+	// Resource is a *TextResourceContents or *BlobResourceContents.
+	Resource HasResourceContents `json:"resource" yaml:"resource" mapstructure:"resource"`
 
 	// Type corresponds to the JSON schema field "type".
 	Type string `json:"type" yaml:"type" mapstructure:"type"`
@@ -676,11 +857,19 @@ func (j *EmbeddedResource) UnmarshalJSON(b []byte) error {
 		return fmt.Errorf("field type in EmbeddedResource: required")
 	}
 	type Plain EmbeddedResource
-	var plain Plain
+	var plain struct {
+		Plain
+		Resource json.RawMessage `json:"resource"`
+	}
 	if err := json.Unmarshal(b, &plain); err != nil {
 		return err
 	}
-	*j = EmbeddedResource(plain)
+	resource, err := UnmarshalResourceContents(plain.Resource)
+	if err != nil {
+		return fmt.Errorf("field resource in EmbeddedResource: %w", err)
+	}
+	plain.Plain.Resource = resource
+	*j = EmbeddedResource(plain.Plain)
 	return nil
 }
 
@@ -1758,6 +1947,9 @@ type ProgressNotificationParams struct {
 
 	// Total number of items to process (or total progress required), if known.
 	Total *float64 `json:"total,omitempty" yaml:"total,omitempty" mapstructure:"total,omitempty"`
+
+	// An optional message describing the current progress.
+	Message *string `json:"message,omitempty" yaml:"message,omitempty" mapstructure:"message,omitempty"`
 }
 
 // UnmarshalJSON implements json.Unmarshaler.
@@ -1895,7 +2087,7 @@ func (j *PromptListChangedNotification) UnmarshalJSON(b []byte) error {
 // resources from the MCP server.
 type PromptMessage struct {
 	// Content corresponds to the JSON schema field "content".
-	Content interface{} `json:"content" yaml:"content" mapstructure:"content"`
+	Content Content `json:"content" yaml:"content" mapstructure:"content"`
 
 	// Role corresponds to the JSON schema field "role".
 	Role Role `json:"role" yaml:"role" mapstructure:"role"`
@@ -1914,11 +2106,19 @@ func (j *PromptMessage) UnmarshalJSON(b []byte) error {
 		return fmt.Errorf("field role in PromptMessage: required")
 	}
 	type Plain PromptMessage
-	var plain Plain
+	var plain struct {
+		Plain
+		Content json.RawMessage `json:"content"`
+	}
 	if err := json.Unmarshal(b, &plain); err != nil {
 		return err
 	}
-	*j = PromptMessage(plain)
+	content, err := UnmarshalContent(plain.Content)
+	if err != nil {
+		return fmt.Errorf("field content in PromptMessage: %w", err)
+	}
+	plain.Plain.Content = content
+	*j = PromptMessage(plain.Plain)
 	return nil
 }
 
@@ -2056,11 +2256,24 @@ func (j *ReadResourceResult) UnmarshalJSON(b []byte) error {
 		return fmt.Errorf("field contents in ReadResourceResult: required")
 	}
 	type Plain ReadResourceResult
-	var plain Plain
+	var plain struct {
+		Plain
+		Contents []json.RawMessage `json:"contents"`
+	}
 	if err := json.Unmarshal(b, &plain); err != nil {
 		return err
 	}
-	*j = ReadResourceResult(plain)
+	if plain.Contents != nil {
+		plain.Plain.Contents = make([]HasResourceContents, len(plain.Contents))
+		for i, item := range plain.Contents {
+			contents, err := UnmarshalResourceContents(item)
+			if err != nil {
+				return fmt.Errorf("field contents[%d] in ReadResourceResult: %w", i, err)
+			}
+			plain.Plain.Contents[i] = contents
+		}
+	}
+	*j = ReadResourceResult(plain.Plain)
 	return nil
 }
 
@@ -2160,6 +2373,58 @@ func (j *ResourceAnnotations) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// A resource that the server is capable of reading, included in a prompt or tool
+// call result.
+//
+// Note: resource links returned by tools are not guaranteed to appear in the
+// results of resources/list requests.
+type ResourceLinkContent struct {
+	// Annotations corresponds to the JSON schema field "annotations".
+	Annotations *AnnotatedAnnotations `json:"annotations,omitempty" yaml:"annotations,omitempty" mapstructure:"annotations,omitempty"`
+
+	// A description of what this resource represents.
+	Description string `json:"description,omitempty" yaml:"description,omitempty" mapstructure:"description,omitempty"`
+
+	// The MIME type of this resource, if known.
+	MimeType string `json:"mimeType,omitempty" yaml:"mimeType,omitempty" mapstructure:"mimeType,omitempty"`
+
+	// A human-readable name for this resource.
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// The size of the raw resource content, in bytes, if known.
+	Size *int `json:"size,omitempty" yaml:"size,omitempty" mapstructure:"size,omitempty"`
+
+	// Type corresponds to the JSON schema field "type".
+	Type string `json:"type" yaml:"type" mapstructure:"type"`
+
+	// The URI of this resource.
+	Uri string `json:"uri" yaml:"uri" mapstructure:"uri"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *ResourceLinkContent) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["name"]; raw != nil && !ok {
+		return fmt.Errorf("field name in ResourceLinkContent: required")
+	}
+	if _, ok := raw["type"]; raw != nil && !ok {
+		return fmt.Errorf("field type in ResourceLinkContent: required")
+	}
+	if _, ok := raw["uri"]; raw != nil && !ok {
+		return fmt.Errorf("field uri in ResourceLinkContent: required")
+	}
+	type Plain ResourceLinkContent
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = ResourceLinkContent(plain)
+	return nil
+}
+
 // The contents of a specific resource or sub-resource.
 type ResourceContents struct {
 	// The MIME type of this resource, if known.
@@ -2542,7 +2807,7 @@ func (j *RootsListChangedNotification) UnmarshalJSON(b []byte) error {
 // Describes a message issued to or received from an LLM API.
 type SamplingMessage struct {
 	// Content corresponds to the JSON schema field "content".
-	Content interface{} `json:"content" yaml:"content" mapstructure:"content"`
+	Content Content `json:"content" yaml:"content" mapstructure:"content"`
 
 	// Role corresponds to the JSON schema field "role".
 	Role Role `json:"role" yaml:"role" mapstructure:"role"`
@@ -2561,11 +2826,19 @@ func (j *SamplingMessage) UnmarshalJSON(b []byte) error {
 		return fmt.Errorf("field role in SamplingMessage: required")
 	}
 	type Plain SamplingMessage
-	var plain Plain
+	var plain struct {
+		Plain
+		Content json.RawMessage `json:"content"`
+	}
 	if err := json.Unmarshal(b, &plain); err != nil {
 		return err
 	}
-	*j = SamplingMessage(plain)
+	content, err := UnmarshalContent(plain.Content)
+	if err != nil {
+		return fmt.Errorf("field content in SamplingMessage: %w", err)
+	}
+	plain.Plain.Content = content
+	*j = SamplingMessage(plain.Plain)
 	return nil
 }
 
@@ -2573,6 +2846,9 @@ func (j *SamplingMessage) UnmarshalJSON(b []byte) error {
 // this schema, but this is not a closed set: any server can define its own,
 // additional capabilities.
 type ServerCapabilities struct {
+	// Present if the server supports argument autocompletion suggestions.
+	Completions ServerCapabilitiesCompletions `json:"completions,omitempty" yaml:"completions,omitempty" mapstructure:"completions,omitempty"`
+
 	// Experimental, non-standard capabilities that the server supports.
 	Experimental ServerCapabilitiesExperimental `json:"experimental,omitempty" yaml:"experimental,omitempty" mapstructure:"experimental,omitempty"`
 
@@ -2589,6 +2865,9 @@ type ServerCapabilities struct {
 	Tools *ServerCapabilitiesTools `json:"tools,omitempty" yaml:"tools,omitempty" mapstructure:"tools,omitempty"`
 }
 
+// Present if the server supports argument autocompletion suggestions.
+type ServerCapabilitiesCompletions map[string]interface{}
+
 // Experimental, non-standard capabilities that the server supports.
 type ServerCapabilitiesExperimental map[string]map[string]interface{}
 
@@ -2833,17 +3112,25 @@ func (j *TextResourceContents) UnmarshalJSON(b []byte) error {
 	if _, ok := raw["uri"]; raw != nil && !ok {
 		return fmt.Errorf("field uri in TextResourceContents: required")
 	}
-	type Plain TextResourceContents
-	var plain Plain
+	// Plain would inherit ResourceContents.UnmarshalJSON from the embedded struct and drop text
+	var plain struct {
+		Text string `json:"text"`
+	}
 	if err := json.Unmarshal(b, &plain); err != nil {
 		return err
 	}
-	*j = TextResourceContents(plain)
+	if err := j.ResourceContents.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	j.Text = plain.Text
 	return nil
 }
 
 // Definition for a tool the client can call.
 type Tool struct {
+	// Optional additional tool information.
+	Annotations *ToolAnnotations `json:"annotations,omitempty" yaml:"annotations,omitempty" mapstructure:"annotations,omitempty"`
+
 	// A human-readable description of the tool.
 	Description string `json:"description,omitempty" yaml:"description,omitempty" mapstructure:"description,omitempty"`
 
@@ -2852,46 +3139,59 @@ type Tool struct {
 
 	// The name of the tool.
 	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// An optional JSON Schema object defining the structure of the tool's output
+	// returned in the structuredContent field of a CallToolResult.
+	OutputSchema *ToolOutputSchema `json:"outputSchema,omitempty" yaml:"outputSchema,omitempty" mapstructure:"outputSchema,omitempty"`
 }
 
-// A JSON Schema object defining the expected parameters for the tool.
-type ToolInputSchema struct {
-	// Properties corresponds to the JSON schema field "properties".
-	Properties ToolInputSchemaProperties `json:"properties,omitempty" yaml:"properties,omitempty" mapstructure:"properties,omitempty"`
+// Additional properties describing a Tool to clients.
+//
+// NOTE: all properties in ToolAnnotations are **hints**.
+// They are not guaranteed to provide a faithful description of
+// tool behavior (including descriptive properties like `title`).
+//
+// Clients should never make tool use decisions based on ToolAnnotations
+// received from untrusted servers.
+type ToolAnnotations struct {
+	// If true, the tool may perform destructive updates to its environment.
+	// If false, the tool performs only additive updates.
+	//
+	// (This property is meaningful only when `readOnlyHint == false`)
+	//
+	// Default: true
+	DestructiveHint *bool `json:"destructiveHint,omitempty" yaml:"destructiveHint,omitempty" mapstructure:"destructiveHint,omitempty"`
 
-	// Required corresponds to the JSON schema field "required".
-	Required []string `json:"required,omitempty" yaml:"required,omitempty" mapstructure:"required,omitempty"`
+	// If true, calling the tool repeatedly with the same arguments
+	// will have no additional effect on its environment.
+	//
+	// (This property is meaningful only when `readOnlyHint == false`)
+	//
+	// Default: false
+	IdempotentHint *bool `json:"idempotentHint,omitempty" yaml:"idempotentHint,omitempty" mapstructure:"idempotentHint,omitempty"`
 
-	// Type corresponds to the JSON schema field "type".
-	Type string `json:"type" yaml:"type" mapstructure:"type"`
-}
+	// If true, this tool may interact with an "open world" of external
+	// entities. If false, the tool's domain of interaction is closed.
+	// For example, the world of a web search tool is open, whereas that
+	// of a memory tool is not.
+	//
+	// Default: true
+	OpenWorldHint *bool `json:"openWorldHint,omitempty" yaml:"openWorldHint,omitempty" mapstructure:"openWorldHint,omitempty"`
 
-// Property definition for a tool input schema
-type ToolInputSchemaProperty struct {
-	Type        string  `json:"type" yaml:"type" mapstructure:"type"`
-	Description string `json:"description,omitempty" yaml:"description,omitempty" mapstructure:"description,omitempty"`
-	Required    *bool   `json:"required,omitempty" yaml:"required,omitempty" mapstructure:"required,omitempty"`
+	// If true, the tool does not modify its environment.
+	//
+	// Default: false
+	ReadOnlyHint *bool `json:"readOnlyHint,omitempty" yaml:"readOnlyHint,omitempty" mapstructure:"readOnlyHint,omitempty"`
+
+	// A human-readable title for the tool.
+	Title string `json:"title,omitempty" yaml:"title,omitempty" mapstructure:"title,omitempty"`
 }
 
-type ToolInputSchemaProperties map[string]ToolInputSchemaProperty
+// A JSON Schema object defining the expected parameters for the tool.
+type ToolInputSchema JSONSchema
 
-// UnmarshalJSON implements json.Unmarshaler.
-func (j *ToolInputSchema) UnmarshalJSON(b []byte) error {
-	var raw map[string]interface{}
-	if err := json.Unmarshal(b, &raw); err != nil {
-		return err
-	}
-	if _, ok := raw["type"]; raw != nil && !ok {
-		return fmt.Errorf("field type in ToolInputSchema: required")
-	}
-	type Plain ToolInputSchema
-	var plain Plain
-	if err := json.Unmarshal(b, &plain); err != nil {
-		return err
-	}
-	*j = ToolInputSchema(plain)
-	return nil
-}
+// A JSON Schema object defining the structure of the tool's output.
+type ToolOutputSchema = JSONSchema
 
 // An optional notification from the server to the client, informing it that the
 // list of tools it offers has changed. This may be issued by servers without any
@@ -0,0 +1,13 @@
+package server
+
+import "github.com/nalbion/go-mcp/pkg/mcp"
+
+// PrepareArguments returns a copy of the arguments with `default` values filled in and, if `coerce` is true,
+// values converted to the types in the schema, see mcp.JSONSchema.Prepare(). The result is then checked with ValidateArguments().
+func PrepareArguments(schema mcp.ToolInputSchema, arguments map[string]any, coerce bool) (map[string]any, []ValidationError) {
+	prepared, _ := mcp.JSONSchema(schema).Prepare(arguments, coerce).(map[string]any)
+	if prepared == nil {
+		prepared = map[string]any{}
+	}
+	return prepared, ValidateArguments(schema, prepared)
+}
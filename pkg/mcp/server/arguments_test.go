@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareArguments(t *testing.T) {
+	schema := mcp.NewObjectSchema().
+		WithProperty("city", mcp.NewStringSchema(), true).
+		WithProperty("days", mcp.NewIntegerSchema().WithMinimum(1).WithDefault(3), false).
+		WithProperty("metric", mcp.NewBooleanSchema().WithDefault(true), false).
+		WithProperty("tags", mcp.NewArraySchema(mcp.NewStringSchema()), false).
+		WithProperty("filter", mcp.NewObjectSchema().
+			WithProperty("limit", mcp.NewNumberSchema().WithDefault(10), false), false).
+		ToolInputSchema()
+
+	t.Run("should fill in defaults without changing the arguments passed in", func(t *testing.T) {
+		// given
+		arguments := map[string]any{"city": "Sydney", "filter": map[string]any{}}
+
+		// when
+		prepared, errors := PrepareArguments(schema, arguments, false)
+
+		// then
+		assert.Empty(t, errors)
+		assert.Equal(t, map[string]any{
+			"city":   "Sydney",
+			"days":   3.0,
+			"metric": true,
+			"filter": map[string]any{"limit": 10.0},
+		}, prepared)
+		assert.Equal(t, map[string]any{"city": "Sydney", "filter": map[string]any{}}, arguments)
+	})
+
+	t.Run("should coerce arguments to the types in the schema", func(t *testing.T) {
+		// when
+		prepared, errors := PrepareArguments(schema, map[string]any{
+			"city":   12,
+			"days":   "5",
+			"metric": "false",
+			"tags":   "weather",
+		}, true)
+
+		// then
+		assert.Empty(t, errors)
+		assert.Equal(t, "12", prepared["city"])
+		assert.Equal(t, 5.0, prepared["days"])
+		assert.Equal(t, false, prepared["metric"])
+		assert.Equal(t, []any{"weather"}, prepared["tags"])
+	})
+
+	t.Run("should not coerce values which do not convert cleanly", func(t *testing.T) {
+		// when
+		_, errors := PrepareArguments(schema, map[string]any{"city": "Sydney", "days": "2.5", "metric": "maybe"}, true)
+
+		// then
+		assert.Equal(t, []ValidationError{
+			{Path: "days", Message: "must be of type integer"},
+			{Path: "metric", Message: "must be of type boolean"},
+		}, errors)
+	})
+
+	t.Run("should report errors without coercion", func(t *testing.T) {
+		// when
+		_, errors := PrepareArguments(schema, map[string]any{"days": "5"}, false)
+
+		// then
+		assert.Equal(t, []ValidationError{
+			{Path: "city", Message: "is required"},
+			{Path: "days", Message: "must be of type integer"},
+		}, errors)
+	})
+}
+
+func TestHandleCallToolValidation(t *testing.T) {
+	ctx := context.Background()
+	schema := mcp.NewObjectSchema().
+		WithProperty("city", mcp.NewStringSchema(), true).
+		WithProperty("days", mcp.NewIntegerSchema().WithMinimum(1).WithDefault(3), false).
+		ToolInputSchema()
+
+	addForecastTool := func(t *testing.T, server *Server) chan mcp.CallToolRequestParams {
+		calls := make(chan mcp.CallToolRequestParams, 1)
+		require.NoError(t, server.AddTool("forecast", "", schema, func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+			calls <- params
+			return textResult("sunny"), nil
+		}))
+		return calls
+	}
+
+	t.Run("should pass valid arguments with defaults to the handler", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		calls := addForecastTool(t, server)
+
+		// when
+		transport.ReceiveRequest(1, shared.ToolsCallMethod, map[string]any{"name": "forecast", "arguments": map[string]any{"city": "Sydney"}})
+
+		// then
+		_, err := transport.WaitForResponse(1, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, mcp.CallToolRequestParamsArguments{"city": "Sydney", "days": 3.0}, (<-calls).Arguments)
+	})
+
+	t.Run("should reject invalid arguments with every failing path", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		calls := addForecastTool(t, server)
+
+		// when
+		transport.ReceiveRequest(1, shared.ToolsCallMethod, map[string]any{"name": "forecast", "arguments": map[string]any{"days": 0}})
+
+		// then
+		_, err := transport.WaitForResponse(1, time.Second)
+		var jsonrpcErr *jsonrpc.JSONRPCErrorError
+		require.ErrorAs(t, err, &jsonrpcErr)
+		assert.Equal(t, int(jsonrpc.InvalidParams), jsonrpcErr.Code)
+		assert.Equal(t, "Invalid arguments for tool forecast: city: is required; days: must be >= 1", jsonrpcErr.Message)
+		assert.Equal(t, map[string]any{"errors": []ValidationError{
+			{Path: "city", Message: "is required"},
+			{Path: "days", Message: "must be >= 1"},
+		}}, jsonrpcErr.Data)
+		assert.Empty(t, calls)
+	})
+
+	t.Run("should coerce arguments if enabled", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		server.options.CoerceToolArguments = true
+		calls := addForecastTool(t, server)
+
+		// when
+		transport.ReceiveRequest(1, shared.ToolsCallMethod, map[string]any{"name": "forecast", "arguments": map[string]any{"city": "Sydney", "days": "5"}})
+
+		// then
+		_, err := transport.WaitForResponse(1, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, 5.0, (<-calls).Arguments["days"])
+	})
+
+	t.Run("should not validate if disabled", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		server.options.ValidateToolArguments = false
+		calls := addForecastTool(t, server)
+
+		// when
+		transport.ReceiveRequest(1, shared.ToolsCallMethod, map[string]any{"name": "forecast", "arguments": map[string]any{"days": "5"}})
+
+		// then
+		_, err := transport.WaitForResponse(1, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, mcp.CallToolRequestParamsArguments{"days": "5"}, (<-calls).Arguments)
+	})
+}
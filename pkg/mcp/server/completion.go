@@ -0,0 +1,301 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// maxCompletionValues is the maximum number of values in a completion/complete response, as required by the protocol.
+const maxCompletionValues = 100
+
+// The types of reference in completion/complete requests
+const (
+	PromptReferenceType   = "ref/prompt"
+	ResourceReferenceType = "ref/resource"
+)
+
+// Completer suggests values for an argument of a prompt, or a variable of a resource template,
+// given the value the user has typed so far in `params.Argument.Value`.
+// It may return any number of values, the server sends the first 100 to the client with the total and hasMore.
+type Completer func(ctx context.Context, params mcp.CompleteRequestParams, extra *RequestHandlerExtra) ([]string, error)
+
+// CompletionSource provides the candidates for PrefixCompleter() and FuzzyCompleter(), eg: the names of the tables in a database.
+type CompletionSource func(ctx context.Context) ([]string, error)
+
+type completerKey struct {
+	refType string
+	// name is the name of a prompt or the URI template of a resource template
+	name     string
+	argument string
+}
+
+// AddPromptCompleter registers a completer for an argument of a prompt.
+func (s *Server) AddPromptCompleter(promptName string, argumentName string, completer Completer) error {
+	if s.capabilities.Prompts == nil {
+		return errors.New("Server does not support prompts capability.")
+	}
+
+	s.logger.Info("Registering completer for argument %s of prompt %s", argumentName, promptName)
+	s.completersMutex.Lock()
+	defer s.completersMutex.Unlock()
+	s.completers[completerKey{PromptReferenceType, promptName, argumentName}] = completer
+	return nil
+}
+
+// AddResourceTemplateCompleter registers a completer for a variable of a resource template,
+// eg: AddResourceTemplateCompleter("users://{id}/profile", "id", completer)
+func (s *Server) AddResourceTemplateCompleter(uriTemplate string, variableName string, completer Completer) error {
+	if s.capabilities.Resources == nil {
+		return errors.New("Server does not support resources capability.")
+	}
+
+	template, err := mcp.ParseUriTemplate(uriTemplate)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, name := range template.VariableNames() {
+		found = found || name == variableName
+	}
+	if !found {
+		return fmt.Errorf("URI template %s has no variable %s", uriTemplate, variableName)
+	}
+
+	s.logger.Info("Registering completer for variable %s of resource template %s", variableName, uriTemplate)
+	s.completersMutex.Lock()
+	defer s.completersMutex.Unlock()
+	s.completers[completerKey{ResourceReferenceType, uriTemplate, variableName}] = completer
+	return nil
+}
+
+func (s *Server) handleComplete(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+	s.logger.Info("Handling complete request from client: %v", request.Params)
+
+	var completeParams mcp.CompleteRequestParams
+	if err := shared.DecodeParams(request, &completeParams); err != nil {
+		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid complete request parameters", nil)
+	}
+	key, err := decodeCompletionRef(completeParams.Ref)
+	if err != nil {
+		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid complete request parameters", nil)
+	}
+	key.argument = completeParams.Argument.Name
+
+	switch key.refType {
+	case PromptReferenceType:
+		if _, ok := s.registeredPrompt(key.name); !ok {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Prompt not found", nil)
+		}
+	case ResourceReferenceType:
+		s.templatesMutex.RLock()
+		_, ok := s.resourceTemplates[key.name]
+		s.templatesMutex.RUnlock()
+		if !ok {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Resource template not found", nil)
+		}
+	}
+
+	s.completersMutex.RLock()
+	completer, ok := s.completers[key]
+	s.completersMutex.RUnlock()
+
+	// arguments without a completer have no suggestions
+	var values []string
+	if ok {
+		values, err = completer(ctx, completeParams, s.newRequestHandlerExtra(request, extra))
+		if err != nil {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InternalError, err.Error(), nil)
+		}
+	}
+
+	return jsonrpc.Result{
+		AdditionalProperties: mcp.CompleteResult{
+			Completion: newCompletion(values),
+		},
+	}, nil
+}
+
+// decodeCompletionRef reads the type and the prompt name or URI template of a mcp.PromptReference or mcp.ResourceReference,
+// which may have been decoded from JSON as a map.
+func decodeCompletionRef(ref interface{}) (completerKey, error) {
+	data, err := json.Marshal(ref)
+	if err != nil {
+		return completerKey{}, err
+	}
+	var decoded struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+		Uri  string `json:"uri"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return completerKey{}, err
+	}
+
+	switch decoded.Type {
+	case PromptReferenceType:
+		if decoded.Name == "" {
+			return completerKey{}, errors.New("missing prompt name")
+		}
+		return completerKey{refType: decoded.Type, name: decoded.Name}, nil
+	case ResourceReferenceType:
+		if decoded.Uri == "" {
+			return completerKey{}, errors.New("missing resource uri")
+		}
+		return completerKey{refType: decoded.Type, name: decoded.Uri}, nil
+	default:
+		return completerKey{}, fmt.Errorf("unknown reference type %q", decoded.Type)
+	}
+}
+
+// newCompletion limits the values to the first 100, and reports the total number of values.
+func newCompletion(values []string) mcp.CompleteResultCompletion {
+	total := len(values)
+	hasMore := total > maxCompletionValues
+	if hasMore {
+		values = values[:maxCompletionValues]
+	} else if values == nil {
+		values = []string{}
+	}
+	return mcp.CompleteResultCompletion{
+		Values:  values,
+		Total:   &total,
+		HasMore: &hasMore,
+	}
+}
+
+// StaticCompleter completes an argument from a fixed list of values, eg: StaticCompleter("celsius", "fahrenheit").
+// Values which start with the typed value, ignoring case, are returned in the order given.
+func StaticCompleter(values ...string) Completer {
+	return PrefixCompleter(func(ctx context.Context) ([]string, error) {
+		return values, nil
+	})
+}
+
+// PrefixCompleter completes an argument with the candidates from the source which start with the typed value, ignoring case.
+func PrefixCompleter(source CompletionSource) Completer {
+	return func(ctx context.Context, params mcp.CompleteRequestParams, extra *RequestHandlerExtra) ([]string, error) {
+		candidates, err := source(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := strings.ToLower(params.Argument.Value)
+		var values []string
+		for _, candidate := range candidates {
+			if strings.HasPrefix(strings.ToLower(candidate), prefix) {
+				values = append(values, candidate)
+			}
+		}
+		return values, nil
+	}
+}
+
+// FuzzyCompleter completes an argument with the candidates from the source which contain the characters of the typed value
+// in order, ignoring case, eg: "nyc" matches "New York City". The closest matches are first: those where the characters
+// start earlier and are closer together.
+func FuzzyCompleter(source CompletionSource) Completer {
+	return func(ctx context.Context, params mcp.CompleteRequestParams, extra *RequestHandlerExtra) ([]string, error) {
+		candidates, err := source(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		type match struct {
+			value string
+			score int
+		}
+		query := []rune(strings.ToLower(params.Argument.Value))
+		var matches []match
+		for _, candidate := range candidates {
+			if score, ok := fuzzyScore(query, []rune(strings.ToLower(candidate))); ok {
+				matches = append(matches, match{candidate, score})
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score < matches[j].score
+		})
+
+		values := make([]string, len(matches))
+		for i, m := range matches {
+			values[i] = m.value
+		}
+		return values, nil
+	}
+}
+
+// fuzzyScore returns the position of the first matched character plus the number of characters skipped between matches,
+// or false if the candidate does not contain the characters of the query in order. Lower scores are better matches.
+func fuzzyScore(query []rune, candidate []rune) (int, bool) {
+	if len(query) == 0 {
+		return 0, true
+	}
+
+	score, q, last := 0, 0, -1
+	for i, r := range candidate {
+		if r != query[q] {
+			continue
+		}
+		if last < 0 {
+			score += i
+		} else {
+			score += i - last - 1
+		}
+		last = i
+		q++
+		if q == len(query) {
+			return score, true
+		}
+	}
+	return 0, false
+}
+
+// PathCompleter completes an argument with the paths of the files and directories in the file system, eg:
+//
+//	server.AddPromptCompleter("review", "file", server.PathCompleter(os.DirFS(projectDir)))
+//
+// The typed value is a slash-separated path, relative to the root of the file system. The entries of its directory
+// which start with the last element of the path are returned, with "/" appended to directories. Hidden entries,
+// which start with ".", are only returned if the last element starts with ".". Paths outside the file system,
+// eg: "../secret", have no completions.
+func PathCompleter(fsys fs.FS) Completer {
+	return func(ctx context.Context, params mcp.CompleteRequestParams, extra *RequestHandlerExtra) ([]string, error) {
+		value := params.Argument.Value
+		dir, prefix := ".", value
+		if i := strings.LastIndex(value, "/"); i >= 0 {
+			dir, prefix = path.Clean(value[:i+1]), value[i+1:]
+		}
+		if !fs.ValidPath(dir) {
+			return nil, nil
+		}
+
+		entries, err := fs.ReadDir(fsys, dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var values []string
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+				continue
+			}
+			if entry.IsDir() {
+				name += "/"
+			}
+			values = append(values, value[:len(value)-len(prefix)]+name)
+		}
+		return values, nil
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletion(t *testing.T) {
+	ctx := context.Background()
+	getPrompt := func(ctx context.Context, params mcp.GetPromptRequestParams, extra *RequestHandlerExtra) mcp.GetPromptResult {
+		return mcp.GetPromptResult{}
+	}
+	readTemplate := func(ctx context.Context, params mcp.ReadResourceRequestParams, variables mcp.UriTemplateVariables, extra *RequestHandlerExtra) mcp.ReadResourceResult {
+		return mcp.ReadResourceResult{}
+	}
+
+	// newCompletingSession connects a server with a prompt and a resource template to a MockTransport
+	newCompletingSession := func(t *testing.T) (*Server, *jsonrpc.MockTransport) {
+		options := NewServerOptions()
+		options.Capabilities = mcp.ServerCapabilities{
+			Prompts:   &mcp.ServerCapabilitiesPrompts{},
+			Resources: &mcp.ServerCapabilitiesResources{},
+		}
+		server := NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+		require.NoError(t, server.AddPrompt(mcp.Prompt{Name: "weather", Arguments: []mcp.PromptArgument{{Name: "units"}}}, getPrompt))
+		require.NoError(t, server.AddResourceTemplate("users://{id}/profile", "User profile", "", "text/plain", readTemplate))
+
+		transport := &jsonrpc.MockTransport{}
+		require.NoError(t, server.Connect(ctx, transport))
+		return server, transport
+	}
+
+	complete := func(t *testing.T, transport *jsonrpc.MockTransport, ref map[string]any, argument string, value string) (mcp.CompleteResultCompletion, error) {
+		transport.ReceiveRequest(1, shared.CompletionCompleteMethod, map[string]any{
+			"ref":      ref,
+			"argument": map[string]any{"name": argument, "value": value},
+		})
+		response, err := transport.WaitForResponse(1, time.Second)
+		if err != nil {
+			return mcp.CompleteResultCompletion{}, err
+		}
+		return response.Result.AdditionalProperties.(mcp.CompleteResult).Completion, nil
+	}
+	promptRef := map[string]any{"type": "ref/prompt", "name": "weather"}
+	templateRef := map[string]any{"type": "ref/resource", "uri": "users://{id}/profile"}
+
+	t.Run("should complete a prompt argument", func(t *testing.T) {
+		// given
+		server, transport := newCompletingSession(t)
+		require.NoError(t, server.AddPromptCompleter("weather", "units", StaticCompleter("celsius", "fahrenheit", "Kelvin")))
+
+		// when
+		completion, err := complete(t, transport, promptRef, "units", "k")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Kelvin"}, completion.Values)
+		assert.Equal(t, 1, *completion.Total)
+		assert.False(t, *completion.HasMore)
+	})
+
+	t.Run("should complete a resource template variable", func(t *testing.T) {
+		// given
+		server, transport := newCompletingSession(t)
+		users := func(ctx context.Context) ([]string, error) { return []string{"alice", "bob", "alan"}, nil }
+		require.NoError(t, server.AddResourceTemplateCompleter("users://{id}/profile", "id", PrefixCompleter(users)))
+
+		// when
+		completion, err := complete(t, transport, templateRef, "id", "al")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice", "alan"}, completion.Values)
+	})
+
+	t.Run("should send the first 100 values with the total", func(t *testing.T) {
+		// given
+		server, transport := newCompletingSession(t)
+		values := make([]string, 150)
+		for i := range values {
+			values[i] = fmt.Sprintf("value-%03d", i)
+		}
+		require.NoError(t, server.AddPromptCompleter("weather", "units", StaticCompleter(values...)))
+
+		// when
+		completion, err := complete(t, transport, promptRef, "units", "")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, values[:100], completion.Values)
+		assert.Equal(t, 150, *completion.Total)
+		assert.True(t, *completion.HasMore)
+	})
+
+	t.Run("should return no values for an argument without a completer", func(t *testing.T) {
+		// given
+		_, transport := newCompletingSession(t)
+
+		// when
+		completion, err := complete(t, transport, promptRef, "units", "c")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{}, completion.Values)
+		assert.Equal(t, 0, *completion.Total)
+	})
+
+	t.Run("should reject unknown references", func(t *testing.T) {
+		// given
+		_, transport := newCompletingSession(t)
+
+		// when
+		_, promptErr := complete(t, transport, map[string]any{"type": "ref/prompt", "name": "unknown"}, "units", "")
+		_, templateErr := complete(t, transport, map[string]any{"type": "ref/resource", "uri": "files://{path}"}, "path", "")
+		_, typeErr := complete(t, transport, map[string]any{"type": "ref/tool", "name": "weather"}, "units", "")
+
+		// then
+		assert.ErrorContains(t, promptErr, "Prompt not found")
+		assert.ErrorContains(t, templateErr, "Resource template not found")
+		assert.ErrorContains(t, typeErr, "Invalid complete request parameters")
+	})
+
+	t.Run("should not register a completer for a variable which is not in the template", func(t *testing.T) {
+		// given
+		server, _ := newCompletingSession(t)
+
+		// when
+		err := server.AddResourceTemplateCompleter("users://{id}/profile", "name", StaticCompleter())
+
+		// then
+		assert.ErrorContains(t, err, "has no variable name")
+	})
+
+	t.Run("should rank fuzzy matches by how early and close together the characters are", func(t *testing.T) {
+		// given
+		cities := func(ctx context.Context) ([]string, error) {
+			return []string{"Canberra", "New York City", "Sydney", "Newcastle"}, nil
+		}
+		completer := FuzzyCompleter(cities)
+
+		// when
+		values, err := completer(ctx, mcp.CompleteRequestParams{Argument: mcp.CompleteRequestParamsArgument{Value: "nc"}}, nil)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Newcastle", "New York City"}, values)
+	})
+
+	t.Run("should complete paths within the file system", func(t *testing.T) {
+		// given
+		completer := PathCompleter(fstest.MapFS{
+			"docs/guide.md":   {},
+			"docs/glossary":   {},
+			"docs/api/ref.md": {},
+			"docs/.hidden":    {},
+			"go.mod":          {},
+		})
+		completePath := func(value string) []string {
+			values, err := completer(ctx, mcp.CompleteRequestParams{Argument: mcp.CompleteRequestParamsArgument{Value: value}}, nil)
+			require.NoError(t, err)
+			return values
+		}
+
+		// then
+		assert.Equal(t, []string{"docs/", "go.mod"}, completePath(""))
+		assert.Equal(t, []string{"docs/glossary", "docs/guide.md"}, completePath("docs/g"))
+		assert.Equal(t, []string{"docs/api/"}, completePath("docs/a"))
+		assert.Equal(t, []string{"docs/.hidden"}, completePath("docs/."))
+		assert.Empty(t, completePath("../"))
+		assert.Empty(t, completePath("missing/"))
+	})
+}
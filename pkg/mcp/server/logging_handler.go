@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/mcp"
+)
+
+// slog levels for the MCP logging levels which slog does not define, eg: logger.Log(ctx, server.LevelCritical, "disk full")
+const (
+	LevelNotice    = slog.LevelInfo + 2
+	LevelCritical  = slog.LevelError + 4
+	LevelAlert     = slog.LevelError + 8
+	LevelEmergency = slog.LevelError + 12
+)
+
+// LoggerKey is the attribute which names the logger of a message, rather than being added to its data, eg:
+//
+//	logger.With(server.LoggerKey, "database").Info("connected")
+const LoggerKey = "logger"
+
+// MessageKey is the key of the message of a log record in the data of notifications/message.
+const MessageKey = "message"
+
+// LoggingHandler is a slog.Handler which sends log records to the client as notifications/message.
+// The data of each notification is a JSON object with the message and attributes of the record, and groups as nested objects.
+// Records which are less severe than the level the client asked for with logging/setLevel are dropped.
+type LoggingHandler struct {
+	session *Session
+	logger  string
+	// data holds the attributes added by WithAttrs(), which are copied into the data of each record
+	data   map[string]any
+	groups []string
+}
+
+// NewLoggingHandler creates a slog.Handler which sends logs to the client of the session, eg: in a tool handler
+//
+//	logger := slog.New(server.NewLoggingHandler(extra.Session, "weather"))
+//	logger.Info("fetching forecast", "city", city)
+//
+// `loggerName` may be empty.
+func NewLoggingHandler(session *Session, loggerName string) *LoggingHandler {
+	return &LoggingHandler{session: session, logger: loggerName, data: map[string]any{}}
+}
+
+// Logger returns a slog.Logger which sends logs to the client, see NewLoggingHandler().
+func (s *Session) Logger(name string) *slog.Logger {
+	return slog.New(NewLoggingHandler(s, name))
+}
+
+func (h *LoggingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.session.IsLoggingEnabled(loggingLevelFromSlog(level))
+}
+
+func (h *LoggingHandler) Handle(ctx context.Context, record slog.Record) error {
+	logger := h.logger
+	data := cloneLogData(h.data)
+	attrs := map[string]any{}
+	record.Attrs(func(attr slog.Attr) bool {
+		if len(h.groups) == 0 && attr.Key == LoggerKey {
+			logger = attr.Value.Resolve().String()
+		} else {
+			addLogAttr(attrs, attr)
+		}
+		return true
+	})
+	mergeLogData(data, h.groups, attrs)
+	data[MessageKey] = record.Message
+
+	params := mcp.LoggingMessageNotificationParams{
+		Level: loggingLevelFromSlog(record.Level),
+		Data:  data,
+	}
+	if logger != "" {
+		params.Logger = &logger
+	}
+	return h.session.SendLoggingMessage(params)
+}
+
+func (h *LoggingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.data = cloneLogData(h.data)
+	added := map[string]any{}
+	for _, attr := range attrs {
+		if len(h.groups) == 0 && attr.Key == LoggerKey {
+			handler.logger = attr.Value.Resolve().String()
+		} else {
+			addLogAttr(added, attr)
+		}
+	}
+	mergeLogData(handler.data, h.groups, added)
+	return &handler
+}
+
+func (h *LoggingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handler := *h
+	handler.groups = append(append([]string(nil), h.groups...), name)
+	return &handler
+}
+
+// loggingLevelFromSlog maps the slog levels to the closest MCP level, eg: slog.LevelWarn to mcp.LoggingLevelWarning.
+func loggingLevelFromSlog(level slog.Level) mcp.LoggingLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return mcp.LoggingLevelDebug
+	case level < LevelNotice:
+		return mcp.LoggingLevelInfo
+	case level < slog.LevelWarn:
+		return mcp.LoggingLevelNotice
+	case level < slog.LevelError:
+		return mcp.LoggingLevelWarning
+	case level < LevelCritical:
+		return mcp.LoggingLevelError
+	case level < LevelAlert:
+		return mcp.LoggingLevelCritical
+	case level < LevelEmergency:
+		return mcp.LoggingLevelAlert
+	default:
+		return mcp.LoggingLevelEmergency
+	}
+}
+
+// addLogAttr adds an attribute to the data, following the rules of slog.Handler: empty attributes and empty groups
+// are ignored, and the attributes of a group with an empty key are inlined.
+func addLogAttr(data map[string]any, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() != slog.KindGroup {
+		data[attr.Key] = logValue(attr.Value)
+		return
+	}
+
+	target := data
+	if attr.Key != "" {
+		target = map[string]any{}
+	}
+	for _, groupAttr := range attr.Value.Group() {
+		addLogAttr(target, groupAttr)
+	}
+	if attr.Key != "" && len(target) > 0 {
+		data[attr.Key] = target
+	}
+}
+
+// logValue converts a value to one which marshals to JSON in a readable form.
+func logValue(value slog.Value) any {
+	switch value.Kind() {
+	case slog.KindTime:
+		return value.Time().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		return value.Duration().String()
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return err.Error()
+		}
+	}
+	return value.Any()
+}
+
+// mergeLogData adds the attributes to the data, nested within the groups. Groups are only created if there are attributes.
+func mergeLogData(data map[string]any, groups []string, attrs map[string]any) {
+	if len(attrs) == 0 {
+		return
+	}
+	for _, group := range groups {
+		nested, ok := data[group].(map[string]any)
+		if !ok {
+			nested = map[string]any{}
+			data[group] = nested
+		}
+		data = nested
+	}
+	for key, value := range attrs {
+		data[key] = value
+	}
+}
+
+// cloneLogData copies the nested maps of the data, so that a handler's data is not modified by the handlers derived from it.
+func cloneLogData(data map[string]any) map[string]any {
+	clone := make(map[string]any, len(data))
+	for key, value := range data {
+		if nested, ok := value.(map[string]any); ok {
+			value = cloneLogData(nested)
+		}
+		clone[key] = value
+	}
+	return clone
+}
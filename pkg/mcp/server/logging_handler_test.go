@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogging(t *testing.T) {
+	ctx := context.Background()
+
+	// newLoggingSession connects a server with the logging capability to a MockTransport
+	newLoggingSession := func(t *testing.T) (*Server, *jsonrpc.MockTransport) {
+		options := NewServerOptions()
+		options.Capabilities = mcp.ServerCapabilities{Logging: mcp.ServerCapabilitiesLogging{}}
+		server := NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+		transport := &jsonrpc.MockTransport{}
+		require.NoError(t, server.Connect(ctx, transport))
+		return server, transport
+	}
+
+	setLevel := func(t *testing.T, transport *jsonrpc.MockTransport, level string) error {
+		transport.ReceiveRequest(1, shared.LoggingSetLevelMethod, map[string]any{"level": level})
+		_, err := transport.WaitForResponse(1, time.Second)
+		return err
+	}
+
+	sentLogs := func(transport *jsonrpc.MockTransport) []mcp.LoggingMessageNotificationParams {
+		var logs []mcp.LoggingMessageNotificationParams
+		for _, notification := range transport.SentNotifications {
+			if notification.Method == string(shared.LoggingMessageNotificationMethod) {
+				logs = append(logs, notification.Params.AdditionalProperties.(mcp.LoggingMessageNotificationParams))
+			}
+		}
+		return logs
+	}
+
+	t.Run("should send all log messages until the client sets a level", func(t *testing.T) {
+		// given
+		server, transport := newLoggingSession(t)
+
+		// when
+		require.NoError(t, server.SendLoggingMessage(mcp.LoggingMessageNotificationParams{Level: mcp.LoggingLevelDebug, Data: "details"}))
+
+		// then
+		assert.Len(t, sentLogs(transport), 1)
+		assert.Equal(t, mcp.LoggingLevel(""), server.Session().LoggingLevel())
+	})
+
+	t.Run("should only send log messages at or above the level set by the client", func(t *testing.T) {
+		// given
+		server, transport := newLoggingSession(t)
+		require.NoError(t, setLevel(t, transport, "warning"))
+
+		// when
+		for _, level := range []mcp.LoggingLevel{mcp.LoggingLevelDebug, mcp.LoggingLevelInfo, mcp.LoggingLevelWarning, mcp.LoggingLevelCritical} {
+			require.NoError(t, server.SendLoggingMessage(mcp.LoggingMessageNotificationParams{Level: level, Data: string(level)}))
+		}
+
+		// then
+		logs := sentLogs(transport)
+		require.Len(t, logs, 2)
+		assert.Equal(t, mcp.LoggingLevelWarning, logs[0].Level)
+		assert.Equal(t, mcp.LoggingLevelCritical, logs[1].Level)
+	})
+
+	t.Run("should reject an invalid level", func(t *testing.T) {
+		// given
+		server, transport := newLoggingSession(t)
+
+		// when
+		err := setLevel(t, transport, "verbose")
+
+		// then
+		assert.ErrorContains(t, err, "Invalid set level request parameters")
+		assert.Equal(t, mcp.LoggingLevel(""), server.Session().LoggingLevel())
+	})
+
+	t.Run("should forward slog records with the logger name and data", func(t *testing.T) {
+		// given
+		server, transport := newLoggingSession(t)
+		logger := server.Session().Logger("weather").With("city", "Sydney").WithGroup("request")
+
+		// when
+		logger.Warn("slow response", "elapsed", 1500*time.Millisecond, slog.Group("http", "status", 200), "err", errors.New("timeout"))
+
+		// then
+		logs := sentLogs(transport)
+		require.Len(t, logs, 1)
+		assert.Equal(t, mcp.LoggingLevelWarning, logs[0].Level)
+		require.NotNil(t, logs[0].Logger)
+		assert.Equal(t, "weather", *logs[0].Logger)
+		assert.Equal(t, map[string]any{
+			"message": "slow response",
+			"city":    "Sydney",
+			"request": map[string]any{
+				"elapsed": "1.5s",
+				"http":    map[string]any{"status": int64(200)},
+				"err":     "timeout",
+			},
+		}, logs[0].Data)
+	})
+
+	t.Run("should name the logger with the logger attribute and omit empty groups", func(t *testing.T) {
+		// given
+		server, transport := newLoggingSession(t)
+		logger := slog.New(NewLoggingHandler(server.Session(), "")).With(LoggerKey, "database")
+
+		// when
+		logger.WithGroup("empty").Info("connected")
+
+		// then
+		logs := sentLogs(transport)
+		require.Len(t, logs, 1)
+		require.NotNil(t, logs[0].Logger)
+		assert.Equal(t, "database", *logs[0].Logger)
+		assert.Equal(t, map[string]any{"message": "connected"}, logs[0].Data)
+	})
+
+	t.Run("should not send slog records below the level set by the client", func(t *testing.T) {
+		// given
+		server, transport := newLoggingSession(t)
+		require.NoError(t, setLevel(t, transport, "error"))
+		logger := server.Session().Logger("")
+
+		// when
+		logger.Info("ignored")
+		logger.Log(ctx, LevelCritical, "disk full")
+
+		// then
+		logs := sentLogs(transport)
+		require.Len(t, logs, 1)
+		assert.Equal(t, mcp.LoggingLevelCritical, logs[0].Level)
+		assert.Nil(t, logs[0].Logger)
+		assert.False(t, logger.Enabled(ctx, slog.LevelWarn))
+	})
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// ProgressReporter sends notifications/progress for the request being handled.
+// If the client did not send a progressToken with the request, reports are silently discarded.
+type ProgressReporter struct {
+	session *Session
+	// token is sent back exactly as the client sent it, which may be a string or a number
+	token       any
+	minInterval time.Duration
+	lastSent    time.Time
+	now         func() time.Time
+	mu          sync.Mutex
+}
+
+// progressNotificationParams is mcp.ProgressNotificationParams with a token which may be a string, as allowed by the spec.
+type progressNotificationParams struct {
+	ProgressToken any      `json:"progressToken"`
+	Progress      float64  `json:"progress"`
+	Total         *float64 `json:"total,omitempty"`
+	Message       string   `json:"message,omitempty"`
+}
+
+func newProgressReporter(session *Session, meta map[string]any, minInterval time.Duration) *ProgressReporter {
+	return &ProgressReporter{
+		session:     session,
+		token:       meta["progressToken"],
+		minInterval: minInterval,
+		now:         time.Now,
+	}
+}
+
+// Enabled reports whether the client asked for progress notifications.
+func (r *ProgressReporter) Enabled() bool {
+	return r != nil && r.token != nil && r.session != nil
+}
+
+// SetMinInterval overrides ServerOptions.MinProgressInterval for this request.
+func (r *ProgressReporter) SetMinInterval(minInterval time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.minInterval = minInterval
+}
+
+// Report sends the progress so far, when the total is not known.
+// `progress` should increase with each report. `message` is optional.
+func (r *ProgressReporter) Report(progress float64, message string) error {
+	return r.report(progressNotificationParams{Progress: progress, Message: message}, false)
+}
+
+// ReportWithTotal sends the progress so far out of `total`.
+// The final report, where progress reaches the total, is always sent, even if rate-limited.
+func (r *ProgressReporter) ReportWithTotal(progress float64, total float64, message string) error {
+	return r.report(progressNotificationParams{Progress: progress, Total: &total, Message: message}, progress >= total)
+}
+
+func (r *ProgressReporter) report(params progressNotificationParams, final bool) error {
+	if !r.Enabled() {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := r.now()
+	if !final && r.minInterval > 0 && !r.lastSent.IsZero() && now.Sub(r.lastSent) < r.minInterval {
+		r.mu.Unlock()
+		return nil
+	}
+	r.lastSent = now
+	r.mu.Unlock()
+
+	params.ProgressToken = r.token
+	return r.session.SendNotification(shared.NotificationsProgressMethod, params)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressReporter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should send progress with the client's token", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		require.NoError(t, server.AddTool("index", "", mcp.ToolInputSchema{}, func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+			if err := extra.Progress.Report(1, ""); err != nil {
+				return mcp.CallToolResult{}, err
+			}
+			if err := extra.Progress.ReportWithTotal(2, 10, "indexing"); err != nil {
+				return mcp.CallToolResult{}, err
+			}
+			return textResult("done"), nil
+		}))
+
+		// when
+		transport.Receive(&jsonrpc.JSONRPCRequest{
+			Jsonrpc: "2.0",
+			Id:      1,
+			Method:  string(shared.ToolsCallMethod),
+			Params: &jsonrpc.JSONRPCRequestParams{
+				Meta:                 &jsonrpc.JSONRPCRequestParamsMeta{"progressToken": "index-1"},
+				AdditionalProperties: map[string]any{"name": "index"},
+			},
+		})
+
+		// then
+		_, err := transport.WaitForResponse(1, time.Second)
+		require.NoError(t, err)
+		require.Len(t, transport.SentNotifications, 2)
+		assert.Equal(t, progressNotificationParams{ProgressToken: "index-1", Progress: 1}, transport.SentNotifications[0].Params.AdditionalProperties)
+		total := 10.0
+		assert.Equal(t, progressNotificationParams{ProgressToken: "index-1", Progress: 2, Total: &total, Message: "indexing"}, transport.SentNotifications[1].Params.AdditionalProperties)
+	})
+
+	t.Run("should not send progress if the client did not ask for it", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		enabled := make(chan bool, 1)
+		require.NoError(t, server.AddTool("index", "", mcp.ToolInputSchema{}, func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+			enabled <- extra.Progress.Enabled()
+			return textResult("done"), extra.Progress.ReportWithTotal(1, 2, "")
+		}))
+
+		// when
+		transport.ReceiveRequest(1, shared.ToolsCallMethod, map[string]any{"name": "index"})
+
+		// then
+		_, err := transport.WaitForResponse(1, time.Second)
+		require.NoError(t, err)
+		assert.False(t, <-enabled)
+		assert.Empty(t, transport.SentNotifications)
+	})
+
+	t.Run("should rate-limit reports but always send the final report", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		reporter := newProgressReporter(server.Session(), map[string]any{"progressToken": 7}, time.Second)
+		now := time.Now()
+		reporter.now = func() time.Time { return now }
+
+		// when
+		require.NoError(t, reporter.ReportWithTotal(1, 4, ""))
+		now = now.Add(500 * time.Millisecond)
+		require.NoError(t, reporter.ReportWithTotal(2, 4, ""))
+		now = now.Add(600 * time.Millisecond)
+		require.NoError(t, reporter.ReportWithTotal(3, 4, ""))
+		require.NoError(t, reporter.ReportWithTotal(4, 4, ""))
+
+		// then
+		var sent []float64
+		for _, notification := range transport.SentNotifications {
+			sent = append(sent, notification.Params.AdditionalProperties.(progressNotificationParams).Progress)
+		}
+		assert.Equal(t, []float64{1, 3, 4}, sent)
+	})
+}
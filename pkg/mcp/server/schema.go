@@ -0,0 +1,319 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/mcp"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// InputSchemaFor generates the input schema of a tool from the fields of the struct T.
+//
+// Properties are named by the `json` tag, and are required unless the field is a pointer or tagged `omitempty`.
+// The `jsonschema` tag adds comma-separated constraints, eg:
+//
+//	Unit  string  `json:"unit" jsonschema:"description=Temperature unit,enum=celsius,enum=fahrenheit,default=celsius"`
+//	Count int     `json:"count,omitempty" jsonschema:"minimum=1,maximum=100"`
+//
+// Supported keys are description, enum (repeated for each value), default, format, minimum, maximum,
+// minLength, maxLength, pattern, and required/optional to override the default. Use `\,` for a comma in a value.
+func InputSchemaFor[T any]() (mcp.ToolInputSchema, error) {
+	schema, err := (&schemaGenerator{}).objectSchema(reflect.TypeFor[T](), "input")
+	return mcp.ToolInputSchema(schema), err
+}
+
+// OutputSchemaFor generates the output schema of a tool from the fields of the struct T, as InputSchemaFor() does.
+// As the schema describes the JSON that T is marshalled to, every field without `omitempty` is required,
+// and pointers, slices and maps may be null.
+func OutputSchemaFor[T any]() (*mcp.ToolOutputSchema, error) {
+	schema, err := (&schemaGenerator{output: true}).objectSchema(reflect.TypeFor[T](), "output")
+	if err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// schemaGenerator generates a JSON Schema from a Go type.
+type schemaGenerator struct {
+	// output is true if the schema describes values marshalled by encoding/json, rather than values to be unmarshalled
+	output bool
+	// seen contains the structs being generated, to detect recursive types
+	seen map[reflect.Type]bool
+}
+
+func (g *schemaGenerator) objectSchema(t reflect.Type, kind string) (mcp.JSONSchema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return mcp.JSONSchema{}, fmt.Errorf("tool %s must be a struct, not %s", kind, t)
+	}
+
+	g.seen = map[reflect.Type]bool{t: true}
+	properties, required, err := g.structProperties(t)
+	if err != nil {
+		return mcp.JSONSchema{}, err
+	}
+	return mcp.JSONSchema{
+		Type:       "object",
+		Properties: properties,
+		Required:   required,
+	}, nil
+}
+
+func (g *schemaGenerator) structProperties(t reflect.Type) (mcp.ToolInputSchemaProperties, []string, error) {
+	properties := mcp.ToolInputSchemaProperties{}
+	var required []string
+	var embeddedTypes []reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				embeddedTypes = append(embeddedTypes, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, err := g.schemaForType(field.Type)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		isRequired := !omitEmpty && field.Type.Kind() != reflect.Pointer
+		if g.output && !omitEmpty {
+			// encoding/json always writes the field, as null if it is nil
+			isRequired = true
+			switch field.Type.Kind() {
+			case reflect.Pointer, reflect.Slice, reflect.Map:
+				if len(property.TypeNames()) > 0 {
+					property.Nullable()
+				}
+			}
+		}
+		if isRequired, err = applySchemaTag(&property, field.Tag.Get("jsonschema"), isRequired); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		properties[name] = property
+		if isRequired {
+			required = append(required, name)
+		}
+	}
+
+	// the fields of an embedded struct are promoted, as they are by encoding/json, unless the outer struct has a field with the same name
+	for _, embedded := range embeddedTypes {
+		embeddedProperties, embeddedRequired, err := g.structProperties(embedded)
+		if err != nil {
+			return nil, nil, err
+		}
+		promoted := map[string]bool{}
+		for name, property := range embeddedProperties {
+			if _, taken := properties[name]; !taken {
+				properties[name] = property
+				promoted[name] = true
+			}
+		}
+		for _, name := range embeddedRequired {
+			if promoted[name] {
+				required = append(required, name)
+			}
+		}
+	}
+
+	return properties, required, nil
+}
+
+// jsonFieldName returns the name from the `json` tag, which is empty if not specified.
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" || option == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+func (g *schemaGenerator) schemaForType(t reflect.Type) (mcp.ToolInputSchemaProperty, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return mcp.ToolInputSchemaProperty{Type: "string", Format: "date-time"}, nil
+	case t == rawMessageType:
+		// any JSON value
+		return mcp.ToolInputSchemaProperty{}, nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		// encoding/json encodes []byte as a base64 string
+		return mcp.ToolInputSchemaProperty{Type: "string", ContentEncoding: "base64"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return mcp.ToolInputSchemaProperty{Type: "string"}, nil
+	case reflect.Bool:
+		return mcp.ToolInputSchemaProperty{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return mcp.ToolInputSchemaProperty{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return mcp.ToolInputSchemaProperty{Type: "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := g.schemaForType(t.Elem())
+		if err != nil {
+			return mcp.ToolInputSchemaProperty{}, err
+		}
+		return mcp.ToolInputSchemaProperty{Type: "array", Items: &items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return mcp.ToolInputSchemaProperty{}, fmt.Errorf("map keys must be strings, not %s", t.Key())
+		}
+		return mcp.ToolInputSchemaProperty{Type: "object"}, nil
+	case reflect.Struct:
+		if g.seen[t] {
+			return mcp.ToolInputSchemaProperty{}, fmt.Errorf("recursive type %s is not supported", t)
+		}
+		g.seen[t] = true
+		defer delete(g.seen, t)
+		properties, required, err := g.structProperties(t)
+		if err != nil {
+			return mcp.ToolInputSchemaProperty{}, err
+		}
+		return mcp.ToolInputSchemaProperty{Type: "object", Properties: properties, Required: required}, nil
+	case reflect.Interface:
+		// any value
+		return mcp.ToolInputSchemaProperty{}, nil
+	}
+	return mcp.ToolInputSchemaProperty{}, fmt.Errorf("unsupported type %s", t)
+}
+
+// applySchemaTag adds the constraints from a `jsonschema` tag, and returns whether the property is required.
+func applySchemaTag(property *mcp.ToolInputSchemaProperty, tag string, required bool) (bool, error) {
+	if tag == "" {
+		return required, nil
+	}
+
+	for _, option := range splitSchemaTag(tag) {
+		key, value, _ := strings.Cut(option, "=")
+		var err error
+		switch key {
+		case "required":
+			required = true
+		case "optional":
+			required = false
+		case "description":
+			property.Description = value
+		case "format":
+			property.Format = value
+		case "pattern":
+			if _, err = regexp.Compile(value); err == nil {
+				property.Pattern = value
+			}
+		case "enum":
+			var enumValue any
+			if enumValue, err = parseSchemaValue(property.Type, value); err == nil {
+				property.Enum = append(property.Enum, enumValue)
+			}
+		case "default":
+			property.Default, err = parseSchemaValue(property.Type, value)
+		case "minimum":
+			property.Minimum, err = parseFloat(value)
+		case "maximum":
+			property.Maximum, err = parseFloat(value)
+		case "minLength":
+			property.MinLength, err = parseInt(value)
+		case "maxLength":
+			property.MaxLength, err = parseInt(value)
+		default:
+			return required, fmt.Errorf("unknown jsonschema tag option %q", key)
+		}
+		if err != nil {
+			return required, fmt.Errorf("invalid jsonschema tag option %q: %w", option, err)
+		}
+	}
+	return required, nil
+}
+
+// splitSchemaTag splits a tag on commas which are not escaped with a backslash.
+func splitSchemaTag(tag string) []string {
+	var options []string
+	var option strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			option.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			options = append(options, option.String())
+			option.Reset()
+		default:
+			option.WriteByte(tag[i])
+		}
+	}
+	return append(options, option.String())
+}
+
+func parseSchemaValue(schemaType string, value string) (any, error) {
+	switch schemaType {
+	case "integer", "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "string", "":
+		return value, nil
+	}
+	var parsed any
+	err := json.Unmarshal([]byte(value), &parsed)
+	return parsed, err
+}
+
+func parseFloat(value string) (*float64, error) {
+	parsed, err := strconv.ParseFloat(value, 64)
+	return &parsed, err
+}
+
+func parseInt(value string) (*int, error) {
+	parsed, err := strconv.Atoi(value)
+	return &parsed, err
+}
+
+// ValidationError describes an argument which does not match the input schema.
+type ValidationError = mcp.ValidationError
+
+// ValidateArguments checks tool arguments, as parsed from JSON, against an input schema and returns every error found.
+func ValidateArguments(schema mcp.ToolInputSchema, arguments map[string]any) []ValidationError {
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+	return mcp.JSONSchema(schema).Validate(arguments)
+}
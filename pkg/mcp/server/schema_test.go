@@ -0,0 +1,219 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Paging struct {
+	Limit int `json:"limit,omitempty" jsonschema:"minimum=1,maximum=100,default=10"`
+}
+
+type searchInput struct {
+	Paging
+	Query   string            `json:"query" jsonschema:"description=Text to search for\\, eg: a function name,minLength=1"`
+	Scope   string            `json:"scope,omitempty" jsonschema:"enum=files,enum=symbols"`
+	Tags    []string          `json:"tags,omitempty"`
+	Since   *time.Time        `json:"since"`
+	Options map[string]string `json:"options,omitempty"`
+	Owner   struct {
+		Name string `json:"name"`
+	} `json:"owner" jsonschema:"optional"`
+	Ignored  string `json:"-"`
+	internal string
+}
+
+func TestInputSchemaFor(t *testing.T) {
+	t.Run("should generate a schema from struct fields and tags", func(t *testing.T) {
+		// when
+		schema, err := InputSchemaFor[searchInput]()
+
+		// then
+		require.NoError(t, err)
+		minimum, maximum, minLength := 1.0, 100.0, 1
+		assert.Equal(t, mcp.ToolInputSchema{
+			Type:     "object",
+			Required: []string{"query"},
+			Properties: mcp.ToolInputSchemaProperties{
+				"limit":   {Type: "integer", Minimum: &minimum, Maximum: &maximum, Default: 10.0},
+				"query":   {Type: "string", Description: "Text to search for, eg: a function name", MinLength: &minLength},
+				"scope":   {Type: "string", Enum: []any{"files", "symbols"}},
+				"tags":    {Type: "array", Items: &mcp.ToolInputSchemaProperty{Type: "string"}},
+				"since":   {Type: "string", Format: "date-time"},
+				"options": {Type: "object"},
+				"owner": {Type: "object", Required: []string{"name"}, Properties: mcp.ToolInputSchemaProperties{
+					"name": {Type: "string"},
+				}},
+			},
+		}, schema)
+	})
+
+	t.Run("should describe []byte as a base64 string", func(t *testing.T) {
+		// when
+		schema, err := InputSchemaFor[struct {
+			Data []byte          `json:"data"`
+			Raw  json.RawMessage `json:"raw,omitempty"`
+		}]()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, mcp.ToolInputSchemaProperty{Type: "string", ContentEncoding: "base64"}, schema.Properties["data"])
+		assert.Equal(t, mcp.ToolInputSchemaProperty{}, schema.Properties["raw"])
+		assert.Empty(t, mcp.JSONSchema(schema).Validate(map[string]any{"data": "aGVsbG8="}))
+	})
+
+	t.Run("should not let promoted fields replace a field of the outer struct", func(t *testing.T) {
+		// given
+		type base struct {
+			Name  int    `json:"name"`
+			Owner string `json:"owner"`
+		}
+		type outer struct {
+			Name string `json:"name,omitempty"`
+			base
+		}
+
+		// when
+		schema, err := InputSchemaFor[outer]()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "string", schema.Properties["name"].Type)
+		assert.Equal(t, "string", schema.Properties["owner"].Type)
+		assert.Equal(t, []string{"owner"}, schema.Required)
+	})
+
+	t.Run("should reject unsupported types and tags", func(t *testing.T) {
+		_, err := InputSchemaFor[string]()
+		assert.ErrorContains(t, err, "must be a struct")
+
+		_, err = InputSchemaFor[struct {
+			Callback func() `json:"callback"`
+		}]()
+		assert.ErrorContains(t, err, "callback: unsupported type func()")
+
+		_, err = InputSchemaFor[struct {
+			Count int `json:"count" jsonschema:"minimum=one"`
+		}]()
+		assert.ErrorContains(t, err, `invalid jsonschema tag option "minimum=one"`)
+
+		type node struct {
+			Children []node `json:"children"`
+		}
+		_, err = InputSchemaFor[node]()
+		assert.ErrorContains(t, err, "recursive type")
+	})
+}
+
+func TestOutputSchemaFor(t *testing.T) {
+	t.Run("should require every field which is always marshalled, and allow null for nil values", func(t *testing.T) {
+		// given
+		type forecast struct {
+			Summary string            `json:"summary"`
+			Days    []float64         `json:"days"`
+			Alert   *string           `json:"alert"`
+			Source  string            `json:"source,omitempty"`
+			Extra   map[string]string `json:"extra,omitempty"`
+		}
+
+		// when
+		schema, err := OutputSchemaFor[forecast]()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"summary", "days", "alert"}, schema.Required)
+		assert.Equal(t, []string{"array", "null"}, schema.Properties["days"].Types)
+		assert.Equal(t, []string{"string", "null"}, schema.Properties["alert"].Types)
+		assert.Equal(t, "object", schema.Properties["extra"].Type)
+		assert.Empty(t, schema.Validate(map[string]any{"summary": "sunny", "days": nil, "alert": nil}))
+	})
+}
+
+func TestValidateArguments(t *testing.T) {
+	schema, err := InputSchemaFor[searchInput]()
+	require.NoError(t, err)
+
+	t.Run("should accept valid arguments", func(t *testing.T) {
+		errors := ValidateArguments(schema, map[string]any{
+			"query": "main",
+			"limit": 50.0,
+			"scope": "files",
+			"tags":  []any{"go"},
+		})
+
+		assert.Empty(t, errors)
+	})
+
+	t.Run("should report every invalid argument", func(t *testing.T) {
+		errors := ValidateArguments(schema, map[string]any{
+			"limit": 2.5,
+			"scope": "everything",
+			"tags":  []any{"go", 1.0},
+			"owner": map[string]any{"name": true},
+		})
+
+		assert.Equal(t, []ValidationError{
+			{Path: "query", Message: "is required"},
+			{Path: "limit", Message: "must be an integer"},
+			{Path: "owner.name", Message: "must be of type string"},
+			{Path: "scope", Message: `must be one of ["files","symbols"]`},
+			{Path: "tags[1]", Message: "must be of type string"},
+		}, errors)
+	})
+}
+
+func TestValidateArgumentsWithJSONSchema(t *testing.T) {
+	schema := mcp.NewObjectSchema().
+		WithDef("point", mcp.NewObjectSchema().
+			WithProperty("x", mcp.NewNumberSchema(), true).
+			WithProperty("y", mcp.NewNumberSchema(), true).
+			WithAdditionalProperties(mcp.FalseSchema())).
+		WithProperty("location", mcp.NewOneOfSchema(mcp.NewRefSchema("#/$defs/point"), mcp.NewStringSchema()), true).
+		WithProperty("id", mcp.NewAnyOfSchema(mcp.NewStringSchema(), mcp.NewIntegerSchema()), false).
+		WithProperty("unit", mcp.NewStringSchema().Nullable(), false).
+		WithProperty("tags", mcp.NewArraySchema(mcp.NewStringSchema()).WithMaxItems(2), false).
+		WithAdditionalProperties(mcp.NewIntegerSchema()).
+		ToolInputSchema()
+
+	t.Run("should accept valid arguments", func(t *testing.T) {
+		errors := ValidateArguments(schema, map[string]any{
+			"location": map[string]any{"x": 1.0, "y": 2.0},
+			"id":       3.0,
+			"unit":     nil,
+			"count":    1.0,
+		})
+
+		assert.Empty(t, errors)
+	})
+
+	t.Run("should follow $refs and check composed schemas", func(t *testing.T) {
+		errors := ValidateArguments(schema, map[string]any{
+			"location": map[string]any{"x": 1.0, "z": 2.0},
+			"id":       true,
+			"unit":     1.0,
+			"tags":     []any{"a", "b", "c"},
+			"count":    "one",
+		})
+
+		assert.Equal(t, []ValidationError{
+			{Path: "count", Message: "must be of type integer"},
+			{Path: "id", Message: "must match at least one schema in anyOf"},
+			{Path: "location", Message: "must match exactly one schema in oneOf, but matches 0"},
+			{Path: "tags", Message: "must have at most 2 items"},
+			{Path: "unit", Message: "must be of type string or null"},
+		}, errors)
+	})
+
+	t.Run("should report a $ref which cannot be resolved", func(t *testing.T) {
+		schema := mcp.NewObjectSchema().WithProperty("home", mcp.NewRefSchema("#/$defs/address"), true).ToolInputSchema()
+
+		errors := ValidateArguments(schema, map[string]any{"home": "1 Main St"})
+
+		assert.Equal(t, []ValidationError{{Path: "home", Message: `$ref "#/$defs/address" not found`}}, errors)
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/nalbion/go-mcp/pkg/jsonrpc"
 	"github.com/nalbion/go-mcp/pkg/mcp"
@@ -21,6 +23,18 @@ type ServerOptions struct {
 	// Instructions provides optional instructions to clients
 	Instructions string
 	Logger       shared.MCPLogger
+	// MinProgressInterval limits how often each request may send a progress notification, see ProgressReporter
+	MinProgressInterval time.Duration
+	// ValidateToolArguments checks the arguments of tools/call against the tool's InputSchema before the handler is called,
+	// filling in default values. Invalid calls are rejected with an InvalidParams error listing each problem.
+	// Enabled by NewServerOptions(), but not in a zero value ServerOptions.
+	ValidateToolArguments bool
+	// CoerceToolArguments converts arguments to the type in the InputSchema where possible, eg: "5" to 5,
+	// for clients which send every argument as a string. Requires ValidateToolArguments.
+	CoerceToolArguments bool
+	// ResourceSubscriptions tracks the resources/subscribe requests of the client. If nil, the Server creates its own.
+	// Share one between the Servers of a WebSocketHandler or SocketListener to notify every subscribed client.
+	ResourceSubscriptions *ResourceSubscriptions
 }
 
 func NewServerOptions() ServerOptions {
@@ -28,8 +42,9 @@ func NewServerOptions() ServerOptions {
 		ProtocolOptions: shared.ProtocolOptions{
 			EnforceStrictCapabilities: true,
 		},
-		Capabilities: mcp.ServerCapabilities{},
-		Logger:       shared.DefaultLogger,
+		Capabilities:          mcp.ServerCapabilities{},
+		Logger:                shared.DefaultLogger,
+		ValidateToolArguments: true,
 	}
 }
 
@@ -51,14 +66,19 @@ type Server struct {
 	prompts           map[string]RegisteredPrompt
 	resources         map[string]RegisteredResource
 	resourceTemplates map[string]RegisteredResourceTemplate
+	completers        map[completerKey]Completer
 
 	onInitialized jsonrpc.NotificationHandler
 	onClose       func()
+	session       *Session
+	subscriptions *ResourceSubscriptions
 
-	toolsMutex     sync.RWMutex
-	promptsMutex   sync.RWMutex
-	resourcesMutex sync.RWMutex
-	templatesMutex sync.RWMutex
+	// tools, prompts, resources, templates and completers may be added while requests are being handled
+	toolsMutex      sync.RWMutex
+	promptsMutex    sync.RWMutex
+	resourcesMutex  sync.RWMutex
+	templatesMutex  sync.RWMutex
+	completersMutex sync.RWMutex
 
 	logger shared.MCPLogger
 }
@@ -75,10 +95,18 @@ func NewServer(ctx context.Context, serverInfo mcp.Implementation, options *Serv
 		prompts:           make(map[string]RegisteredPrompt),
 		resources:         make(map[string]RegisteredResource),
 		resourceTemplates: make(map[string]RegisteredResourceTemplate),
+		completers:        make(map[completerKey]Completer),
 		onClose:           func() {},
 		logger:            options.Logger,
+		subscriptions:     options.ResourceSubscriptions,
 	}
 
+	s.session = &Session{server: s}
+	if s.subscriptions == nil {
+		s.subscriptions = NewResourceSubscriptions()
+	}
+	s.SetOnClose(s.handleClose)
+
 	// If no logger was provided, use the default logger
 	if s.logger == nil {
 		s.logger = shared.DefaultLogger
@@ -101,19 +129,46 @@ func NewServer(ctx context.Context, serverInfo mcp.Implementation, options *Serv
 		s.SetRequestHandler(shared.GetPromptsMethod, s.handleGetPrompt)
 	}
 
+	if s.capabilities.Logging != nil {
+		s.SetRequestHandler(shared.LoggingSetLevelMethod, s.handleSetLevel)
+	}
+
 	if s.capabilities.Resources != nil {
 		s.SetRequestHandler(shared.ListResourcesMethod, s.handleListResources)
 		s.SetRequestHandler(shared.ReadResourcesMethod, s.handleReadResource)
-		// s.SetRequestHandler(shared.ListResourcesTemplatesMethod, s.handleListResourceTemplates)
+		s.SetRequestHandler(shared.ListResourcesTemplatesMethod, s.handleListResourceTemplates)
+		if s.capabilities.Resources.Subscribe != nil && *s.capabilities.Resources.Subscribe {
+			s.SetRequestHandler(shared.ResourcesSubscribeMethod, s.handleSubscribe)
+			s.SetRequestHandler(shared.ResourcesUnsubscribeMethod, s.handleUnsubscribe)
+		}
+	}
+
+	if s.capabilities.Completions != nil || s.capabilities.Prompts != nil || s.capabilities.Resources != nil {
+		s.SetRequestHandler(shared.CompletionCompleteMethod, s.handleComplete)
 	}
 
 	return s
 }
 
-func (s *Server) handleInitialize(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+// OnClose adds a callback for when the connection to the client is closed.
+func (s *Server) OnClose(handler func()) {
+	old := s.onClose
+	s.onClose = func() {
+		old()
+		handler()
+	}
+}
+
+func (s *Server) handleClose() {
+	s.subscriptions.removeSession(s.session)
+	s.onClose()
+}
+
+func (s *Server) handleInitialize(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
 	s.logger.Info("Handling initialize request from client: %v", request.Params)
 
-	if initParams, ok := request.Params.AdditionalProperties.(mcp.InitializeRequestParams); !ok {
+	var initParams mcp.InitializeRequestParams
+	if err := shared.DecodeParams(request, &initParams); err != nil {
 		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid initialize request parameters", nil)
 	} else {
 		s.clientCapabilities = &initParams.Capabilities
@@ -151,15 +206,21 @@ func (s *Server) OnInitialized(handler jsonrpc.NotificationHandler) {
 
 const maxListResults = 100
 
-func (s *Server) HandleListTools(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+func (s *Server) HandleListTools(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
 	s.logger.Info("Handling list tools request from client: %v", request.Params)
+	s.toolsMutex.RLock()
 	toolList := make([]mcp.Tool, 0, len(s.tools))
 	for _, tool := range s.tools {
 		toolList = append(toolList, tool.Tool)
 	}
+	s.toolsMutex.RUnlock()
 
 	var cursor *string
-	if listParams, ok := request.Params.AdditionalProperties.(mcp.ListToolsRequestParams); ok {
+	if request.Params != nil {
+		var listParams mcp.ListToolsRequestParams
+		if err := shared.DecodeParams(request, &listParams); err != nil {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid list tools request parameters", nil)
+		}
 		cursor = listParams.Cursor
 	}
 
@@ -176,15 +237,21 @@ func (s *Server) HandleListTools(ctx context.Context, request *jsonrpc.JSONRPCRe
 	}, nil
 }
 
-func (s *Server) handleListPrompts(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+func (s *Server) handleListPrompts(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
 	s.logger.Info("Handling list prompts request from client: %v", request.Params)
+	s.promptsMutex.RLock()
 	promptList := make([]mcp.Prompt, 0, len(s.prompts))
 	for _, prompt := range s.prompts {
 		promptList = append(promptList, prompt.Prompt)
 	}
+	s.promptsMutex.RUnlock()
 
 	var cursor *string
-	if listParams, ok := request.Params.AdditionalProperties.(mcp.ListPromptsRequestParams); ok {
+	if request.Params != nil {
+		var listParams mcp.ListPromptsRequestParams
+		if err := shared.DecodeParams(request, &listParams); err != nil {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid list prompts request parameters", nil)
+		}
 		cursor = listParams.Cursor
 	}
 
@@ -201,15 +268,21 @@ func (s *Server) handleListPrompts(ctx context.Context, request *jsonrpc.JSONRPC
 	}, nil
 }
 
-func (s *Server) handleListResources(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+func (s *Server) handleListResources(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
 	s.logger.Info("Handling list resources request from client: %v", request.Params)
+	s.resourcesMutex.RLock()
 	resourceList := make([]mcp.Resource, 0, len(s.resources))
 	for _, resource := range s.resources {
 		resourceList = append(resourceList, resource.Resource)
 	}
+	s.resourcesMutex.RUnlock()
 
 	var cursor *string
-	if listParams, ok := request.Params.AdditionalProperties.(mcp.ListResourcesRequestParams); ok {
+	if request.Params != nil {
+		var listParams mcp.ListResourcesRequestParams
+		if err := shared.DecodeParams(request, &listParams); err != nil {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid list resources request parameters", nil)
+		}
 		cursor = listParams.Cursor
 	}
 
@@ -226,19 +299,31 @@ func (s *Server) handleListResources(ctx context.Context, request *jsonrpc.JSONR
 	}, nil
 }
 
-func (s *Server) HandleCallTool(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+func (s *Server) HandleCallTool(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
 	s.logger.Info("Handling call tool request from client: %v", request.Params)
 
-	if callParams, ok := request.Params.AdditionalProperties.(mcp.CallToolRequestParams); !ok {
+	var callParams mcp.CallToolRequestParams
+	if err := shared.DecodeParams(request, &callParams); err != nil {
 		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid call tool request parameters", nil)
 	} else {
-		if tool, ok := s.tools[callParams.Name]; !ok {
+		if tool, ok := s.registeredTool(callParams.Name); !ok {
 			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Tool not found", nil)
 		} else {
-			toolResult, err := tool.Handler(callParams)
+			if s.options.ValidateToolArguments {
+				arguments, validationErrors := PrepareArguments(tool.Tool.InputSchema, callParams.Arguments, s.options.CoerceToolArguments)
+				if len(validationErrors) > 0 {
+					return jsonrpc.Result{}, invalidArgumentsError(request.Id, callParams.Name, validationErrors)
+				}
+				callParams.Arguments = arguments
+			}
+			toolResult, err := tool.Handler(ctx, callParams, s.newRequestHandlerExtra(request, extra))
 			if err != nil {
 				return jsonrpc.Result{}, err
 			}
+			if err := tool.Tool.ValidateStructuredContent(toolResult); err != nil {
+				s.logger.Error("Tool %s returned an invalid result: %v", callParams.Name, err)
+				return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InternalError, err.Error(), nil)
+			}
 			return jsonrpc.Result{
 				AdditionalProperties: toolResult,
 			}, nil
@@ -246,16 +331,17 @@ func (s *Server) HandleCallTool(ctx context.Context, request *jsonrpc.JSONRPCReq
 	}
 }
 
-func (s *Server) handleGetPrompt(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+func (s *Server) handleGetPrompt(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
 	s.logger.Info("Handling get prompt request from client: %v", request.Params)
 
-	if getParams, ok := request.Params.AdditionalProperties.(mcp.GetPromptRequestParams); !ok {
+	var getParams mcp.GetPromptRequestParams
+	if err := shared.DecodeParams(request, &getParams); err != nil {
 		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid get prompt request parameters", nil)
 	} else {
-		if prompt, ok := s.prompts[getParams.Name]; !ok {
+		if prompt, ok := s.registeredPrompt(getParams.Name); !ok {
 			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Prompt not found", nil)
 		} else {
-			promptResult := prompt.MessageProvider(getParams)
+			promptResult := prompt.MessageProvider(ctx, getParams, s.newRequestHandlerExtra(request, extra))
 			return jsonrpc.Result{
 				AdditionalProperties: promptResult,
 			}, nil
@@ -263,25 +349,146 @@ func (s *Server) handleGetPrompt(ctx context.Context, request *jsonrpc.JSONRPCRe
 	}
 }
 
-func (s *Server) handleReadResource(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+func (s *Server) handleReadResource(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
 	s.logger.Info("Handling read resource request from client: %v", request.Params)
 
-	if readParams, ok := request.Params.AdditionalProperties.(mcp.ReadResourceRequestParams); !ok {
+	var readParams mcp.ReadResourceRequestParams
+	if err := shared.DecodeParams(request, &readParams); err != nil {
 		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid read resource request parameters", nil)
 	} else {
-		if resource, ok := s.resources[readParams.Uri]; !ok {
-			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Resource not found", nil)
-		} else {
-			resourceResult := resource.ReadHandler(readParams)
+		if resource, ok := s.registeredResource(readParams.Uri); ok {
+			resourceResult := resource.ReadHandler(ctx, readParams, s.newRequestHandlerExtra(request, extra))
 			return jsonrpc.Result{
 				AdditionalProperties: resourceResult,
 			}, nil
 		}
+
+		if template, variables, ok := s.matchResourceTemplate(readParams.Uri); ok {
+			resourceResult := template.Handler(ctx, readParams, variables, s.newRequestHandlerExtra(request, extra))
+			return jsonrpc.Result{
+				AdditionalProperties: resourceResult,
+			}, nil
+		}
+		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Resource not found", nil)
+	}
+}
+
+func (s *Server) registeredTool(name string) (RegisteredTool, bool) {
+	s.toolsMutex.RLock()
+	defer s.toolsMutex.RUnlock()
+	tool, ok := s.tools[name]
+	return tool, ok
+}
+
+func (s *Server) registeredPrompt(name string) (RegisteredPrompt, bool) {
+	s.promptsMutex.RLock()
+	defer s.promptsMutex.RUnlock()
+	prompt, ok := s.prompts[name]
+	return prompt, ok
+}
+
+func (s *Server) registeredResource(uri string) (RegisteredResource, bool) {
+	s.resourcesMutex.RLock()
+	defer s.resourcesMutex.RUnlock()
+	resource, ok := s.resources[uri]
+	return resource, ok
+}
+
+// matchResourceTemplate finds the resource template which matches the URI.
+// If more than one template matches, the first by uriTemplate in lexical order is used.
+func (s *Server) matchResourceTemplate(uri string) (RegisteredResourceTemplate, mcp.UriTemplateVariables, bool) {
+	for _, template := range s.sortedResourceTemplates() {
+		if variables, ok := template.uriTemplate.Match(uri); ok {
+			return template, variables, true
+		}
+	}
+	return RegisteredResourceTemplate{}, nil, false
+}
+
+func (s *Server) sortedResourceTemplates() []RegisteredResourceTemplate {
+	s.templatesMutex.RLock()
+	defer s.templatesMutex.RUnlock()
+
+	templates := make([]RegisteredResourceTemplate, 0, len(s.resourceTemplates))
+	for _, template := range s.resourceTemplates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Template.UriTemplate < templates[j].Template.UriTemplate
+	})
+	return templates
+}
+
+func (s *Server) handleSetLevel(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+	s.logger.Info("Handling set level request from client: %v", request.Params)
+
+	var setLevelParams mcp.SetLevelRequestParams
+	if err := shared.DecodeParams(request, &setLevelParams); err != nil || !setLevelParams.Level.IsValid() {
+		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid set level request parameters", nil)
+	}
+
+	s.session.setLoggingLevel(setLevelParams.Level)
+	return jsonrpc.Result{}, nil
+}
+
+func (s *Server) handleSubscribe(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+	s.logger.Info("Handling subscribe request from client: %v", request.Params)
+
+	var subscribeParams mcp.SubscribeRequestParams
+	if err := shared.DecodeParams(request, &subscribeParams); err != nil {
+		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid subscribe request parameters", nil)
+	}
+	if _, ok := s.registeredResource(subscribeParams.Uri); !ok {
+		if _, _, ok := s.matchResourceTemplate(subscribeParams.Uri); !ok {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Resource not found", nil)
+		}
 	}
+
+	s.subscriptions.subscribe(s.session, subscribeParams.Uri)
+	return jsonrpc.Result{}, nil
 }
 
-// func (s *Server) handleListResourceTemplates(ctx context.Context, request jsonrpc.JSONRPCRequest, extra jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
-// }
+func (s *Server) handleUnsubscribe(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+	s.logger.Info("Handling unsubscribe request from client: %v", request.Params)
+
+	var unsubscribeParams mcp.UnsubscribeRequestParams
+	if err := shared.DecodeParams(request, &unsubscribeParams); err != nil {
+		return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid unsubscribe request parameters", nil)
+	}
+
+	s.subscriptions.unsubscribe(s.session, unsubscribeParams.Uri)
+	return jsonrpc.Result{}, nil
+}
+
+func (s *Server) handleListResourceTemplates(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+	s.logger.Info("Handling list resource templates request from client: %v", request.Params)
+	templates := s.sortedResourceTemplates()
+	templateList := make([]mcp.ResourceTemplate, len(templates))
+	for i, template := range templates {
+		templateList[i] = template.Template
+	}
+
+	var cursor *string
+	if request.Params != nil {
+		var listParams mcp.ListResourceTemplatesRequestParams
+		if err := shared.DecodeParams(request, &listParams); err != nil {
+			return jsonrpc.Result{}, jsonrpc.NewJSONRPCErrorError(request.Id, jsonrpc.InvalidParams, "Invalid list resource templates request parameters", nil)
+		}
+		cursor = listParams.Cursor
+	}
+
+	templateList, nextCursor, err := paginate(request.Id, templateList, cursor)
+	if err != nil {
+		return jsonrpc.Result{}, err
+	}
+
+	return jsonrpc.Result{
+		AdditionalProperties: mcp.ListResourceTemplatesResult{
+			ResourceTemplates: templateList,
+			NextCursor:        nextCursor,
+		},
+	}, nil
+}
 
 func (c *Server) AssertCapabilityForMethod(method jsonrpc.Method) error {
 	switch method {
@@ -293,6 +500,10 @@ func (c *Server) AssertCapabilityForMethod(method jsonrpc.Method) error {
 		if c.clientCapabilities.Roots == nil {
 			return fmt.Errorf("client does not support roots (required for %s)", method)
 		}
+	case shared.ElicitationCreateMethod:
+		if c.clientCapabilities.Elicitation == nil {
+			return fmt.Errorf("client does not support elicitation (required for %s)", method)
+		}
 	}
 
 	return nil
@@ -339,6 +550,14 @@ func (c *Server) AssertRequestHandlerCapability(method jsonrpc.Method) error {
 		if c.capabilities.Resources == nil {
 			return fmt.Errorf("server does not support resources (required for %s)", method)
 		}
+	case shared.ResourcesSubscribeMethod, shared.ResourcesUnsubscribeMethod:
+		if c.capabilities.Resources == nil || c.capabilities.Resources.Subscribe == nil || !*c.capabilities.Resources.Subscribe {
+			return fmt.Errorf("server does not support resource subscriptions (required for %s)", method)
+		}
+	case shared.CompletionCompleteMethod:
+		if c.capabilities.Completions == nil && c.capabilities.Prompts == nil && c.capabilities.Resources == nil {
+			return fmt.Errorf("server does not support completions (required for %s)", method)
+		}
 	case shared.ToolsCallMethod, shared.ToolsListMethod:
 		if c.capabilities.Tools == nil {
 			return fmt.Errorf("server does not support tools (required for %s)", method)
@@ -349,22 +568,31 @@ func (c *Server) AssertRequestHandlerCapability(method jsonrpc.Method) error {
 }
 
 // AddTool registers a single tool. This tool can then be called by the client
+// Annotations are optional, eg: mcp.NewToolAnnotations().WithReadOnlyHint(true), and at most one may be given.
 func (s *Server) AddTool(
 	name string,
 	description string,
 	inputSchema mcp.ToolInputSchema,
-	handler func(mcp.CallToolRequestParams) (mcp.CallToolResult, error),
+	handler ToolHandler,
+	annotations ...*mcp.ToolAnnotations,
 ) error {
 	if s.capabilities.Tools == nil {
 		return errors.New("Server does not support tools capability. Enable it in ServerOptions.")
 	}
+	toolAnnotations, err := optionalAnnotations(name, annotations)
+	if err != nil {
+		return err
+	}
 
 	s.logger.Info("Registering tool %s", name)
+	s.toolsMutex.Lock()
+	defer s.toolsMutex.Unlock()
 	s.tools[name] = RegisteredTool{
 		Tool: mcp.Tool{
 			Name:        name,
 			Description: description,
 			InputSchema: inputSchema,
+			Annotations: toolAnnotations,
 		},
 		Handler: handler,
 	}
@@ -372,6 +600,17 @@ func (s *Server) AddTool(
 	return nil
 }
 
+// optionalAnnotations returns the annotations passed to the variadic parameter of AddTool() or AddTypedTool(), if any.
+func optionalAnnotations(name string, annotations []*mcp.ToolAnnotations) (*mcp.ToolAnnotations, error) {
+	switch len(annotations) {
+	case 0:
+		return nil, nil
+	case 1:
+		return annotations[0], nil
+	}
+	return nil, fmt.Errorf("tool %s has %d annotations, at most one may be given", name, len(annotations))
+}
+
 // AddTools registers multiple tools at once.
 func (s *Server) AddTools(toolsToAdd []RegisteredTool) error {
 	if s.capabilities.Tools == nil {
@@ -379,6 +618,8 @@ func (s *Server) AddTools(toolsToAdd []RegisteredTool) error {
 	}
 
 	s.logger.Info("Registering %d tools", len(toolsToAdd))
+	s.toolsMutex.Lock()
+	defer s.toolsMutex.Unlock()
 	for _, rt := range toolsToAdd {
 		s.logger.Info("Registering tool %s", rt.Tool.Name)
 		s.tools[rt.Tool.Name] = rt
@@ -388,12 +629,14 @@ func (s *Server) AddTools(toolsToAdd []RegisteredTool) error {
 }
 
 // AddPrompt registers a single prompt. The prompt can then be retrieved by the client.
-func (s *Server) AddPrompt(prompt mcp.Prompt, promptProvider func(mcp.GetPromptRequestParams) mcp.GetPromptResult) error {
+func (s *Server) AddPrompt(prompt mcp.Prompt, promptProvider PromptProvider) error {
 	if s.capabilities.Prompts == nil {
 		return errors.New("Server does not support prompts capability.")
 	}
 
 	s.logger.Info("Registering prompt %s", prompt.Name)
+	s.promptsMutex.Lock()
+	defer s.promptsMutex.Unlock()
 	s.prompts[prompt.Name] = RegisteredPrompt{
 		Prompt:          prompt,
 		MessageProvider: promptProvider,
@@ -409,6 +652,8 @@ func (s *Server) AddPrompts(promptsToAdd []RegisteredPrompt) error {
 	}
 
 	s.logger.Info("Registering %d prompts", len(promptsToAdd))
+	s.promptsMutex.Lock()
+	defer s.promptsMutex.Unlock()
 	for _, rp := range promptsToAdd {
 		s.logger.Info("Registering prompt %s", rp.Prompt.Name)
 		s.prompts[rp.Prompt.Name] = rp
@@ -423,13 +668,15 @@ func (s *Server) AddResource(
 	name string,
 	description string,
 	mimeType string,
-	readHandler func(mcp.ReadResourceRequestParams) mcp.ReadResourceResult,
+	readHandler ResourceReadHandler,
 ) error {
 	if s.capabilities.Resources == nil {
 		return errors.New("Server does not support resources capability.")
 	}
 
 	s.logger.Info("Registering resource %s at %s", name, uri)
+	s.resourcesMutex.Lock()
+	defer s.resourcesMutex.Unlock()
 	s.resources[uri] = RegisteredResource{
 		Resource: mcp.Resource{
 			Uri:         uri,
@@ -450,6 +697,8 @@ func (s *Server) AddResources(resourcesToAdd []RegisteredResource) error {
 	}
 
 	s.logger.Info("Registering %d resources", len(resourcesToAdd))
+	s.resourcesMutex.Lock()
+	defer s.resourcesMutex.Unlock()
 	for _, r := range resourcesToAdd {
 		s.logger.Info("Registering resource %s at %s", r.Resource.Name, r.Resource.Uri)
 		s.resources[r.Resource.Uri] = r
@@ -458,6 +707,62 @@ func (s *Server) AddResources(resourcesToAdd []RegisteredResource) error {
 	return nil
 }
 
+// AddResourceTemplate registers a resource template, eg: "users://{id}/profile", see RFC 6570.
+// Reads of URIs which match the template, and which are not registered resources, are passed to the handler
+// with the values of the template's variables.
+func (s *Server) AddResourceTemplate(
+	uriTemplate string,
+	name string,
+	description string,
+	mimeType string,
+	handler ResourceTemplateReadHandler,
+) error {
+	template := mcp.ResourceTemplate{
+		UriTemplate: uriTemplate,
+		Name:        name,
+	}
+	if description != "" {
+		template.Description = &description
+	}
+	if mimeType != "" {
+		template.MimeType = &mimeType
+	}
+
+	return s.AddResourceTemplates([]RegisteredResourceTemplate{{Template: template, Handler: handler}})
+}
+
+// AddResourceTemplates registers multiple resource templates at once.
+// An error is returned, and none of the templates are registered, if any of them is not a valid URI template.
+func (s *Server) AddResourceTemplates(templatesToAdd []RegisteredResourceTemplate) error {
+	if s.capabilities.Resources == nil {
+		return errors.New("Server does not support resources capability.")
+	}
+
+	for i := range templatesToAdd {
+		uriTemplate, err := mcp.ParseUriTemplate(templatesToAdd[i].Template.UriTemplate)
+		if err != nil {
+			return err
+		}
+		templatesToAdd[i].uriTemplate = uriTemplate
+	}
+
+	s.templatesMutex.Lock()
+	defer s.templatesMutex.Unlock()
+
+	s.logger.Info("Registering %d resource templates", len(templatesToAdd))
+	for _, rt := range templatesToAdd {
+		s.logger.Info("Registering resource template %s at %s", rt.Template.Name, rt.Template.UriTemplate)
+		s.resourceTemplates[rt.Template.UriTemplate] = rt
+	}
+
+	return nil
+}
+
+// Session returns the connection to the client, which is also passed to handlers as RequestHandlerExtra.Session.
+func (s *Server) Session() *Session {
+	return s.session
+}
+
 // Ping sends a ping request to the client to check connectivity.
 func (s *Server) Ping() error {
 	return s.SendRequest(s.ctx, shared.PingMethod, nil, nil, nil)
@@ -497,17 +802,30 @@ func (s *Server) ListRoots(options *mcp.RequestOptions) (*mcp.ListRootsResult, e
 	return result, err
 }
 
-// SendLoggingMessage sends a logging message notification to the client.
+// Elicit asks the client to collect information from the user, see Session.Elicit().
+func (s *Server) Elicit(ctx context.Context, message string, requestedSchema *mcp.JSONSchema) (*mcp.ElicitResult, error) {
+	return s.session.Elicit(ctx, message, requestedSchema)
+}
+
+// SendLoggingMessage sends a logging message notification to the client,
+// unless it is less severe than the level the client asked for with logging/setLevel.
 func (s *Server) SendLoggingMessage(params mcp.LoggingMessageNotificationParams) error {
-	return s.SendNotification(
-		shared.LoggingMessageNotificationMethod,
-		&jsonrpc.JSONRPCNotificationParams{
-			AdditionalProperties: params,
-		},
-	)
+	return s.session.SendLoggingMessage(params)
+}
+
+// ResourceSubscriptions returns the subscriptions of the client to resources, eg: to set OnFirstSubscriber().
+func (s *Server) ResourceSubscriptions() *ResourceSubscriptions {
+	return s.subscriptions
 }
 
-// SendResourceUpdated sends a resource-updated notification to the client.
+// NotifyResourceUpdated sends a resource-updated notification to every session subscribed to the resource,
+// which includes the clients of other Servers sharing the same ServerOptions.ResourceSubscriptions.
+func (s *Server) NotifyResourceUpdated(uri string) error {
+	return s.subscriptions.NotifyResourceUpdated(uri)
+}
+
+// SendResourceUpdated sends a resource-updated notification to the client, whether or not it has subscribed to the resource.
+// See NotifyResourceUpdated().
 func (s *Server) SendResourceUpdated(params mcp.ResourceUpdatedNotificationParams) error {
 	return s.SendNotification(
 		shared.ResourceUpdatedNotificationMethod,
@@ -535,25 +853,27 @@ func (s *Server) SendPromptListChanged() error {
 // RegisteredTool represents a registered tool on the server.
 type RegisteredTool struct {
 	Tool    mcp.Tool
-	Handler func(mcp.CallToolRequestParams) (mcp.CallToolResult, error)
+	Handler ToolHandler
 }
 
 // RegisteredPrompt represents a registered prompt on the server.
 type RegisteredPrompt struct {
 	Prompt          mcp.Prompt
-	MessageProvider func(mcp.GetPromptRequestParams) mcp.GetPromptResult
+	MessageProvider PromptProvider
 }
 
 // RegisteredResource represents a registered resource on the server.
 type RegisteredResource struct {
 	Resource    mcp.Resource
-	ReadHandler func(mcp.ReadResourceRequestParams) mcp.ReadResourceResult
+	ReadHandler ResourceReadHandler
 }
 
 // RegisteredResourceTemplate represents a registered resource template on the server.
 type RegisteredResourceTemplate struct {
 	Template mcp.ResourceTemplate
-	Handler  func(mcp.ReadResourceRequestParams) mcp.ReadResourceResult
+	Handler  ResourceTemplateReadHandler
+
+	uriTemplate *mcp.UriTemplate
 }
 
 func paginate[T any](requestId jsonrpc.RequestId, items []T, cursor *string) ([]T, *string, *jsonrpc.JSONRPCErrorError) {
@@ -562,7 +882,7 @@ func paginate[T any](requestId jsonrpc.RequestId, items []T, cursor *string) ([]
 
 	if cursor != nil {
 		cursor, err := strconv.Atoi(*cursor)
-		if err != nil {
+		if err != nil || cursor < 0 || cursor > len(items) {
 			return nil, nil, jsonrpc.NewJSONRPCErrorError(requestId, jsonrpc.InvalidParams, "Invalid cursor", nil)
 		}
 		start = cursor
@@ -570,11 +890,12 @@ func paginate[T any](requestId jsonrpc.RequestId, items []T, cursor *string) ([]
 
 	// if there are more items than maxListResults, we set nextCursor to the next index
 	// eg: if start = 0 & len(toolList) = 1000, we return toolList[0:100] and nextCursor = "100"
+	var nextCursor *string
 	if end > start+maxListResults {
 		end = start + maxListResults
 		nextCursorValue := strconv.Itoa(start + maxListResults)
-		cursor = &nextCursorValue
+		nextCursor = &nextCursorValue
 	}
 
-	return items[start:end], cursor, nil
+	return items[start:end], nextCursor, nil
 }
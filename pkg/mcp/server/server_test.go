@@ -2,6 +2,8 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/nalbion/go-mcp/pkg/jsonrpc"
@@ -16,16 +18,11 @@ type mockToolHandler struct {
 	params mcp.CallToolRequestParams
 }
 
-func (m *mockToolHandler) Handle(params mcp.CallToolRequestParams) (mcp.CallToolResult, error) {
+func (m *mockToolHandler) Handle(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
 	m.called = true
 	m.params = params
 	return mcp.CallToolResult{
-		Content: []interface{}{
-			map[string]interface{}{
-				"type": "text",
-				"text": "Mock tool response",
-			},
-		},
+		Content: []mcp.Content{mcp.Text("Mock tool response")},
 	}, nil
 }
 
@@ -35,7 +32,7 @@ type mockPromptProvider struct {
 	params mcp.GetPromptRequestParams
 }
 
-func (m *mockPromptProvider) Provide(params mcp.GetPromptRequestParams) mcp.GetPromptResult {
+func (m *mockPromptProvider) Provide(ctx context.Context, params mcp.GetPromptRequestParams, extra *RequestHandlerExtra) mcp.GetPromptResult {
 	m.called = true
 	m.params = params
 	return mcp.GetPromptResult{
@@ -57,7 +54,7 @@ type mockResourceHandler struct {
 	params mcp.ReadResourceRequestParams
 }
 
-func (m *mockResourceHandler) Read(params mcp.ReadResourceRequestParams) mcp.ReadResourceResult {
+func (m *mockResourceHandler) Read(ctx context.Context, params mcp.ReadResourceRequestParams, extra *RequestHandlerExtra) mcp.ReadResourceResult {
 	m.called = true
 	m.params = params
 	return mcp.ReadResourceResult{
@@ -490,6 +487,161 @@ func TestHandleReadResource(t *testing.T) {
 	assert.Equal(t, "test://resource", resourceHandler.params.Uri)
 }
 
+func TestResourceTemplates(t *testing.T) {
+	ctx := context.Background()
+	newServer := func(t *testing.T) *Server {
+		options := NewServerOptions()
+		options.Capabilities = mcp.ServerCapabilities{
+			Resources: &mcp.ServerCapabilitiesResources{},
+		}
+		return NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+	}
+	readProfile := func(ctx context.Context, params mcp.ReadResourceRequestParams, variables mcp.UriTemplateVariables, extra *RequestHandlerExtra) mcp.ReadResourceResult {
+		return mcp.ReadResourceResult{
+			Contents: []mcp.HasResourceContents{
+				&mcp.TextResourceContents{
+					ResourceContents: mcp.ResourceContents{Uri: params.Uri, MimeType: "text/plain"},
+					Text:             "Profile of user " + variables.Get("id"),
+				},
+			},
+		}
+	}
+
+	t.Run("should list resource templates", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		err := server.AddResourceTemplate("users://{id}/profile", "User profile", "The profile of a user", "text/plain", readProfile)
+		require.NoError(t, err)
+
+		// when
+		result, err := server.handleListResourceTemplates(ctx, &jsonrpc.JSONRPCRequest{
+			Id:     1,
+			Method: "resources/templates/list",
+		}, nil)
+
+		// then
+		require.NoError(t, err)
+		listResult, ok := result.AdditionalProperties.(mcp.ListResourceTemplatesResult)
+		require.True(t, ok)
+		require.Len(t, listResult.ResourceTemplates, 1)
+		assert.Equal(t, "users://{id}/profile", listResult.ResourceTemplates[0].UriTemplate)
+		assert.Equal(t, "The profile of a user", *listResult.ResourceTemplates[0].Description)
+		assert.Nil(t, listResult.NextCursor)
+	})
+
+	t.Run("should paginate resource templates", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		templates := make([]RegisteredResourceTemplate, maxListResults+5)
+		for i := range templates {
+			templates[i] = RegisteredResourceTemplate{
+				Template: mcp.ResourceTemplate{UriTemplate: fmt.Sprintf("test://%03d/{id}", i), Name: fmt.Sprintf("Template %d", i)},
+				Handler:  readProfile,
+			}
+		}
+		require.NoError(t, server.AddResourceTemplates(templates))
+
+		// when
+		first, err := server.handleListResourceTemplates(ctx, &jsonrpc.JSONRPCRequest{Id: 1, Method: "resources/templates/list"}, nil)
+		require.NoError(t, err)
+		firstPage := first.AdditionalProperties.(mcp.ListResourceTemplatesResult)
+		second, err := server.handleListResourceTemplates(ctx, &jsonrpc.JSONRPCRequest{
+			Id:     2,
+			Method: "resources/templates/list",
+			Params: &jsonrpc.JSONRPCRequestParams{
+				AdditionalProperties: mcp.ListResourceTemplatesRequestParams{Cursor: firstPage.NextCursor},
+			},
+		}, nil)
+
+		// then
+		require.NoError(t, err)
+		secondPage := second.AdditionalProperties.(mcp.ListResourceTemplatesResult)
+		assert.Len(t, firstPage.ResourceTemplates, maxListResults)
+		assert.Equal(t, "test://000/{id}", firstPage.ResourceTemplates[0].UriTemplate)
+		require.Len(t, secondPage.ResourceTemplates, 5)
+		assert.Equal(t, "test://100/{id}", secondPage.ResourceTemplates[0].UriTemplate)
+		assert.Nil(t, secondPage.NextCursor)
+	})
+
+	t.Run("should read a resource which matches a template", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		var receivedVariables mcp.UriTemplateVariables
+		err := server.AddResourceTemplate("users://{id}/profile", "User profile", "", "text/plain",
+			func(ctx context.Context, params mcp.ReadResourceRequestParams, variables mcp.UriTemplateVariables, extra *RequestHandlerExtra) mcp.ReadResourceResult {
+				receivedVariables = variables
+				return readProfile(ctx, params, variables, extra)
+			})
+		require.NoError(t, err)
+
+		// when
+		result, err := server.handleReadResource(ctx, &jsonrpc.JSONRPCRequest{
+			Id:     1,
+			Method: "resources/read",
+			Params: &jsonrpc.JSONRPCRequestParams{
+				AdditionalProperties: mcp.ReadResourceRequestParams{Uri: "users://alice%20smith/profile"},
+			},
+		}, nil)
+
+		// then
+		require.NoError(t, err)
+		readResult, ok := result.AdditionalProperties.(mcp.ReadResourceResult)
+		require.True(t, ok)
+		assert.Equal(t, mcp.UriTemplateVariables{"id": "alice smith"}, receivedVariables)
+		assert.Equal(t, "Profile of user alice smith", readResult.Contents[0].GetValue())
+	})
+
+	t.Run("should prefer a registered resource to a template", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		resourceHandler := &mockResourceHandler{}
+		require.NoError(t, server.AddResource("users://admin/profile", "Admin", "", "text/plain", resourceHandler.Read))
+		require.NoError(t, server.AddResourceTemplate("users://{id}/profile", "User profile", "", "text/plain", readProfile))
+
+		// when
+		_, err := server.handleReadResource(ctx, &jsonrpc.JSONRPCRequest{
+			Id:     1,
+			Method: "resources/read",
+			Params: &jsonrpc.JSONRPCRequestParams{
+				AdditionalProperties: mcp.ReadResourceRequestParams{Uri: "users://admin/profile"},
+			},
+		}, nil)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, resourceHandler.called)
+	})
+
+	t.Run("should return an error if no resource or template matches", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		require.NoError(t, server.AddResourceTemplate("users://{id}/profile", "User profile", "", "text/plain", readProfile))
+
+		// when
+		_, err := server.handleReadResource(ctx, &jsonrpc.JSONRPCRequest{
+			Id:     1,
+			Method: "resources/read",
+			Params: &jsonrpc.JSONRPCRequestParams{
+				AdditionalProperties: mcp.ReadResourceRequestParams{Uri: "users://alice/settings"},
+			},
+		}, nil)
+
+		// then
+		assert.ErrorContains(t, err, "Resource not found")
+	})
+
+	t.Run("should reject an invalid URI template", func(t *testing.T) {
+		// given
+		server := newServer(t)
+
+		// when
+		err := server.AddResourceTemplate("users://{id/profile", "User profile", "", "text/plain", readProfile)
+
+		// then
+		assert.ErrorContains(t, err, "unclosed expression")
+	})
+}
+
 func TestSendNotifications(t *testing.T) {
 	// given
 	ctx := context.Background()
@@ -560,3 +712,47 @@ func TestSendNotifications(t *testing.T) {
 		assert.Equal(t, "resources/updated", mockTransport.SentNotifications[0].Method)
 	})
 }
+
+func TestConcurrentRegistration(t *testing.T) {
+	t.Run("should handle requests while tools, prompts and resources are added", func(t *testing.T) {
+		// given
+		ctx := context.Background()
+		options := NewServerOptions()
+		options.Capabilities = mcp.ServerCapabilities{
+			Tools:     &mcp.ServerToolsCapabilities{},
+			Prompts:   &mcp.ServerCapabilitiesPrompts{},
+			Resources: &mcp.ServerCapabilitiesResources{},
+		}
+		server := NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+
+		// when
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				name := fmt.Sprintf("item-%d", i)
+				assert.NoError(t, server.AddTool(name, "", mcp.ToolInputSchema{}, (&mockToolHandler{}).Handle))
+				assert.NoError(t, server.AddPrompt(mcp.Prompt{Name: name}, (&mockPromptProvider{}).Provide))
+				assert.NoError(t, server.AddResource("test://"+name, name, "", "text/plain", (&mockResourceHandler{}).Read))
+			}()
+			go func() {
+				defer wg.Done()
+				request := &jsonrpc.JSONRPCRequest{Id: jsonrpc.RequestId(i)}
+				_, err := server.HandleListTools(ctx, request, nil)
+				assert.NoError(t, err)
+				_, err = server.handleListPrompts(ctx, request, nil)
+				assert.NoError(t, err)
+				_, err = server.handleListResources(ctx, request, nil)
+				assert.NoError(t, err)
+				server.registeredResource(fmt.Sprintf("test://item-%d", i))
+			}()
+		}
+		wg.Wait()
+
+		// then
+		result, err := server.HandleListTools(ctx, &jsonrpc.JSONRPCRequest{Id: 1}, nil)
+		require.NoError(t, err)
+		assert.Len(t, result.AdditionalProperties.(mcp.ListToolsResult).Tools, 10)
+	})
+}
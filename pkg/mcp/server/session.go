@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// ToolHandler is called when a client calls a tool.
+// ctx is cancelled if the client cancels the request or disconnects.
+type ToolHandler func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error)
+
+// PromptProvider is called when a client gets a prompt.
+type PromptProvider func(ctx context.Context, params mcp.GetPromptRequestParams, extra *RequestHandlerExtra) mcp.GetPromptResult
+
+// ResourceReadHandler is called when a client reads a resource.
+type ResourceReadHandler func(ctx context.Context, params mcp.ReadResourceRequestParams, extra *RequestHandlerExtra) mcp.ReadResourceResult
+
+// ResourceTemplateReadHandler is called when a client reads a resource with a URI which matches a resource template.
+// variables holds the values of the template's variables, extracted from the URI.
+type ResourceTemplateReadHandler func(ctx context.Context, params mcp.ReadResourceRequestParams, variables mcp.UriTemplateVariables, extra *RequestHandlerExtra) mcp.ReadResourceResult
+
+// RequestHandlerExtra is passed to tool, prompt and resource handlers with details of the request and the client which sent it.
+type RequestHandlerExtra struct {
+	*jsonrpc.RequestHandlerExtra
+	// ClientInfo and ClientCapabilities were sent by the client in the initialize request, and are nil until then.
+	ClientInfo         *mcp.Implementation
+	ClientCapabilities *mcp.ClientCapabilities
+	// Session is used to send notifications or make requests back to the client while the request is in flight.
+	Session *Session
+	// Progress sends progress notifications for this request, if the client asked for them.
+	Progress *ProgressReporter
+}
+
+// Session is the connection to a client. There is one for each Server, as each Server instance serves a single client.
+type Session struct {
+	server *Server
+
+	loggingMutex sync.RWMutex
+	// loggingLevel was requested by the client with logging/setLevel, or is "" to send all log messages
+	loggingLevel mcp.LoggingLevel
+}
+
+func (s *Server) newRequestHandlerExtra(request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) *RequestHandlerExtra {
+	if extra == nil {
+		// eg: a handler called directly, rather than by the Protocol
+		extra = &jsonrpc.RequestHandlerExtra{RequestId: request.Id}
+		if request.Params != nil && request.Params.Meta != nil {
+			extra.Meta = *request.Params.Meta
+		}
+	}
+
+	return &RequestHandlerExtra{
+		RequestHandlerExtra: extra,
+		ClientInfo:          s.clientVersion,
+		ClientCapabilities:  s.clientCapabilities,
+		Session:             s.session,
+		Progress:            newProgressReporter(s.session, extra.Meta, s.options.MinProgressInterval),
+	}
+}
+
+// SendNotification sends a notification to the client.
+func (s *Session) SendNotification(method jsonrpc.Method, params any) error {
+	var notificationParams *jsonrpc.JSONRPCNotificationParams
+	if params != nil {
+		notificationParams = &jsonrpc.JSONRPCNotificationParams{AdditionalProperties: params}
+	}
+	return s.server.SendNotification(method, notificationParams)
+}
+
+// SendLoggingMessage sends a log message to the client, unless it is less severe than the level the client asked for.
+func (s *Session) SendLoggingMessage(params mcp.LoggingMessageNotificationParams) error {
+	if !s.IsLoggingEnabled(params.Level) {
+		return nil
+	}
+	return s.SendNotification(shared.LoggingMessageNotificationMethod, params)
+}
+
+// LoggingLevel returns the minimum level of log messages which the client asked for with logging/setLevel,
+// or "" if it has not asked, in which case all log messages are sent.
+func (s *Session) LoggingLevel() mcp.LoggingLevel {
+	s.loggingMutex.RLock()
+	defer s.loggingMutex.RUnlock()
+	return s.loggingLevel
+}
+
+// IsLoggingEnabled returns true if log messages at the level would be sent to the client.
+func (s *Session) IsLoggingEnabled(level mcp.LoggingLevel) bool {
+	min := s.LoggingLevel()
+	return min == "" || level.IsAtLeast(min)
+}
+
+func (s *Session) setLoggingLevel(level mcp.LoggingLevel) {
+	s.loggingMutex.Lock()
+	defer s.loggingMutex.Unlock()
+	s.loggingLevel = level
+}
+
+// SendRequest sends a request to the client and waits for the response, which is unmarshalled into `result`.
+// Pass the ctx of the handler so that the request is abandoned if the handler is cancelled.
+func (s *Session) SendRequest(ctx context.Context, method jsonrpc.Method, params any, result any, options *mcp.RequestOptions) error {
+	if err := s.assertClientCapability(method); err != nil {
+		return err
+	}
+
+	var requestParams *jsonrpc.JSONRPCRequestParams
+	if params != nil {
+		requestParams = &jsonrpc.JSONRPCRequestParams{AdditionalProperties: params}
+	}
+	var requestResult *jsonrpc.Result
+	if result != nil {
+		requestResult = &jsonrpc.Result{AdditionalProperties: result}
+	}
+	return s.server.SendRequest(ctx, method, requestParams, requestResult, options)
+}
+
+// CreateMessage asks the client to sample from an LLM.
+func (s *Session) CreateMessage(ctx context.Context, params mcp.CreateMessageRequestParams, options *mcp.RequestOptions) (*mcp.CreateMessageResult, error) {
+	result := &mcp.CreateMessageResult{}
+	err := s.SendRequest(ctx, shared.SamplingCreateMessageMethod, params, result, options)
+	return result, err
+}
+
+// ListRoots asks the client for its roots.
+func (s *Session) ListRoots(ctx context.Context, options *mcp.RequestOptions) (*mcp.ListRootsResult, error) {
+	result := &mcp.ListRootsResult{}
+	err := s.SendRequest(ctx, shared.RootsListMethod, nil, result, options)
+	return result, err
+}
+
+// Elicit asks the client to collect information from the user with a form for the requested schema, eg: in a tool handler
+//
+//	schema := mcp.NewObjectSchema().WithProperty("confirm", mcp.NewBooleanSchema(), true)
+//	result, err := extra.Session.Elicit(ctx, "Delete all 12 files?", schema)
+//
+// The schema must be an object with primitive properties, see mcp.ValidateElicitationSchema().
+// The user may take a while to respond, so the request waits until ctx is done, or for the default request timeout if
+// ctx has no deadline. If the user accepts, the content is checked against the schema and an *mcp.ElicitationValidationError
+// is returned if it does not match.
+func (s *Session) Elicit(ctx context.Context, message string, requestedSchema *mcp.JSONSchema) (*mcp.ElicitResult, error) {
+	if requestedSchema == nil {
+		return nil, errors.New("a requested schema is required for elicitation")
+	}
+	if err := mcp.ValidateElicitationSchema(*requestedSchema); err != nil {
+		return nil, err
+	}
+
+	var options *mcp.RequestOptions
+	if deadline, ok := ctx.Deadline(); ok {
+		options = &mcp.RequestOptions{Timeout: time.Until(deadline)}
+	}
+	params := mcp.ElicitRequestParams{Message: message, RequestedSchema: *requestedSchema}
+	result := &mcp.ElicitResult{}
+	if err := s.SendRequest(ctx, shared.ElicitationCreateMethod, params, result, options); err != nil {
+		return nil, err
+	}
+	if err := result.Validate(*requestedSchema); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Session) assertClientCapability(method jsonrpc.Method) error {
+	switch method {
+	case shared.SamplingCreateMessageMethod, shared.RootsListMethod, shared.ElicitationCreateMethod:
+		if s.server.clientCapabilities == nil {
+			return errors.New("the client has not been initialized")
+		}
+		return s.server.AssertCapabilityForMethod(method)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockSession connects a server with the tools capability to a MockTransport,
+// and initializes it with params parsed from JSON, as a remote client would send them.
+func newMockSession(t *testing.T, ctx context.Context, clientCapabilities map[string]any) (*Server, *jsonrpc.MockTransport) {
+	options := NewServerOptions()
+	options.Capabilities = mcp.ServerCapabilities{Tools: &mcp.ServerCapabilitiesTools{}}
+	server := NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+	transport := &jsonrpc.MockTransport{}
+	require.NoError(t, server.Connect(ctx, transport))
+
+	transport.ReceiveRequest(0, shared.InitializeMethod, map[string]any{
+		"protocolVersion": shared.LatestProtocolVersion,
+		"capabilities":    clientCapabilities,
+		"clientInfo":      map[string]any{"name": "test-client", "version": "1.0.0"},
+	})
+	_, err := transport.WaitForResponse(0, time.Second)
+	require.NoError(t, err)
+	return server, transport
+}
+
+func textResult(text string) mcp.CallToolResult {
+	return mcp.CallToolResult{Content: []mcp.Content{mcp.Text(text)}}
+}
+
+func TestRequestHandlerExtra(t *testing.T) {
+	t.Run("should pass the request details and client to tool handlers", func(t *testing.T) {
+		// given
+		ctx := jsonrpc.WithAuthInfo(context.Background(), &jsonrpc.AuthInfo{Token: "secret"})
+		server, transport := newMockSession(t, ctx, map[string]any{"roots": map[string]any{}})
+		extras := make(chan *RequestHandlerExtra, 1)
+		require.NoError(t, server.AddTool("whoami", "", mcp.ToolInputSchema{}, func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+			extras <- extra
+			return textResult(extra.ClientInfo.Name), nil
+		}))
+
+		// when
+		transport.Receive(&jsonrpc.JSONRPCRequest{
+			Jsonrpc: "2.0",
+			Id:      1,
+			Method:  string(shared.ToolsCallMethod),
+			Params: &jsonrpc.JSONRPCRequestParams{
+				Meta:                 &jsonrpc.JSONRPCRequestParamsMeta{"progressToken": "abc"},
+				AdditionalProperties: map[string]any{"name": "whoami"},
+			},
+		})
+
+		// then
+		response, err := transport.WaitForResponse(1, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "test-client", response.Result.AdditionalProperties.(mcp.CallToolResult).Content[0].(mcp.TextContent).Text)
+
+		extra := <-extras
+		assert.Equal(t, jsonrpc.RequestId(1), extra.RequestId)
+		assert.Equal(t, "abc", extra.Meta["progressToken"])
+		assert.Equal(t, "secret", extra.AuthInfo.Token)
+		assert.NotNil(t, extra.ClientCapabilities.Roots)
+		assert.Same(t, server.Session(), extra.Session)
+	})
+
+	t.Run("should make nested requests to the client", func(t *testing.T) {
+		// given
+		ctx := context.Background()
+		server, transport := newMockSession(t, ctx, map[string]any{"sampling": map[string]any{}})
+		transport.ExpectRequest(shared.SamplingCreateMessageMethod).Reply(map[string]any{
+			"model":   "test-model",
+			"role":    "assistant",
+			"content": map[string]any{"type": "text", "text": "a summary"},
+		})
+		require.NoError(t, server.AddTool("summarise", "", mcp.ToolInputSchema{}, func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+			if err := extra.Session.SendLoggingMessage(mcp.LoggingMessageNotificationParams{Level: mcp.LoggingLevelInfo, Data: "sampling"}); err != nil {
+				return mcp.CallToolResult{}, err
+			}
+			result, err := extra.Session.CreateMessage(ctx, mcp.CreateMessageRequestParams{MaxTokens: 100, Messages: []mcp.SamplingMessage{}}, nil)
+			if err != nil {
+				return mcp.CallToolResult{}, err
+			}
+			return textResult(result.Model), nil
+		}))
+
+		// when
+		transport.ReceiveRequest(1, shared.ToolsCallMethod, map[string]any{"name": "summarise"})
+
+		// then
+		response, err := transport.WaitForResponse(1, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "test-model", response.Result.AdditionalProperties.(mcp.CallToolResult).Content[0].(mcp.TextContent).Text)
+		_, err = transport.WaitForNotification(shared.LoggingMessageNotificationMethod, time.Second)
+		assert.NoError(t, err)
+		transport.AssertExpectations(t)
+	})
+
+	t.Run("should not make requests which the client does not support", func(t *testing.T) {
+		// given
+		ctx := context.Background()
+		server, _ := newMockSession(t, ctx, map[string]any{})
+
+		// when
+		_, err := server.Session().CreateMessage(ctx, mcp.CreateMessageRequestParams{}, nil)
+
+		// then
+		assert.ErrorContains(t, err, "sampling")
+	})
+
+	t.Run("should cancel the handler's context when the client cancels the request", func(t *testing.T) {
+		// given
+		ctx := context.Background()
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		started := make(chan struct{})
+		cancelled := make(chan error, 1)
+		require.NoError(t, server.AddTool("wait", "", mcp.ToolInputSchema{}, func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+			close(started)
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return mcp.CallToolResult{}, ctx.Err()
+		}))
+		transport.ReceiveRequest(1, shared.ToolsCallMethod, map[string]any{"name": "wait"})
+		<-started
+
+		// when
+		transport.ReceiveNotification(shared.NotificationsCancelledMethod, mcp.CancelledNotificationParams{RequestId: 1})
+
+		// then
+		select {
+		case err := <-cancelled:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("the handler was not cancelled")
+		}
+		_, err := transport.WaitForResponse(1, 50*time.Millisecond)
+		assert.ErrorContains(t, err, "timed out", "no response should be sent for a cancelled request")
+	})
+}
+
+func TestElicit(t *testing.T) {
+	ctx := context.Background()
+	schema := mcp.NewObjectSchema().
+		WithProperty("path", mcp.NewStringSchema(), true).
+		WithProperty("confirm", mcp.NewBooleanSchema(), true)
+
+	t.Run("should return the content accepted by the user", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{"elicitation": map[string]any{}})
+		transport.ExpectRequest(shared.ElicitationCreateMethod).Reply(map[string]any{
+			"action":  "accept",
+			"content": map[string]any{"path": "/tmp", "confirm": true},
+		})
+
+		// when
+		result, err := server.Elicit(ctx, "Delete all files in /tmp?", schema)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, result.IsAccepted())
+		assert.Equal(t, map[string]interface{}{"path": "/tmp", "confirm": true}, result.Content)
+		request, err := transport.WaitForRequest(shared.ElicitationCreateMethod, time.Second)
+		require.NoError(t, err)
+		params := request.Params.AdditionalProperties.(mcp.ElicitRequestParams)
+		assert.Equal(t, "Delete all files in /tmp?", params.Message)
+		assert.Equal(t, *schema, params.RequestedSchema)
+	})
+
+	t.Run("should return a declined result without content", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{"elicitation": map[string]any{}})
+		transport.ExpectRequest(shared.ElicitationCreateMethod).Reply(map[string]any{"action": "decline"})
+
+		// when
+		result, err := server.Elicit(ctx, "Delete all files in /tmp?", schema)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, mcp.ElicitActionDecline, result.Action)
+		assert.False(t, result.IsAccepted())
+	})
+
+	t.Run("should reject content which does not match the schema", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{"elicitation": map[string]any{}})
+		transport.ExpectRequest(shared.ElicitationCreateMethod).Reply(map[string]any{
+			"action":  "accept",
+			"content": map[string]any{"path": "/tmp", "confirm": "yes"},
+		})
+
+		// when
+		_, err := server.Elicit(ctx, "Delete all files in /tmp?", schema)
+
+		// then
+		var validationErr *mcp.ElicitationValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("should not send a schema which is not allowed for elicitation", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{"elicitation": map[string]any{}})
+
+		// when
+		_, err := server.Elicit(ctx, "Where do you live?", mcp.NewObjectSchema().WithProperty("address", mcp.NewObjectSchema(), true))
+
+		// then
+		assert.ErrorContains(t, err, "nested schemas are not allowed")
+		_, err = transport.WaitForRequest(shared.ElicitationCreateMethod, 50*time.Millisecond)
+		assert.Error(t, err)
+	})
+
+	t.Run("should not send a request without a schema", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{"elicitation": map[string]any{}})
+
+		// when
+		_, serverErr := server.Elicit(ctx, "Delete all files in /tmp?", nil)
+		_, sessionErr := server.Session().Elicit(ctx, "Delete all files in /tmp?", nil)
+
+		// then
+		assert.ErrorContains(t, serverErr, "a requested schema is required")
+		assert.ErrorContains(t, sessionErr, "a requested schema is required")
+		_, err := transport.WaitForRequest(shared.ElicitationCreateMethod, 50*time.Millisecond)
+		assert.Error(t, err)
+	})
+
+	t.Run("should not ask clients which do not support elicitation", func(t *testing.T) {
+		// given
+		server, _ := newMockSession(t, ctx, map[string]any{})
+
+		// when
+		_, err := server.Elicit(ctx, "Delete all files in /tmp?", schema)
+
+		// then
+		assert.ErrorContains(t, err, "client does not support elicitation")
+	})
+}
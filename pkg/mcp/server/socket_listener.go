@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	jsonrpcserver "github.com/nalbion/go-mcp/pkg/jsonrpc/server"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// SocketListener accepts connections on a TCP or Unix domain socket and serves each connection with its own Server,
+// so that every client has an independent MCP session.
+type SocketListener struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	listener  net.Listener
+	newServer func(ctx context.Context) *Server
+	sessions  map[*SocketSession]struct{}
+	job       sync.WaitGroup
+	mu        sync.Mutex
+}
+
+// A SocketSession is a single client connection to a SocketListener.
+type SocketSession struct {
+	Server    *Server
+	Transport *jsonrpcserver.SocketServerTransport
+}
+
+// PeerCredentials returns the credentials of the connected process, or nil if they are not available.
+func (s *SocketSession) PeerCredentials() *jsonrpcserver.PeerCredentials {
+	return s.Transport.PeerCredentials()
+}
+
+// Close disconnects this client without affecting other sessions.
+func (s *SocketSession) Close() error {
+	return s.Transport.Close()
+}
+
+// NewSocketListener serves connections from `listener` once Serve() is called.
+// `newServer` is called for each connection to create a Server with its tools, prompts and resources.
+// The context it receives is cancelled when the connection is closed, and carries the peer credentials
+// of Unix domain socket clients, which handlers can retrieve with jsonrpc/server.PeerCredentialsFromContext().
+func NewSocketListener(ctx context.Context, listener net.Listener, newServer func(ctx context.Context) *Server) *SocketListener {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &SocketListener{
+		ctx:       ctx,
+		cancel:    cancel,
+		listener:  listener,
+		newServer: newServer,
+		sessions:  make(map[*SocketSession]struct{}),
+	}
+}
+
+// ListenSocket listens on a TCP address, eg: ("tcp", "localhost:8080"), or a Unix domain socket, eg: ("unix", "/run/mcp.sock").
+func ListenSocket(ctx context.Context, network string, address string, newServer func(ctx context.Context) *Server) (*SocketListener, error) {
+	listener, err := (&net.ListenConfig{}).Listen(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewSocketListener(ctx, listener, newServer), nil
+}
+
+func (l *SocketListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// Serve accepts connections until the listener is closed. It returns nil after Close() is called.
+// Each connection is served in its own goroutine, so a slow client does not delay the others.
+func (l *SocketListener) Serve() error {
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if l.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		l.mu.Lock()
+		if l.ctx.Err() != nil {
+			l.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		l.job.Add(1)
+		l.mu.Unlock()
+
+		go func() {
+			defer l.job.Done()
+			if err := l.serveConn(conn); err != nil {
+				conn.Close()
+				if l.ctx.Err() == nil {
+					shared.DefaultLogger.Error("failed to start MCP session for %v: %v", conn.RemoteAddr(), err)
+				}
+			}
+		}()
+	}
+}
+
+func (l *SocketListener) serveConn(conn net.Conn) error {
+	ctx, cancel := context.WithCancel(l.ctx)
+	transport := jsonrpcserver.NewSocketServerTransport(ctx, conn)
+	if creds := transport.PeerCredentials(); creds != nil {
+		ctx = jsonrpcserver.WithPeerCredentials(ctx, creds)
+	}
+
+	session := &SocketSession{
+		Server:    l.newServer(ctx),
+		Transport: transport,
+	}
+
+	l.mu.Lock()
+	if l.ctx.Err() != nil {
+		l.mu.Unlock()
+		cancel()
+		return l.ctx.Err()
+	}
+	l.sessions[session] = struct{}{}
+	l.job.Add(1)
+	l.mu.Unlock()
+
+	go func() {
+		defer l.job.Done()
+		<-transport.Done()
+		cancel()
+
+		l.mu.Lock()
+		delete(l.sessions, session)
+		l.mu.Unlock()
+	}()
+
+	if err := session.Server.Connect(ctx, transport); err != nil {
+		transport.Close()
+		return err
+	}
+	return nil
+}
+
+// Sessions returns the currently connected sessions.
+func (l *SocketListener) Sessions() []*SocketSession {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sessions := make([]*SocketSession, 0, len(l.sessions))
+	for session := range l.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// Close stops accepting connections, closes all sessions and waits for them to finish.
+func (l *SocketListener) Close() error {
+	l.mu.Lock()
+	l.cancel()
+	sessions := make([]*SocketSession, 0, len(l.sessions))
+	for session := range l.sessions {
+		sessions = append(sessions, session)
+	}
+	l.mu.Unlock()
+
+	err := l.listener.Close()
+	for _, session := range sessions {
+		session.Close()
+	}
+	l.job.Wait()
+	return err
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	jsonrpcclient "github.com/nalbion/go-mcp/pkg/jsonrpc/client"
+	jsonrpcserver "github.com/nalbion/go-mcp/pkg/jsonrpc/server"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocketListener(t *testing.T) {
+	// given a listener on a Unix domain socket, which creates a server for each connection
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "mcp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var serversCreated atomic.Int32
+	listener, err := ListenSocket(ctx, "unix", filepath.Join(dir, "mcp.sock"), func(ctx context.Context) *Server {
+		serversCreated.Add(1)
+		options := NewServerOptions()
+		server := NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+		server.SetRequestHandler("whoami", func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+			pid := int32(0)
+			if creds, ok := jsonrpcserver.PeerCredentialsFromContext(ctx); ok {
+				pid = creds.Pid
+			}
+			return jsonrpc.Result{AdditionalProperties: map[string]any{"pid": pid}}, nil
+		})
+		return server
+	})
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- listener.Serve()
+	}()
+
+	connect := func() (*jsonrpc.Protocol, chan struct{}) {
+		transport := jsonrpcclient.NewSocketClientTransport(ctx, "unix", listener.Addr().String())
+		protocol := jsonrpc.NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, transport))
+		closed := make(chan struct{})
+		transport.SetOnClose(func() { close(closed) })
+		return protocol, closed
+	}
+
+	// when two clients connect
+	first, firstClosed := connect()
+	second, secondClosed := connect()
+
+	// then each client has its own session
+	for _, protocol := range []*jsonrpc.Protocol{first, second} {
+		result := &jsonrpc.Result{AdditionalProperties: &map[string]any{}}
+		require.NoError(t, protocol.SendRequest(ctx, "whoami", nil, result))
+		if runtime.GOOS == "linux" {
+			assert.Equal(t, float64(os.Getpid()), (*result.AdditionalProperties.(*map[string]any))["pid"])
+		}
+	}
+	assert.Len(t, listener.Sessions(), 2)
+	assert.Equal(t, int32(2), serversCreated.Load())
+
+	// when one session is closed by the server
+	sessions := listener.Sessions()
+	require.NoError(t, sessions[0].Close())
+
+	// then only that client is disconnected
+	select {
+	case <-firstClosed:
+	case <-secondClosed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for client to be disconnected")
+	}
+	assert.Eventually(t, func() bool { return len(listener.Sessions()) == 1 }, time.Second, 10*time.Millisecond)
+
+	// when the listener is closed
+	require.NoError(t, listener.Close())
+
+	// then all sessions are closed and Serve() returns
+	assert.Empty(t, listener.Sessions())
+	select {
+	case <-firstClosed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for first client to be disconnected")
+	}
+	select {
+	case <-secondClosed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for second client to be disconnected")
+	}
+	assert.NoError(t, <-served)
+}
+
+func TestSocketListenerServesConnectionsConcurrently(t *testing.T) {
+	// given a listener whose first server takes a long time to create
+	ctx := context.Background()
+	release := make(chan struct{})
+	var connections atomic.Int32
+	listener, err := ListenSocket(ctx, "tcp", "127.0.0.1:0", func(ctx context.Context) *Server {
+		if connections.Add(1) == 1 {
+			<-release
+		}
+		options := NewServerOptions()
+		server := NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+		server.SetRequestHandler("hello", func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+			return jsonrpc.Result{}, nil
+		})
+		return server
+	})
+	require.NoError(t, err)
+	defer listener.Close()
+	defer close(release)
+	go listener.Serve()
+
+	connect := func() *jsonrpc.Protocol {
+		transport := jsonrpcclient.NewSocketClientTransport(ctx, "tcp", listener.Addr().String())
+		protocol := jsonrpc.NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, transport))
+		return protocol
+	}
+
+	// when a second client connects while the first session is still starting
+	connect()
+	require.Eventually(t, func() bool { return connections.Load() == 1 }, time.Second, time.Millisecond)
+	second := connect()
+
+	// then the second client is served
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	require.NoError(t, second.SendRequest(ctx, "hello", nil, nil))
+}
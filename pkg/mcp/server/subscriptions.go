@@ -0,0 +1,149 @@
+package server
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// ResourceSubscriptions tracks which sessions have subscribed to which resources with resources/subscribe,
+// so that notifications/resources/updated is only sent to the sessions which asked for it.
+//
+// Each Server has its own by default. A WebSocketHandler or SocketListener creates a Server for each connection,
+// so share one ResourceSubscriptions between them with ServerOptions.ResourceSubscriptions, and NotifyResourceUpdated()
+// will reach every subscribed client.
+type ResourceSubscriptions struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*Session]struct{}
+
+	onFirstSubscriber  func(uri string)
+	onLastUnsubscribed func(uri string)
+}
+
+func NewResourceSubscriptions() *ResourceSubscriptions {
+	return &ResourceSubscriptions{
+		subscribers: make(map[string]map[*Session]struct{}),
+	}
+}
+
+// OnFirstSubscriber sets a callback for when a resource gets its first subscriber, eg: to start watching it for changes.
+func (r *ResourceSubscriptions) OnFirstSubscriber(handler func(uri string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onFirstSubscriber = handler
+}
+
+// OnLastUnsubscribed sets a callback for when the last subscriber of a resource unsubscribes or disconnects,
+// eg: to stop watching it for changes.
+func (r *ResourceSubscriptions) OnLastUnsubscribed(handler func(uri string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onLastUnsubscribed = handler
+}
+
+// IsSubscribed returns true if any session is subscribed to the resource.
+func (r *ResourceSubscriptions) IsSubscribed(uri string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.subscribers[uri]) > 0
+}
+
+// Uris returns the URIs of the resources with at least one subscriber, in lexical order.
+func (r *ResourceSubscriptions) Uris() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	uris := make([]string, 0, len(r.subscribers))
+	for uri := range r.subscribers {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+// NotifyResourceUpdated sends notifications/resources/updated to each session subscribed to the resource.
+// Sessions which are not subscribed are not notified, and nothing is sent if there are no subscribers.
+func (r *ResourceSubscriptions) NotifyResourceUpdated(uri string) error {
+	r.mu.Lock()
+	sessions := make([]*Session, 0, len(r.subscribers[uri]))
+	for session := range r.subscribers[uri] {
+		sessions = append(sessions, session)
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, session := range sessions {
+		if err := session.SendNotification(shared.ResourceUpdatedNotificationMethod, mcp.ResourceUpdatedNotificationParams{Uri: uri}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *ResourceSubscriptions) subscribe(session *Session, uri string) {
+	r.mu.Lock()
+	sessions, ok := r.subscribers[uri]
+	if !ok {
+		sessions = make(map[*Session]struct{})
+		r.subscribers[uri] = sessions
+	}
+	sessions[session] = struct{}{}
+	onFirstSubscriber := r.onFirstSubscriber
+	r.mu.Unlock()
+
+	// the callbacks are called without holding the lock, so that they may use the ResourceSubscriptions
+	if !ok && onFirstSubscriber != nil {
+		onFirstSubscriber(uri)
+	}
+}
+
+func (r *ResourceSubscriptions) unsubscribe(session *Session, uri string) {
+	r.mu.Lock()
+	unsubscribed := r.remove(session, uri)
+	onLastUnsubscribed := r.onLastUnsubscribed
+	r.mu.Unlock()
+
+	if unsubscribed && onLastUnsubscribed != nil {
+		onLastUnsubscribed(uri)
+	}
+}
+
+// removeSession removes all the subscriptions of a session which has closed.
+func (r *ResourceSubscriptions) removeSession(session *Session) {
+	r.mu.Lock()
+	var unsubscribed []string
+	for uri := range r.subscribers {
+		if r.remove(session, uri) {
+			unsubscribed = append(unsubscribed, uri)
+		}
+	}
+	onLastUnsubscribed := r.onLastUnsubscribed
+	r.mu.Unlock()
+
+	if onLastUnsubscribed != nil {
+		sort.Strings(unsubscribed)
+		for _, uri := range unsubscribed {
+			onLastUnsubscribed(uri)
+		}
+	}
+}
+
+// remove returns true if the session was the last subscriber to the resource. The caller must hold the lock.
+func (r *ResourceSubscriptions) remove(session *Session, uri string) bool {
+	sessions, ok := r.subscribers[uri]
+	if !ok {
+		return false
+	}
+	if _, ok := sessions[session]; !ok {
+		return false
+	}
+	delete(sessions, session)
+	if len(sessions) > 0 {
+		return false
+	}
+	delete(r.subscribers, uri)
+	return true
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	readResource := func(ctx context.Context, params mcp.ReadResourceRequestParams, extra *RequestHandlerExtra) mcp.ReadResourceResult {
+		return mcp.ReadResourceResult{}
+	}
+	readTemplate := func(ctx context.Context, params mcp.ReadResourceRequestParams, variables mcp.UriTemplateVariables, extra *RequestHandlerExtra) mcp.ReadResourceResult {
+		return mcp.ReadResourceResult{}
+	}
+
+	// newSubscribingSession connects a server which supports resource subscriptions to a MockTransport
+	newSubscribingSession := func(t *testing.T, subscriptions *ResourceSubscriptions) (*Server, *jsonrpc.MockTransport) {
+		subscribe := true
+		options := NewServerOptions()
+		options.Capabilities = mcp.ServerCapabilities{Resources: &mcp.ServerCapabilitiesResources{Subscribe: &subscribe}}
+		options.ResourceSubscriptions = subscriptions
+		server := NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+		require.NoError(t, server.AddResource("file:///config.json", "Config", "", "application/json", readResource))
+		require.NoError(t, server.AddResourceTemplate("users://{id}/profile", "User profile", "", "text/plain", readTemplate))
+
+		transport := &jsonrpc.MockTransport{}
+		require.NoError(t, server.Connect(ctx, transport))
+		return server, transport
+	}
+
+	subscribe := func(t *testing.T, transport *jsonrpc.MockTransport, id int, method jsonrpc.Method, uri string) error {
+		transport.ReceiveRequest(jsonrpc.RequestId(id), method, map[string]any{"uri": uri})
+		_, err := transport.WaitForResponse(jsonrpc.RequestId(id), time.Second)
+		return err
+	}
+
+	t.Run("should only notify sessions subscribed to the resource", func(t *testing.T) {
+		// given
+		subscriptions := NewResourceSubscriptions()
+		server, subscriber := newSubscribingSession(t, subscriptions)
+		_, other := newSubscribingSession(t, subscriptions)
+		require.NoError(t, subscribe(t, subscriber, 1, shared.ResourcesSubscribeMethod, "file:///config.json"))
+		require.NoError(t, subscribe(t, other, 1, shared.ResourcesSubscribeMethod, "users://alice/profile"))
+
+		// when
+		err := server.NotifyResourceUpdated("file:///config.json")
+
+		// then
+		require.NoError(t, err)
+		notification, err := subscriber.WaitForNotification(shared.ResourceUpdatedNotificationMethod, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, mcp.ResourceUpdatedNotificationParams{Uri: "file:///config.json"}, notification.Params.AdditionalProperties)
+		assert.Empty(t, other.SentNotifications)
+	})
+
+	t.Run("should notify sessions subscribed to a URI which matches a template", func(t *testing.T) {
+		// given
+		server, transport := newSubscribingSession(t, nil)
+		require.NoError(t, subscribe(t, transport, 1, shared.ResourcesSubscribeMethod, "users://alice/profile"))
+
+		// when
+		require.NoError(t, server.NotifyResourceUpdated("users://bob/profile"))
+		require.NoError(t, server.NotifyResourceUpdated("users://alice/profile"))
+
+		// then
+		notification, err := transport.WaitForNotification(shared.ResourceUpdatedNotificationMethod, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, mcp.ResourceUpdatedNotificationParams{Uri: "users://alice/profile"}, notification.Params.AdditionalProperties)
+		assert.Len(t, transport.SentNotifications, 1)
+	})
+
+	t.Run("should reject subscriptions to unknown resources", func(t *testing.T) {
+		// given
+		server, transport := newSubscribingSession(t, nil)
+
+		// when
+		err := subscribe(t, transport, 1, shared.ResourcesSubscribeMethod, "file:///unknown.json")
+
+		// then
+		assert.ErrorContains(t, err, "Resource not found")
+		assert.False(t, server.ResourceSubscriptions().IsSubscribed("file:///unknown.json"))
+	})
+
+	t.Run("should stop notifying after unsubscribing", func(t *testing.T) {
+		// given
+		server, transport := newSubscribingSession(t, nil)
+		require.NoError(t, subscribe(t, transport, 1, shared.ResourcesSubscribeMethod, "file:///config.json"))
+
+		// when
+		require.NoError(t, subscribe(t, transport, 2, shared.ResourcesUnsubscribeMethod, "file:///config.json"))
+		require.NoError(t, server.NotifyResourceUpdated("file:///config.json"))
+
+		// then
+		assert.Empty(t, transport.SentNotifications)
+		assert.False(t, server.ResourceSubscriptions().IsSubscribed("file:///config.json"))
+	})
+
+	t.Run("should call the hooks for the first and last subscribers", func(t *testing.T) {
+		// given
+		var mu sync.Mutex
+		var events []string
+		subscriptions := NewResourceSubscriptions()
+		subscriptions.OnFirstSubscriber(func(uri string) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "watch "+uri)
+		})
+		subscriptions.OnLastUnsubscribed(func(uri string) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "unwatch "+uri)
+		})
+		_, first := newSubscribingSession(t, subscriptions)
+		_, second := newSubscribingSession(t, subscriptions)
+
+		// when
+		require.NoError(t, subscribe(t, first, 1, shared.ResourcesSubscribeMethod, "file:///config.json"))
+		require.NoError(t, subscribe(t, second, 1, shared.ResourcesSubscribeMethod, "file:///config.json"))
+		require.NoError(t, subscribe(t, first, 2, shared.ResourcesUnsubscribeMethod, "file:///config.json"))
+		require.NoError(t, subscribe(t, second, 2, shared.ResourcesUnsubscribeMethod, "file:///config.json"))
+
+		// then
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, []string{"watch file:///config.json", "unwatch file:///config.json"}, events)
+	})
+
+	t.Run("should remove the subscriptions of a session when it closes", func(t *testing.T) {
+		// given
+		subscriptions := NewResourceSubscriptions()
+		var unwatched []string
+		subscriptions.OnLastUnsubscribed(func(uri string) { unwatched = append(unwatched, uri) })
+		server, transport := newSubscribingSession(t, subscriptions)
+		closed := false
+		server.OnClose(func() { closed = true })
+		require.NoError(t, subscribe(t, transport, 1, shared.ResourcesSubscribeMethod, "file:///config.json"))
+		require.NoError(t, subscribe(t, transport, 2, shared.ResourcesSubscribeMethod, "users://alice/profile"))
+
+		// when
+		transport.Disconnect()
+
+		// then
+		assert.True(t, closed)
+		assert.Empty(t, subscriptions.Uris())
+		assert.Equal(t, []string{"file:///config.json", "users://alice/profile"}, unwatched)
+	})
+
+	t.Run("should not handle subscriptions unless the capability is enabled", func(t *testing.T) {
+		// given
+		options := NewServerOptions()
+		options.Capabilities = mcp.ServerCapabilities{Resources: &mcp.ServerCapabilitiesResources{}}
+		server := NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+		transport := &jsonrpc.MockTransport{}
+		require.NoError(t, server.Connect(ctx, transport))
+
+		// when
+		err := subscribe(t, transport, 1, shared.ResourcesSubscribeMethod, "file:///config.json")
+
+		// then
+		assert.ErrorContains(t, err, "Method not found")
+	})
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+)
+
+type requestHandlerExtraKey struct{}
+
+// RequestHandlerExtraFromContext returns the RequestHandlerExtra of the request being handled,
+// for handlers which are only passed a context, eg: those registered with AddTypedTool().
+func RequestHandlerExtraFromContext(ctx context.Context) (*RequestHandlerExtra, bool) {
+	extra, ok := ctx.Value(requestHandlerExtraKey{}).(*RequestHandlerExtra)
+	return extra, ok && extra != nil
+}
+
+func withRequestHandlerExtra(ctx context.Context, extra *RequestHandlerExtra) context.Context {
+	return context.WithValue(ctx, requestHandlerExtraKey{}, extra)
+}
+
+// AddTypedTool registers a tool whose input schema is generated from the struct In, see InputSchemaFor().
+// The arguments are validated against the schema, with defaults filled in, and decoded into In before the handler is called.
+// Invalid arguments are rejected with an InvalidParams error listing each problem.
+//
+// The handler's result is converted to a CallToolResult:
+// a CallToolResult is returned as is, a string becomes text content, and any other value is returned as JSON text,
+// and as structuredContent if it is a JSON object. If Out is a struct, the tool's OutputSchema is generated from it.
+// Annotations are optional, and at most one may be given, as for Server.AddTool().
+func AddTypedTool[In any, Out any](s *Server, name string, description string, handler func(ctx context.Context, in In) (Out, error), annotations ...*mcp.ToolAnnotations) error {
+	inputSchema, err := InputSchemaFor[In]()
+	if err != nil {
+		return fmt.Errorf("failed to generate the input schema for tool %s: %w", name, err)
+	}
+	var outputSchema *mcp.ToolOutputSchema
+	if outputType := reflect.TypeFor[Out](); isStructuredOutput(outputType) {
+		if outputSchema, err = OutputSchemaFor[Out](); err != nil {
+			return fmt.Errorf("failed to generate the output schema for tool %s: %w", name, err)
+		}
+	}
+
+	if s.capabilities.Tools == nil {
+		return errors.New("Server does not support tools capability. Enable it in ServerOptions.")
+	}
+	toolAnnotations, err := optionalAnnotations(name, annotations)
+	if err != nil {
+		return err
+	}
+	tool := mcp.Tool{Name: name, Description: description, InputSchema: inputSchema, OutputSchema: outputSchema, Annotations: toolAnnotations}
+	return s.AddTools([]RegisteredTool{{Tool: tool, Handler: func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+		// the arguments have already been prepared if ServerOptions.ValidateToolArguments is set, but In must be valid regardless
+		object, validationErrors := PrepareArguments(inputSchema, params.Arguments, s.options.CoerceToolArguments)
+		if len(validationErrors) > 0 {
+			return mcp.CallToolResult{}, invalidArgumentsError(extra.RequestId, name, validationErrors)
+		}
+
+		var in In
+		content, err := json.Marshal(object)
+		if err != nil {
+			return mcp.CallToolResult{}, err
+		}
+		if err := json.Unmarshal(content, &in); err != nil {
+			return mcp.CallToolResult{}, jsonrpc.NewJSONRPCErrorError(extra.RequestId, jsonrpc.InvalidParams, fmt.Sprintf("Invalid arguments for tool %s: %v", name, err), nil)
+		}
+
+		out, err := handler(withRequestHandlerExtra(ctx, extra), in)
+		if err != nil {
+			return mcp.CallToolResult{}, err
+		}
+		return toCallToolResult(out)
+	}}})
+}
+
+// isStructuredOutput reports whether a tool's output type is a struct which is returned as structuredContent.
+func isStructuredOutput(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != reflect.TypeFor[mcp.CallToolResult]()
+}
+
+func invalidArgumentsError(requestId jsonrpc.RequestId, tool string, validationErrors []ValidationError) *jsonrpc.JSONRPCErrorError {
+	messages := make([]string, len(validationErrors))
+	for i, err := range validationErrors {
+		messages[i] = err.String()
+	}
+	return jsonrpc.NewJSONRPCErrorError(requestId, jsonrpc.InvalidParams,
+		fmt.Sprintf("Invalid arguments for tool %s: %s", tool, strings.Join(messages, "; ")),
+		map[string]any{"errors": validationErrors})
+}
+
+func toCallToolResult(out any) (mcp.CallToolResult, error) {
+	switch out := out.(type) {
+	case mcp.CallToolResult:
+		return out, nil
+	case *mcp.CallToolResult:
+		if out != nil {
+			return *out, nil
+		}
+		return mcp.CallToolResult{Content: []mcp.Content{}}, nil
+	case string:
+		return mcp.CallToolResult{Content: []mcp.Content{mcp.Text(out)}}, nil
+	}
+
+	content, err := json.Marshal(out)
+	if err != nil {
+		return mcp.CallToolResult{}, fmt.Errorf("failed to marshal the tool result: %w", err)
+	}
+	result := mcp.CallToolResult{Content: []mcp.Content{mcp.Text(string(content))}}
+	// the text content is kept for clients which do not support structuredContent
+	var structuredContent map[string]any
+	if json.Unmarshal(content, &structuredContent) == nil {
+		result.StructuredContent = structuredContent
+	}
+	return result, nil
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type weatherInput struct {
+	City string `json:"city" jsonschema:"description=Name of the city"`
+	Unit string `json:"unit,omitempty" jsonschema:"enum=celsius,enum=fahrenheit"`
+}
+
+type weatherOutput struct {
+	Temperature float64 `json:"temperature"`
+	Unit        string  `json:"unit"`
+}
+
+func TestAddTypedTool(t *testing.T) {
+	ctx := context.Background()
+
+	callTool := func(t *testing.T, transport *jsonrpc.MockTransport, arguments map[string]any) (*jsonrpc.JSONRPCResponse, error) {
+		transport.ReceiveRequest(1, shared.ToolsCallMethod, map[string]any{"name": "weather", "arguments": arguments})
+		return transport.WaitForResponse(1, time.Second)
+	}
+
+	t.Run("should decode the arguments and return the result as JSON", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		require.NoError(t, AddTypedTool(server, "weather", "Get the weather", func(ctx context.Context, in weatherInput) (weatherOutput, error) {
+			_, ok := RequestHandlerExtraFromContext(ctx)
+			assert.True(t, ok)
+			return weatherOutput{Temperature: 21.5, Unit: in.Unit}, nil
+		}))
+
+		// when
+		response, err := callTool(t, transport, map[string]any{"city": "Sydney", "unit": "celsius"})
+
+		// then
+		require.NoError(t, err)
+		result := response.Result.AdditionalProperties.(mcp.CallToolResult)
+		assert.Equal(t, `{"temperature":21.5,"unit":"celsius"}`, result.Content[0].(mcp.TextContent).Text)
+		assert.Equal(t, map[string]any{"temperature": 21.5, "unit": "celsius"}, result.StructuredContent)
+		assert.Equal(t, []string{"city"}, server.tools["weather"].Tool.InputSchema.Required)
+		assert.Equal(t, []string{"temperature", "unit"}, server.tools["weather"].Tool.OutputSchema.Required)
+	})
+
+	t.Run("should reject invalid arguments without calling the handler", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		called := false
+		require.NoError(t, AddTypedTool(server, "weather", "Get the weather", func(ctx context.Context, in weatherInput) (string, error) {
+			called = true
+			return "sunny", nil
+		}))
+
+		// when
+		_, err := callTool(t, transport, map[string]any{"unit": "kelvin"})
+
+		// then
+		var jsonrpcErr *jsonrpc.JSONRPCErrorError
+		require.ErrorAs(t, err, &jsonrpcErr)
+		assert.Equal(t, int(jsonrpc.InvalidParams), jsonrpcErr.Code)
+		assert.Equal(t, `Invalid arguments for tool weather: city: is required; unit: must be one of ["celsius","fahrenheit"]`, jsonrpcErr.Message)
+		assert.False(t, called)
+	})
+
+	t.Run("should return errors from the handler", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		require.NoError(t, AddTypedTool(server, "weather", "Get the weather", func(ctx context.Context, in weatherInput) (*mcp.CallToolResult, error) {
+			return nil, errors.New("weather service unavailable")
+		}))
+
+		// when
+		_, err := callTool(t, transport, map[string]any{"city": "Sydney"})
+
+		// then
+		assert.ErrorContains(t, err, "weather service unavailable")
+	})
+
+	t.Run("should reject structured content which does not match the output schema", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		require.NoError(t, server.AddTools([]RegisteredTool{{
+			Tool: mcp.Tool{
+				Name:         "weather",
+				InputSchema:  mcp.ToolInputSchema{Type: "object"},
+				OutputSchema: mcp.NewObjectSchema().WithProperty("temperature", mcp.NewNumberSchema(), true),
+			},
+			Handler: func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+				return mcp.CallToolResult{Content: []mcp.Content{}, StructuredContent: map[string]any{"temperature": "hot"}}, nil
+			},
+		}}))
+
+		// when
+		transport.ReceiveRequest(1, shared.ToolsCallMethod, map[string]any{"name": "weather"})
+
+		// then
+		_, err := transport.WaitForResponse(1, time.Second)
+		var jsonrpcErr *jsonrpc.JSONRPCErrorError
+		require.ErrorAs(t, err, &jsonrpcErr)
+		assert.Equal(t, int(jsonrpc.InternalError), jsonrpcErr.Code)
+		assert.Equal(t, "invalid structured content from tool weather: temperature: must be of type number", jsonrpcErr.Message)
+	})
+
+	t.Run("should list the annotations of tools", func(t *testing.T) {
+		// given
+		server, transport := newMockSession(t, ctx, map[string]any{})
+		require.NoError(t, AddTypedTool(server, "weather", "Get the weather", func(ctx context.Context, in weatherInput) (string, error) {
+			return "sunny", nil
+		}, mcp.NewToolAnnotations().WithTitle("Weather").WithReadOnlyHint(true)))
+		require.NoError(t, server.AddTool("reset", "", mcp.ToolInputSchema{}, func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+			return textResult("done"), nil
+		}))
+
+		// when
+		transport.ReceiveRequest(1, shared.ToolsListMethod, nil)
+
+		// then
+		response, err := transport.WaitForResponse(1, time.Second)
+		require.NoError(t, err)
+		tools := map[string]mcp.Tool{}
+		for _, tool := range response.Result.AdditionalProperties.(mcp.ListToolsResult).Tools {
+			tools[tool.Name] = tool
+		}
+		assert.Equal(t, "Weather", tools["weather"].DisplayTitle())
+		assert.True(t, tools["weather"].IsReadOnly())
+		assert.Nil(t, tools["reset"].Annotations)
+	})
+
+	t.Run("should reject more than one set of annotations", func(t *testing.T) {
+		// given
+		server, _ := newMockSession(t, ctx, map[string]any{})
+		readOnly := mcp.NewToolAnnotations().WithReadOnlyHint(true)
+		titled := mcp.NewToolAnnotations().WithTitle("Weather")
+
+		// when
+		typedErr := AddTypedTool(server, "weather", "Get the weather", func(ctx context.Context, in weatherInput) (string, error) {
+			return "sunny", nil
+		}, readOnly, titled)
+		err := server.AddTool("reset", "", mcp.ToolInputSchema{}, func(ctx context.Context, params mcp.CallToolRequestParams, extra *RequestHandlerExtra) (mcp.CallToolResult, error) {
+			return textResult("done"), nil
+		}, readOnly, titled)
+
+		// then
+		assert.EqualError(t, typedErr, "tool weather has 2 annotations, at most one may be given")
+		assert.EqualError(t, err, "tool reset has 2 annotations, at most one may be given")
+		_, registered := server.registeredTool("weather")
+		assert.False(t, registered)
+	})
+}
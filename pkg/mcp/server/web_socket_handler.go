@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	jsonrpcserver "github.com/nalbion/go-mcp/pkg/jsonrpc/server"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+)
+
+// WebSocketHandler is an http.Handler which upgrades each request to a WebSocket connection using the `mcp`
+// subprotocol, and serves each connection with its own Server so that every client has an independent MCP session.
+type WebSocketHandler struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	newServer func(ctx context.Context, r *http.Request) *Server
+	options   shared.WebSocketOptions
+	// Upgrader may be replaced to check the Origin header of browser clients, set buffer sizes etc.
+	Upgrader *websocket.Upgrader
+	sessions map[*WebSocketSession]struct{}
+	job      sync.WaitGroup
+	mu       sync.Mutex
+}
+
+// A WebSocketSession is a single client connection to a WebSocketHandler.
+type WebSocketSession struct {
+	Server    *Server
+	Transport *jsonrpcserver.WebSocketServerTransport
+}
+
+// Close disconnects this client with status 1000 (normal closure) without affecting other sessions.
+func (s *WebSocketSession) Close() error {
+	return s.Transport.Close()
+}
+
+// NewWebSocketHandler creates a handler which calls `newServer` for each connection to create a Server
+// with its tools, prompts and resources. The context it receives is cancelled when the connection is closed,
+// and the request may be used to authorize the client.
+func NewWebSocketHandler(ctx context.Context, options shared.WebSocketOptions, newServer func(ctx context.Context, r *http.Request) *Server) *WebSocketHandler {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &WebSocketHandler{
+		ctx:       ctx,
+		cancel:    cancel,
+		newServer: newServer,
+		options:   options,
+		Upgrader:  &websocket.Upgrader{},
+		sessions:  make(map[*WebSocketSession]struct{}),
+	}
+}
+
+func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.ctx.Err() != nil {
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		// the token is not verified here, newServer or the request handlers must check it
+		ctx = jsonrpc.WithAuthInfo(ctx, &jsonrpc.AuthInfo{Token: token})
+	}
+	transport, err := jsonrpcserver.NewWebSocketServerTransport(ctx, w, r, h.Upgrader, h.options)
+	if err != nil {
+		// the error response has already been written
+		cancel()
+		shared.DefaultLogger.Warn("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	session := &WebSocketSession{
+		Server:    h.newServer(ctx, r),
+		Transport: transport,
+	}
+
+	h.mu.Lock()
+	if h.ctx.Err() != nil {
+		h.mu.Unlock()
+		cancel()
+		transport.CloseWithCode(websocket.CloseGoingAway, "server is shutting down")
+		return
+	}
+	h.sessions[session] = struct{}{}
+	h.job.Add(1)
+	h.mu.Unlock()
+
+	go func() {
+		defer h.job.Done()
+		<-transport.Done()
+		cancel()
+
+		h.mu.Lock()
+		delete(h.sessions, session)
+		h.mu.Unlock()
+	}()
+
+	if err := session.Server.Connect(ctx, transport); err != nil {
+		shared.DefaultLogger.Error("failed to start MCP session: %v", err)
+		transport.CloseWithCode(websocket.CloseInternalServerErr, "failed to start MCP session")
+	}
+}
+
+// Sessions returns the currently connected sessions.
+func (h *WebSocketHandler) Sessions() []*WebSocketSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sessions := make([]*WebSocketSession, 0, len(h.sessions))
+	for session := range h.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// Close rejects new connections, closes all sessions with status 1001 (going away) and waits for them to finish.
+func (h *WebSocketHandler) Close() error {
+	h.mu.Lock()
+	h.cancel()
+	sessions := make([]*WebSocketSession, 0, len(h.sessions))
+	for session := range h.sessions {
+		sessions = append(sessions, session)
+	}
+	h.mu.Unlock()
+
+	for _, session := range sessions {
+		session.Transport.CloseWithCode(websocket.CloseGoingAway, "server is shutting down")
+	}
+	h.job.Wait()
+	return nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+	jsonrpcclient "github.com/nalbion/go-mcp/pkg/jsonrpc/client"
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/nalbion/go-mcp/pkg/mcp/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocketHandler(t *testing.T) {
+	// given a handler which creates a server for each connection, identifying the user from the request
+	ctx := context.Background()
+	handler := NewWebSocketHandler(ctx, shared.NewWebSocketOptions(), func(ctx context.Context, r *http.Request) *Server {
+		user := r.Header.Get("X-User")
+		options := NewServerOptions()
+		server := NewServer(ctx, mcp.Implementation{Name: "test-server", Version: "1.0.0"}, &options)
+		server.SetRequestHandler("whoami", func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+			return jsonrpc.Result{AdditionalProperties: map[string]any{"user": user}}, nil
+		})
+		return server
+	})
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	connect := func(user string) (*jsonrpc.Protocol, chan struct{}) {
+		transport := jsonrpcclient.NewWebSocketClientTransport(ctx, url, http.Header{"X-User": {user}}, shared.NewWebSocketOptions())
+		protocol := jsonrpc.NewProtocol(ctx)
+		require.NoError(t, protocol.Connect(ctx, transport))
+		closed := make(chan struct{})
+		transport.SetOnClose(func() { close(closed) })
+		return protocol, closed
+	}
+
+	// when two clients connect
+	alice, aliceClosed := connect("alice")
+	bob, bobClosed := connect("bob")
+
+	// then each client has its own session
+	for user, protocol := range map[string]*jsonrpc.Protocol{"alice": alice, "bob": bob} {
+		result := &jsonrpc.Result{AdditionalProperties: &map[string]any{}}
+		require.NoError(t, protocol.SendRequest(ctx, "whoami", nil, result))
+		assert.Equal(t, user, (*result.AdditionalProperties.(*map[string]any))["user"])
+	}
+	assert.Len(t, handler.Sessions(), 2)
+
+	// when the handler is closed
+	require.NoError(t, handler.Close())
+
+	// then all clients are disconnected
+	for _, closed := range []chan struct{}{aliceClosed, bobClosed} {
+		select {
+		case <-closed:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for client to be disconnected")
+		}
+	}
+	assert.Empty(t, handler.Sessions())
+}
@@ -28,6 +28,7 @@ const (
 	SamplingCreateMessageMethod           jsonrpc.Method = "sampling/createMessage"
 	CompletionCompleteMethod              jsonrpc.Method = "completion/complete"
 	RootsListMethod                       jsonrpc.Method = "roots/list"
+	ElicitationCreateMethod               jsonrpc.Method = "elicitation/create"
 )
 
 const (
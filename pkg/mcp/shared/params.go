@@ -0,0 +1,45 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+)
+
+// DecodeParams converts the params of a request to T.
+// In-process transports may pass the params as T or *T, while params received over the wire have been parsed to a map.
+func DecodeParams[T any](request *jsonrpc.JSONRPCRequest, params *T) error {
+	if request.Params == nil {
+		return errors.New("missing params")
+	}
+
+	value := request.Params.AdditionalProperties
+	// the params may have been wrapped more than once, eg: by mcp/client.Client.SendRequest()
+	for {
+		if wrapped, ok := value.(*jsonrpc.JSONRPCRequestParams); ok && wrapped != nil {
+			value = wrapped.AdditionalProperties
+		} else if wrapped, ok := value.(jsonrpc.JSONRPCRequestParams); ok {
+			value = wrapped.AdditionalProperties
+		} else {
+			break
+		}
+	}
+
+	switch value := value.(type) {
+	case T:
+		*params = value
+		return nil
+	case *T:
+		if value != nil {
+			*params = *value
+			return nil
+		}
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, params)
+}
@@ -2,6 +2,7 @@ package shared
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -25,12 +26,14 @@ type Protocol struct {
 	jsonrpc.Protocol
 	options                 *ProtocolOptions
 	progressHandlers        map[int]mcp.ProgressHandler
+	progressMutex           sync.Mutex
 	requestAbortControllers sync.Map
 }
 
 func NewProtocol(ctx context.Context, options *ProtocolOptions) *Protocol {
 	p := &Protocol{
-		Protocol: *jsonrpc.NewProtocol(ctx),
+		Protocol:         *jsonrpc.NewProtocol(ctx),
+		progressHandlers: make(map[int]mcp.ProgressHandler),
 	}
 
 	p.Protocol.OnRequest = p.onRequest
@@ -50,11 +53,11 @@ func NewProtocol(ctx context.Context, options *ProtocolOptions) *Protocol {
 
 func (p *Protocol) Connect(ctx context.Context, transport jsonrpc.Transport) error {
 	p.Protocol.SetNotificationHandler(NotificationsProgressMethod, func(notification *jsonrpc.JSONRPCNotification) error {
-		if progress, ok := notification.Params.AdditionalProperties.(mcp.ProgressNotificationParams); ok {
-			return p.onProgress(progress)
+		var progress mcp.ProgressNotificationParams
+		if err := decodeNotificationParams(notification, &progress); err != nil {
+			return fmt.Errorf("failed to decode ProgressNotification: %w", err)
 		}
-
-		return fmt.Errorf("failed to assert notification as ProgressNotification")
+		return p.onProgress(progress)
 	})
 
 	err := p.Protocol.Connect(ctx, transport)
@@ -85,7 +88,9 @@ func (p *Protocol) SendRequest(
 	jsonrpcRequest, messageID := p.Protocol.NewRequest(method, params)
 
 	if options != nil && options.OnProgress != nil {
+		p.progressMutex.Lock()
 		p.progressHandlers[messageID] = options.OnProgress
+		p.progressMutex.Unlock()
 		if jsonrpcRequest.Params == nil {
 			jsonrpcRequest.Params = &jsonrpc.JSONRPCRequestParams{}
 		}
@@ -107,7 +112,7 @@ func (p *Protocol) SendRequest(
 	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
 
 	return p.Protocol.SendRequestInternal(ctx, jsonrpcRequest, messageID, result, cancelTimeout, func(reason string) {
-		delete(p.progressHandlers, messageID)
+		p.removeProgressHandler(messageID)
 
 		if p.Protocol.IsConnected() {
 			err := p.Protocol.SendNotification(
@@ -130,12 +135,13 @@ func (p *Protocol) SendRequest(
 // which receives the cancelable context created here.
 func (p *Protocol) onRequest(ctx context.Context, request *jsonrpc.JSONRPCRequest, onDone func()) {
 	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
 
 	p.requestAbortControllers.Store(request.Id, cancel)
 
-	p.Protocol.OnRequest(ctx, request, func() {
+	// the request is handled asynchronously, so ctx must not be cancelled until the handler is done
+	p.Protocol.HandleRequest(ctx, request, func() {
 		p.requestAbortControllers.Delete(request.Id)
+		cancel()
 	})
 }
 
@@ -148,7 +154,9 @@ func (p *Protocol) cancelRequest(id jsonrpc.RequestId) {
 func (p *Protocol) onProgress(notification mcp.ProgressNotificationParams) error {
 	progressToken := notification.ProgressToken
 
+	p.progressMutex.Lock()
 	handler := p.progressHandlers[int(progressToken)]
+	p.progressMutex.Unlock()
 	if handler == nil {
 		p.Protocol.OnError(fmt.Errorf("received a progress notification for an unknown token: %v", progressToken))
 		return nil
@@ -159,14 +167,46 @@ func (p *Protocol) onProgress(notification mcp.ProgressNotificationParams) error
 }
 
 func (p *Protocol) removeResponseHandler(id int) {
-	delete(p.progressHandlers, id)
+	p.removeProgressHandler(id)
 	// p.Protocol.RemoveResponseHandler(id)
 }
 
+func (p *Protocol) removeProgressHandler(id int) {
+	p.progressMutex.Lock()
+	defer p.progressMutex.Unlock()
+	delete(p.progressHandlers, id)
+}
+
 func (p *Protocol) onClose() {
+	p.progressMutex.Lock()
 	for k := range p.progressHandlers {
 		delete(p.progressHandlers, k)
 	}
+	p.progressMutex.Unlock()
 
 	p.Protocol.Close()
 }
+
+// decodeNotificationParams converts the params of a notification to T.
+// In-process transports may pass the params as T, while params received over the wire have been parsed to a map.
+func decodeNotificationParams[T any](notification *jsonrpc.JSONRPCNotification, params *T) error {
+	if notification.Params == nil {
+		return errors.New("missing params")
+	}
+	switch value := notification.Params.AdditionalProperties.(type) {
+	case T:
+		*params = value
+		return nil
+	case *T:
+		if value != nil {
+			*params = *value
+			return nil
+		}
+	}
+
+	content, err := json.Marshal(notification.Params.AdditionalProperties)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, params)
+}
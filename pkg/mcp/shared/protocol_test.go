@@ -8,6 +8,7 @@ import (
 
 	"github.com/nalbion/go-mcp/pkg/jsonrpc"
 	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -66,3 +67,96 @@ func TestRemoveResponseHandler(t *testing.T) {
 	// because we removed them when the message was received
 	// require.True(t, messageReceived)
 }
+
+func TestOnRequest(t *testing.T) {
+	t.Run("should not cancel the context before the handler has finished", func(t *testing.T) {
+		// given
+		ctx := context.Background()
+		p := NewProtocol(ctx, &ProtocolOptions{})
+		require.NoError(t, p.Connect(ctx, &jsonrpc.BaseTransport{}))
+
+		handlerErr := make(chan error, 1)
+		p.SetRequestHandler("test", func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+			time.Sleep(10 * time.Millisecond)
+			handlerErr <- ctx.Err()
+			return jsonrpc.Result{}, nil
+		})
+
+		// when
+		p.onRequest(ctx, &jsonrpc.JSONRPCRequest{Id: 1, Method: "test"}, nil)
+
+		// then
+		select {
+		case err := <-handlerErr:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("handler was not called")
+		}
+	})
+
+	t.Run("should cancel the context when the request is cancelled", func(t *testing.T) {
+		// given
+		ctx := context.Background()
+		p := NewProtocol(ctx, &ProtocolOptions{})
+		require.NoError(t, p.Connect(ctx, &jsonrpc.BaseTransport{}))
+
+		started := make(chan struct{})
+		handlerErr := make(chan error, 1)
+		p.SetRequestHandler("test", func(ctx context.Context, request *jsonrpc.JSONRPCRequest, extra *jsonrpc.RequestHandlerExtra) (jsonrpc.Result, error) {
+			close(started)
+			<-ctx.Done()
+			handlerErr <- ctx.Err()
+			return jsonrpc.Result{}, ctx.Err()
+		})
+
+		// when
+		p.onRequest(ctx, &jsonrpc.JSONRPCRequest{Id: 1, Method: "test"}, nil)
+		<-started
+		p.cancelRequest(1)
+
+		// then
+		select {
+		case err := <-handlerErr:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("handler was not cancelled")
+		}
+	})
+}
+
+func TestProgressNotifications(t *testing.T) {
+	t.Run("should pass progress notifications received over the wire to OnProgress", func(t *testing.T) {
+		// given
+		ctx := context.Background()
+		p := NewProtocol(ctx, &ProtocolOptions{})
+		transport := &jsonrpc.MockTransport{}
+		require.NoError(t, p.Connect(ctx, transport))
+		progress := make(chan mcp.ProgressNotificationParams, 1)
+		done := make(chan error, 1)
+		go func() {
+			done <- p.SendRequest(ctx, "tools/call", nil, nil, &mcp.RequestOptions{
+				OnProgress: func(notification mcp.ProgressNotificationParams) {
+					progress <- notification
+				},
+			})
+		}()
+		request, err := transport.WaitForRequest("tools/call", time.Second)
+		require.NoError(t, err)
+
+		// when
+		transport.ReceiveNotification(NotificationsProgressMethod, map[string]any{
+			"progressToken": float64((*request.Params.Meta)["progressToken"].(mcp.ProgressToken)),
+			"progress":      50.0,
+			"total":         100.0,
+			"message":       "half way",
+		})
+		transport.ReceiveResponse(request.Id, map[string]any{})
+
+		// then
+		notification := <-progress
+		require.NoError(t, <-done)
+		assert.Equal(t, 50.0, notification.Progress)
+		assert.Equal(t, 100.0, *notification.Total)
+		assert.Equal(t, "half way", *notification.Message)
+	})
+}
@@ -1,145 +1,280 @@
 package shared
 
-// import (
-// 	"context"
-// 	"encoding/json"
-// 	"errors"
-// 	"net/http"
-// 	"sync"
-// 	"time"
-
-// 	"github.com/gorilla/websocket"
-// )
-
-// const MCP_SUBPROTOCOL = "mcp"
-
-// type JSONRPCMessage struct {
-// 	// Define the structure of your JSONRPCMessage here
-// }
-
-// type WebSocketMcpTransport struct {
-// 	client         *http.Client
-// 	url            string
-// 	requestBuilder func(req *http.Request)
-// 	conn           *websocket.Conn
-// 	mu             sync.Mutex
-// 	initialized    bool
-
-// 	onClose   func()
-// 	onError   func(err error)
-// 	onMessage func(message JSONRPCMessage)
-// }
-
-// func NewWebSocketMcpTransport(client *http.Client, url string, requestBuilder func(req *http.Request)) *WebSocketMcpTransport {
-// 	return &WebSocketMcpTransport{
-// 		client:         client,
-// 		url:            url,
-// 		requestBuilder: requestBuilder,
-// 	}
-// }
-
-// func (w *WebSocketMcpTransport) InitializeSession() error {
-// 	headers := http.Header{}
-// 	headers.Set("Sec-WebSocket-Protocol", MCP_SUBPROTOCOL)
-
-// 	dialer := websocket.Dialer{
-// 		Proxy:            http.ProxyFromEnvironment,
-// 		HandshakeTimeout: 45 * time.Second,
-// 	}
-
-// 	conn, _, err := dialer.Dial(w.url, headers)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	w.conn = conn
-// 	return nil
-// }
-
-// func (w *WebSocketMcpTransport) Start(ctx context.Context) error {
-// 	w.mu.Lock()
-// 	if w.initialized {
-// 		w.mu.Unlock()
-// 		return errors.New("WebSocketClientTransport already started")
-// 	}
-// 	w.initialized = true
-// 	w.mu.Unlock()
-
-// 	if err := w.InitializeSession(); err != nil {
-// 		return err
-// 	}
-
-// 	go func() {
-// 		for {
-// 			select {
-// 			case <-ctx.Done():
-// 				return
-// 			default:
-// 				_, message, err := w.conn.ReadMessage()
-// 				if err != nil {
-// 					if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-// 						w.onClose()
-// 					} else {
-// 						w.onError(err)
-// 					}
-// 					return
-// 				}
-
-// 				var jsonMessage JSONRPCMessage
-// 				if err := json.Unmarshal(message, &jsonMessage); err != nil {
-// 					w.onError(err)
-// 					return
-// 				}
-
-// 				w.onMessage(jsonMessage)
-// 			}
-// 		}
-// 	}()
-
-// 	return nil
-// }
-
-// func (w *WebSocketMcpTransport) Send(message JSONRPCMessage) error {
-// 	w.mu.Lock()
-// 	defer w.mu.Unlock()
-
-// 	if !w.initialized {
-// 		return errors.New("Not connected")
-// 	}
-
-// 	data, err := json.Marshal(message)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return w.conn.WriteMessage(websocket.TextMessage, data)
-// }
-
-// func (w *WebSocketMcpTransport) Close() error {
-// 	w.mu.Lock()
-// 	defer w.mu.Unlock()
-
-// 	if !w.initialized {
-// 		return errors.New("Not connected")
-// 	}
-
-// 	err := w.conn.Close()
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	w.onClose()
-// 	return nil
-// }
-
-// func (w *WebSocketMcpTransport) OnClose(block func()) {
-// 	w.onClose = block
-// }
-
-// func (w *WebSocketMcpTransport) OnError(block func(err error)) {
-// 	w.onError = block
-// }
-
-// func (w *WebSocketMcpTransport) OnMessage(block func(message JSONRPCMessage)) {
-// 	w.onMessage = block
-// }
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nalbion/go-mcp/pkg/jsonrpc"
+)
+
+// MCP_SUBPROTOCOL must be negotiated by both sides of an MCP WebSocket connection.
+const MCP_SUBPROTOCOL = "mcp"
+
+type WebSocketOptions struct {
+	// PingInterval is how often a ping is sent to keep the connection alive. Zero disables pings.
+	PingInterval time.Duration
+	// PongTimeout is how long to wait after a ping for a pong (or any other message) before closing the connection.
+	PongTimeout time.Duration
+	// WriteTimeout limits how long sending a message may block.
+	WriteTimeout time.Duration
+	// MaxMessageSize is the largest message, in bytes, that will be accepted.
+	// Larger messages close the connection with status 1009 (message too big). Zero means no limit.
+	MaxMessageSize int64
+}
+
+func NewWebSocketOptions() WebSocketOptions {
+	return WebSocketOptions{
+		PingInterval:   30 * time.Second,
+		PongTimeout:    10 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxMessageSize: 4 * 1024 * 1024,
+	}
+}
+
+// WebSocketTransport exchanges JSON-RPC messages as WebSocket text messages, one message per frame.
+// It is used by both the client and the server once the connection has been established.
+type WebSocketTransport struct {
+	jsonrpc.BaseTransport
+	ctx       context.Context
+	cancel    context.CancelFunc
+	conn      *websocket.Conn
+	options   WebSocketOptions
+	started   bool
+	closed    bool
+	done      chan struct{}
+	writeLock sync.Mutex
+	mu        sync.Mutex
+}
+
+func NewWebSocketTransport(ctx context.Context, conn *websocket.Conn, options WebSocketOptions) *WebSocketTransport {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &WebSocketTransport{
+		ctx:     ctx,
+		cancel:  cancel,
+		conn:    conn,
+		options: options,
+		done:    make(chan struct{}),
+	}
+}
+
+func (t *WebSocketTransport) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started {
+		return errors.New("WebSocketTransport already started")
+	}
+	if t.closed {
+		return errors.New("WebSocketTransport is closed")
+	}
+	t.started = true
+
+	if t.options.MaxMessageSize > 0 {
+		t.conn.SetReadLimit(t.options.MaxMessageSize)
+	}
+	if t.options.PingInterval > 0 {
+		t.extendReadDeadline()
+		t.conn.SetPongHandler(func(string) error {
+			t.extendReadDeadline()
+			return nil
+		})
+		go t.keepAlive()
+	}
+
+	go t.read()
+	go func() {
+		select {
+		case <-t.ctx.Done():
+			t.CloseWithCode(websocket.CloseGoingAway, "")
+		case <-t.done:
+		}
+	}()
+
+	return nil
+}
+
+func (t *WebSocketTransport) extendReadDeadline() {
+	t.conn.SetReadDeadline(time.Now().Add(t.options.PingInterval + t.options.PongTimeout))
+}
+
+func (t *WebSocketTransport) keepAlive() {
+	ticker := time.NewTicker(t.options.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			// WriteControl may be called concurrently with the other write methods
+			if err := t.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(t.options.PongTimeout)); err != nil {
+				if !t.isClosed() {
+					t.onError(fmt.Errorf("failed to send ping: %w", err))
+				}
+				return
+			}
+		}
+	}
+}
+
+func (t *WebSocketTransport) read() {
+	for {
+		messageType, data, err := t.conn.ReadMessage()
+		if err != nil {
+			t.handleReadError(err)
+			return
+		}
+		if t.options.PingInterval > 0 {
+			t.extendReadDeadline()
+		}
+
+		if messageType != websocket.TextMessage {
+			t.onError(errors.New("received a binary WebSocket message"))
+			t.CloseWithCode(websocket.CloseUnsupportedData, "only text messages are supported")
+			return
+		}
+
+		message, err := jsonrpc.ParseJSONRPCMessage(data)
+		if err != nil {
+			t.onError(fmt.Errorf("failed to parse WebSocket message: %w", err))
+			continue
+		}
+		if onMessage := t.onMessage(); onMessage != nil {
+			onMessage(message)
+		}
+	}
+}
+
+func (t *WebSocketTransport) handleReadError(err error) {
+	if t.isClosed() {
+		// we closed the connection
+		return
+	}
+
+	switch {
+	case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway):
+	case errors.Is(err, websocket.ErrReadLimit):
+		// the connection has already been closed with CloseMessageTooBig
+		t.onError(fmt.Errorf("WebSocket message exceeds %d bytes", t.options.MaxMessageSize))
+	default:
+		t.onError(err)
+	}
+	t.finish()
+}
+
+func (t *WebSocketTransport) Send(message jsonrpc.JSONRPCMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if t.isClosed() {
+		return errors.New("not connected")
+	}
+
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	if t.options.WriteTimeout > 0 {
+		t.conn.SetWriteDeadline(time.Now().Add(t.options.WriteTimeout))
+	}
+	return t.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Close closes the connection with status 1000 (normal closure).
+func (t *WebSocketTransport) Close() error {
+	return t.CloseWithCode(websocket.CloseNormalClosure, "")
+}
+
+// CloseWithCode sends a close frame with the given status code, eg: websocket.CloseGoingAway, and closes the connection.
+func (t *WebSocketTransport) CloseWithCode(code int, reason string) error {
+	if t.isClosed() {
+		return nil
+	}
+
+	deadline := time.Now().Add(time.Second)
+	if t.options.WriteTimeout > 0 {
+		deadline = time.Now().Add(t.options.WriteTimeout)
+	}
+	err := t.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+	if errors.Is(err, websocket.ErrCloseSent) {
+		err = nil
+	}
+
+	t.finish()
+	return err
+}
+
+// finish closes the underlying connection and calls OnClose, once.
+func (t *WebSocketTransport) finish() {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	t.closed = true
+	onClose := t.OnClose
+	t.mu.Unlock()
+
+	t.cancel()
+	t.conn.Close()
+	close(t.done)
+	if onClose != nil {
+		onClose()
+	}
+}
+
+// Done is closed when the transport has been closed.
+func (t *WebSocketTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// Subprotocol returns the subprotocol negotiated for the connection.
+func (t *WebSocketTransport) Subprotocol() string {
+	return t.conn.Subprotocol()
+}
+
+func (t *WebSocketTransport) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+// the callbacks may be replaced while reading, eg: by Protocol.Connect() after Start()
+
+func (t *WebSocketTransport) SetOnClose(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnClose = f
+}
+
+func (t *WebSocketTransport) SetOnError(f func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnError = f
+}
+
+func (t *WebSocketTransport) SetOnMessage(f func(message jsonrpc.JSONRPCMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.OnMessage = f
+}
+
+func (t *WebSocketTransport) onMessage() func(message jsonrpc.JSONRPCMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.OnMessage
+}
+
+func (t *WebSocketTransport) onError(err error) {
+	t.mu.Lock()
+	onError := t.OnError
+	t.mu.Unlock()
+	if onError != nil {
+		onError(err)
+	}
+}
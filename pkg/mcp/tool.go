@@ -0,0 +1,141 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OutputValidationError is returned when the structuredContent of a CallToolResult does not match the tool's OutputSchema.
+type OutputValidationError struct {
+	Tool   string
+	Errors []ValidationError
+}
+
+func (e *OutputValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.String()
+	}
+	return fmt.Sprintf("invalid structured content from tool %s: %s", e.Tool, strings.Join(messages, "; "))
+}
+
+// ValidateStructuredContent checks that a successful result of the tool has structuredContent which matches its OutputSchema.
+// Results of tools without an OutputSchema, and results with IsError set, are not checked.
+func (t Tool) ValidateStructuredContent(result CallToolResult) error {
+	if t.OutputSchema == nil || (result.IsError != nil && *result.IsError) {
+		return nil
+	}
+	if result.StructuredContent == nil {
+		return &OutputValidationError{Tool: t.Name, Errors: []ValidationError{{Message: "structuredContent is required by the output schema"}}}
+	}
+
+	if errors := t.OutputSchema.Validate(normaliseJSON(result.StructuredContent)); len(errors) > 0 {
+		return &OutputValidationError{Tool: t.Name, Errors: errors}
+	}
+	return nil
+}
+
+// DecodeStructuredContent unmarshals the structuredContent of a result into `v`, eg: a pointer to a struct.
+func (r CallToolResult) DecodeStructuredContent(v any) error {
+	if r.StructuredContent == nil {
+		return fmt.Errorf("the result has no structured content")
+	}
+	content, err := json.Marshal(r.StructuredContent)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+// NewToolAnnotations starts building the annotations of a tool, eg:
+//
+//	mcp.NewToolAnnotations().WithTitle("Delete file").WithDestructiveHint(true)
+func NewToolAnnotations() *ToolAnnotations {
+	return &ToolAnnotations{}
+}
+
+func (a *ToolAnnotations) WithTitle(title string) *ToolAnnotations {
+	a.Title = title
+	return a
+}
+
+func (a *ToolAnnotations) WithReadOnlyHint(readOnly bool) *ToolAnnotations {
+	a.ReadOnlyHint = &readOnly
+	return a
+}
+
+func (a *ToolAnnotations) WithDestructiveHint(destructive bool) *ToolAnnotations {
+	a.DestructiveHint = &destructive
+	return a
+}
+
+func (a *ToolAnnotations) WithIdempotentHint(idempotent bool) *ToolAnnotations {
+	a.IdempotentHint = &idempotent
+	return a
+}
+
+func (a *ToolAnnotations) WithOpenWorldHint(openWorld bool) *ToolAnnotations {
+	a.OpenWorldHint = &openWorld
+	return a
+}
+
+// The following helpers apply the defaults from the spec when a hint is not set.
+// Annotations are only hints, and should be ignored if the server is not trusted.
+
+// DisplayTitle returns the title from the annotations, or the name of the tool if there is none.
+func (t Tool) DisplayTitle() string {
+	if t.Annotations != nil && t.Annotations.Title != "" {
+		return t.Annotations.Title
+	}
+	return t.Name
+}
+
+// IsReadOnly reports whether the tool does not modify its environment. Defaults to false.
+func (t Tool) IsReadOnly() bool {
+	return t.Annotations != nil && hint(t.Annotations.ReadOnlyHint, false)
+}
+
+// IsDestructive reports whether the tool may perform destructive updates, rather than only additive ones.
+// Defaults to true, unless the tool is read-only.
+func (t Tool) IsDestructive() bool {
+	if t.IsReadOnly() {
+		return false
+	}
+	return t.Annotations == nil || hint(t.Annotations.DestructiveHint, true)
+}
+
+// IsIdempotent reports whether calling the tool again with the same arguments has no additional effect.
+// Read-only tools are idempotent, otherwise defaults to false.
+func (t Tool) IsIdempotent() bool {
+	return t.IsReadOnly() || (t.Annotations != nil && hint(t.Annotations.IdempotentHint, false))
+}
+
+// IsOpenWorld reports whether the tool may interact with external entities, eg: the web. Defaults to true.
+func (t Tool) IsOpenWorld() bool {
+	return t.Annotations == nil || hint(t.Annotations.OpenWorldHint, true)
+}
+
+// RequiresConfirmation reports whether a host should ask the user before calling the tool,
+// because it may make destructive changes.
+func (t Tool) RequiresConfirmation() bool {
+	return t.IsDestructive()
+}
+
+// IsSafeToRetry reports whether a failed call may be retried without unintended side effects.
+func (t Tool) IsSafeToRetry() bool {
+	return t.IsIdempotent()
+}
+
+// IsCacheable reports whether the results of calls may be cached,
+// because the tool does not modify its environment or depend on external entities which may change.
+func (t Tool) IsCacheable() bool {
+	return t.IsReadOnly() && !t.IsOpenWorld()
+}
+
+func hint(value *bool, defaultValue bool) bool {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
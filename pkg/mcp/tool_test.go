@@ -0,0 +1,130 @@
+package mcp_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredContent(t *testing.T) {
+	tool := mcp.Tool{
+		Name:        "weather",
+		InputSchema: mcp.ToolInputSchema{Type: "object"},
+		OutputSchema: mcp.NewObjectSchema().
+			WithProperty("temperature", mcp.NewNumberSchema(), true).
+			WithProperty("unit", mcp.NewStringSchema().WithEnum("celsius", "fahrenheit"), true),
+	}
+
+	t.Run("should round-trip the output schema and structured content", func(t *testing.T) {
+		// given
+		result := mcp.CallToolResult{
+			Content:           []mcp.Content{mcp.Text("21.5 celsius")},
+			StructuredContent: map[string]any{"temperature": 21.5, "unit": "celsius"},
+		}
+
+		// when
+		toolData, err := json.Marshal(tool)
+		require.NoError(t, err)
+		resultData, err := json.Marshal(result)
+		require.NoError(t, err)
+
+		var unmarshaledTool mcp.Tool
+		require.NoError(t, json.Unmarshal(toolData, &unmarshaledTool))
+		var unmarshaledResult mcp.CallToolResult
+		require.NoError(t, json.Unmarshal(resultData, &unmarshaledResult))
+
+		// then
+		assert.Equal(t, []string{"temperature", "unit"}, unmarshaledTool.OutputSchema.Required)
+		assert.Equal(t, result.StructuredContent, unmarshaledResult.StructuredContent)
+		assert.NoError(t, unmarshaledTool.ValidateStructuredContent(unmarshaledResult))
+	})
+
+	t.Run("should report structured content which does not match the output schema", func(t *testing.T) {
+		// when
+		err := tool.ValidateStructuredContent(mcp.CallToolResult{StructuredContent: map[string]any{"temperature": "hot", "unit": "kelvin"}})
+
+		// then
+		var validationErr *mcp.OutputValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []mcp.ValidationError{
+			{Path: "temperature", Message: "must be of type number"},
+			{Path: "unit", Message: `must be one of ["celsius","fahrenheit"]`},
+		}, validationErr.Errors)
+		assert.EqualError(t, err, `invalid structured content from tool weather: temperature: must be of type number; unit: must be one of ["celsius","fahrenheit"]`)
+	})
+
+	t.Run("should require structured content unless the result is an error", func(t *testing.T) {
+		isError := true
+
+		assert.ErrorContains(t, tool.ValidateStructuredContent(mcp.CallToolResult{}), "structuredContent is required")
+		assert.NoError(t, tool.ValidateStructuredContent(mcp.CallToolResult{IsError: &isError}))
+		assert.NoError(t, mcp.Tool{Name: "untyped"}.ValidateStructuredContent(mcp.CallToolResult{}))
+	})
+
+	t.Run("should decode structured content", func(t *testing.T) {
+		// given
+		var out struct {
+			Temperature float64 `json:"temperature"`
+		}
+
+		// when
+		err := mcp.CallToolResult{StructuredContent: map[string]any{"temperature": 21.5}}.DecodeStructuredContent(&out)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 21.5, out.Temperature)
+		assert.Error(t, mcp.CallToolResult{}.DecodeStructuredContent(&out))
+	})
+}
+
+func TestToolAnnotations(t *testing.T) {
+	t.Run("should apply the defaults from the spec when hints are not set", func(t *testing.T) {
+		tool := mcp.Tool{Name: "unannotated"}
+
+		assert.Equal(t, "unannotated", tool.DisplayTitle())
+		assert.False(t, tool.IsReadOnly())
+		assert.True(t, tool.IsDestructive())
+		assert.False(t, tool.IsIdempotent())
+		assert.True(t, tool.IsOpenWorld())
+		assert.True(t, tool.RequiresConfirmation())
+		assert.False(t, tool.IsSafeToRetry())
+		assert.False(t, tool.IsCacheable())
+	})
+
+	t.Run("should treat read-only tools as safe", func(t *testing.T) {
+		tool := mcp.Tool{Name: "read_file", Annotations: mcp.NewToolAnnotations().WithTitle("Read file").WithReadOnlyHint(true).WithOpenWorldHint(false)}
+
+		assert.Equal(t, "Read file", tool.DisplayTitle())
+		assert.False(t, tool.IsDestructive())
+		assert.False(t, tool.RequiresConfirmation())
+		assert.True(t, tool.IsSafeToRetry())
+		assert.True(t, tool.IsCacheable())
+	})
+
+	t.Run("should use the hints of tools which modify their environment", func(t *testing.T) {
+		tool := mcp.Tool{Name: "set_flag", Annotations: mcp.NewToolAnnotations().WithDestructiveHint(false).WithIdempotentHint(true)}
+
+		assert.False(t, tool.RequiresConfirmation())
+		assert.True(t, tool.IsSafeToRetry())
+		assert.False(t, tool.IsCacheable())
+	})
+
+	t.Run("should round-trip the annotations", func(t *testing.T) {
+		// given
+		data := `{"name": "delete_file", "inputSchema": {"type": "object"}, "annotations": {"title": "Delete file", "destructiveHint": true, "openWorldHint": false}}`
+
+		// when
+		var tool mcp.Tool
+		require.NoError(t, json.Unmarshal([]byte(data), &tool))
+		marshaled, err := json.Marshal(tool)
+
+		// then
+		require.NoError(t, err)
+		assert.JSONEq(t, data, string(marshaled))
+		assert.Nil(t, tool.Annotations.ReadOnlyHint)
+		assert.True(t, tool.RequiresConfirmation())
+	})
+}
@@ -44,7 +44,7 @@ func TestToolRegistration(t *testing.T) {
 				},
 			},
 		},
-		func(params mcp.CallToolRequestParams) (mcp.CallToolResult, error) {
+		func(ctx context.Context, params mcp.CallToolRequestParams, extra *mcpserver.RequestHandlerExtra) (mcp.CallToolResult, error) {
 			return mcp.CallToolResult{}, nil
 		},
 	)
@@ -57,7 +57,7 @@ func TestToolRegistration(t *testing.T) {
 		"test-tool",
 		"Another test tool",
 		mcp.ToolInputSchema{},
-		func(params mcp.CallToolRequestParams) (mcp.CallToolResult, error) {
+		func(ctx context.Context, params mcp.CallToolRequestParams, extra *mcpserver.RequestHandlerExtra) (mcp.CallToolResult, error) {
 			return mcp.CallToolResult{}, nil
 		},
 	)
@@ -94,15 +94,10 @@ func TestToolExecution(t *testing.T) {
 				},
 			},
 		},
-		func(params mcp.CallToolRequestParams) (mcp.CallToolResult, error) {
+		func(ctx context.Context, params mcp.CallToolRequestParams, extra *mcpserver.RequestHandlerExtra) (mcp.CallToolResult, error) {
 			message, _ := params.Arguments["message"].(string)
 			return mcp.CallToolResult{
-				Content: []interface{}{
-					map[string]interface{}{
-						"type": "text",
-						"text": message,
-					},
-				},
+				Content: []mcp.Content{mcp.Text(message)},
 			}, nil
 		},
 	)
@@ -127,10 +122,10 @@ func TestToolExecution(t *testing.T) {
 	callResult, ok := result.AdditionalProperties.(mcp.CallToolResult)
 	require.True(t, ok)
 	require.Len(t, callResult.Content, 1)
-	content, ok := callResult.Content[0].(map[string]interface{})
+	content, ok := callResult.Content[0].(mcp.TextContent)
 	require.True(t, ok)
-	assert.Equal(t, "text", content["type"])
-	assert.Equal(t, "Hello, world!", content["text"])
+	assert.Equal(t, "text", content.Type)
+	assert.Equal(t, "Hello, world!", content.Text)
 }
 
 // TestToolNotification tests the tool list changed notification
@@ -196,7 +191,7 @@ func TestToolValidation(t *testing.T) {
 			},
 			Required: []string{"required_param"},
 		},
-		func(params mcp.CallToolRequestParams) (mcp.CallToolResult, error) {
+		func(ctx context.Context, params mcp.CallToolRequestParams, extra *mcpserver.RequestHandlerExtra) (mcp.CallToolResult, error) {
 			return mcp.CallToolResult{}, nil
 		},
 	)
@@ -286,7 +281,7 @@ func TestMultipleTools(t *testing.T) {
 				Description: "Tool 1",
 				InputSchema: mcp.ToolInputSchema{},
 			},
-			Handler: func(params mcp.CallToolRequestParams) (mcp.CallToolResult, error) {
+			Handler: func(ctx context.Context, params mcp.CallToolRequestParams, extra *mcpserver.RequestHandlerExtra) (mcp.CallToolResult, error) {
 				return mcp.CallToolResult{}, nil
 			},
 		},
@@ -296,7 +291,7 @@ func TestMultipleTools(t *testing.T) {
 				Description: "Tool 2",
 				InputSchema: mcp.ToolInputSchema{},
 			},
-			Handler: func(params mcp.CallToolRequestParams) (mcp.CallToolResult, error) {
+			Handler: func(ctx context.Context, params mcp.CallToolRequestParams, extra *mcpserver.RequestHandlerExtra) (mcp.CallToolResult, error) {
 				return mcp.CallToolResult{}, nil
 			},
 		},
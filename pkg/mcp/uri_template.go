@@ -0,0 +1,602 @@
+package mcp
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// UriTemplate is a URI template as defined by RFC 6570, up to and including level 4, eg: "file:///{+path}" or "users://{id}/posts{?page,limit}".
+//
+// Expand() builds a URI from the values of the variables, and Match() extracts the values of the variables from a URI.
+type UriTemplate struct {
+	template string
+	parts    []uriTemplatePart
+
+	// pattern matches URIs produced by the template, with a capture group for each entry in captures
+	pattern   *regexp.Regexp
+	captures  []uriTemplateCapture
+	queryVars []uriTemplateVarspec
+}
+
+// UriTemplateVariables are the values of the variables of a UriTemplate.
+// When matching a URI, exploded variables (eg: {/path*}) are returned as a []string and other variables as a string.
+// An exploded query variable, eg: {?filter*}, is returned as a map[string]string if the query has parameters
+// which are not named after a variable of the template, as Expand() produces from an associative array.
+type UriTemplateVariables map[string]any
+
+// Get returns the value of a variable as a string, or the first value of a list.
+func (v UriTemplateVariables) Get(name string) string {
+	switch value := v[name].(type) {
+	case string:
+		return value
+	case []string:
+		if len(value) > 0 {
+			return value[0]
+		}
+	}
+	return ""
+}
+
+type uriTemplatePart struct {
+	literal    string
+	expression *uriTemplateExpression
+}
+
+type uriTemplateExpression struct {
+	operator uriTemplateOperator
+	varspecs []uriTemplateVarspec
+}
+
+type uriTemplateVarspec struct {
+	name    string
+	prefix  int
+	explode bool
+}
+
+// uriTemplateCapture maps a capture group of the pattern back to a variable, or to a query string when varspec is nil.
+type uriTemplateCapture struct {
+	operator uriTemplateOperator
+	varspec  *uriTemplateVarspec
+}
+
+// uriTemplateOperator is a row of the table in RFC 6570 Appendix A.
+type uriTemplateOperator struct {
+	symbol        byte
+	first         string
+	sep           string
+	named         bool
+	ifEmpty       string
+	allowReserved bool
+}
+
+var uriTemplateOperators = map[byte]uriTemplateOperator{
+	0:   {symbol: 0, first: "", sep: ","},
+	'+': {symbol: '+', first: "", sep: ",", allowReserved: true},
+	'#': {symbol: '#', first: "#", sep: ",", allowReserved: true},
+	'.': {symbol: '.', first: ".", sep: "."},
+	'/': {symbol: '/', first: "/", sep: "/"},
+	';': {symbol: ';', first: ";", sep: ";", named: true},
+	'?': {symbol: '?', first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {symbol: '&', first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+var uriTemplateVarname = regexp.MustCompile(`^(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2})+(?:\.(?:[A-Za-z0-9_]|%[0-9A-Fa-f]{2})+)*$`)
+
+// ParseUriTemplate parses a URI template, returning an error if it is not valid according to RFC 6570.
+func ParseUriTemplate(template string) (*UriTemplate, error) {
+	t := &UriTemplate{template: template}
+
+	for rest := template; rest != ""; {
+		open := strings.IndexByte(rest, '{')
+		literal := rest
+		if open >= 0 {
+			literal = rest[:open]
+		}
+		if i := strings.IndexAny(literal, " \"'<>\\^`|}"); i >= 0 {
+			return nil, fmt.Errorf("invalid URI template %q: unexpected %q", template, literal[i])
+		}
+		if literal != "" {
+			t.parts = append(t.parts, uriTemplatePart{literal: literal})
+		}
+		if open < 0 {
+			break
+		}
+
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid URI template %q: unclosed expression", template)
+		}
+		expression, err := parseUriTemplateExpression(rest[open+1 : open+end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI template %q: %w", template, err)
+		}
+		t.parts = append(t.parts, uriTemplatePart{expression: expression})
+		rest = rest[open+end+1:]
+	}
+
+	if err := t.compilePattern(); err != nil {
+		return nil, fmt.Errorf("invalid URI template %q: %w", template, err)
+	}
+	return t, nil
+}
+
+// MustParseUriTemplate is like ParseUriTemplate but panics if the template is not valid.
+func MustParseUriTemplate(template string) *UriTemplate {
+	t, err := ParseUriTemplate(template)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseUriTemplateExpression(expression string) (*uriTemplateExpression, error) {
+	if expression == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	operator := uriTemplateOperators[0]
+	if op, ok := uriTemplateOperators[expression[0]]; ok && expression[0] != 0 {
+		operator = op
+		expression = expression[1:]
+	} else if strings.IndexByte("=,!@|", expression[0]) >= 0 {
+		return nil, fmt.Errorf("reserved operator %q", expression[0])
+	}
+
+	parsed := &uriTemplateExpression{operator: operator}
+	for _, spec := range strings.Split(expression, ",") {
+		varspec := uriTemplateVarspec{name: spec}
+		if name, found := strings.CutSuffix(spec, "*"); found {
+			varspec.name = name
+			varspec.explode = true
+		} else if name, prefix, found := strings.Cut(spec, ":"); found {
+			length, err := strconv.Atoi(prefix)
+			if err != nil || length < 1 || length > 9999 || prefix[0] == '0' {
+				return nil, fmt.Errorf("invalid prefix %q of variable %s", prefix, name)
+			}
+			varspec.name = name
+			varspec.prefix = length
+		}
+		if !uriTemplateVarname.MatchString(varspec.name) {
+			return nil, fmt.Errorf("invalid variable name %q", varspec.name)
+		}
+		parsed.varspecs = append(parsed.varspecs, varspec)
+	}
+	return parsed, nil
+}
+
+// String returns the template as it was parsed.
+func (t *UriTemplate) String() string {
+	return t.template
+}
+
+// VariableNames returns the names of the variables in the template, in the order they first appear.
+func (t *UriTemplate) VariableNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, part := range t.parts {
+		if part.expression == nil {
+			continue
+		}
+		for _, varspec := range part.expression.varspecs {
+			if !seen[varspec.name] {
+				seen[varspec.name] = true
+				names = append(names, varspec.name)
+			}
+		}
+	}
+	return names
+}
+
+// Expand builds a URI by substituting the values of the variables into the template.
+// A value can be a string, a slice (a list) or a map with string keys (an associative array, expanded in key order).
+// Other values are formatted with fmt.Sprint(). Variables which are missing, nil, or empty lists or maps are undefined, and are omitted.
+func (t *UriTemplate) Expand(variables map[string]any) (string, error) {
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.expression == nil {
+			b.WriteString(part.literal)
+			continue
+		}
+		if err := part.expression.expand(&b, variables); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+func (e *uriTemplateExpression) expand(b *strings.Builder, variables map[string]any) error {
+	op := e.operator
+	first := true
+	for _, varspec := range e.varspecs {
+		value, defined := uriTemplateValue(variables[varspec.name])
+		if !defined {
+			continue
+		}
+		if first {
+			b.WriteString(op.first)
+			first = false
+		} else {
+			b.WriteString(op.sep)
+		}
+
+		switch value := value.(type) {
+		case string:
+			if op.named {
+				b.WriteString(varspec.name)
+				if value == "" {
+					b.WriteString(op.ifEmpty)
+					continue
+				}
+				b.WriteByte('=')
+			}
+			if varspec.prefix > 0 && utf8.RuneCountInString(value) > varspec.prefix {
+				value = string([]rune(value)[:varspec.prefix])
+			}
+			b.WriteString(encodeUriTemplateValue(value, op.allowReserved))
+
+		case []string:
+			if varspec.prefix > 0 {
+				return fmt.Errorf("a prefix cannot be applied to the list value of %s", varspec.name)
+			}
+			if !varspec.explode {
+				if op.named {
+					b.WriteString(varspec.name + "=")
+				}
+				for i, item := range value {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					b.WriteString(encodeUriTemplateValue(item, op.allowReserved))
+				}
+				continue
+			}
+			for i, item := range value {
+				if i > 0 {
+					b.WriteString(op.sep)
+				}
+				if op.named {
+					b.WriteString(varspec.name)
+					if item == "" {
+						b.WriteString(op.ifEmpty)
+						continue
+					}
+					b.WriteByte('=')
+				}
+				b.WriteString(encodeUriTemplateValue(item, op.allowReserved))
+			}
+
+		case [][2]string:
+			if varspec.prefix > 0 {
+				return fmt.Errorf("a prefix cannot be applied to the associative array value of %s", varspec.name)
+			}
+			if !varspec.explode {
+				if op.named {
+					b.WriteString(varspec.name + "=")
+				}
+				for i, pair := range value {
+					if i > 0 {
+						b.WriteByte(',')
+					}
+					b.WriteString(encodeUriTemplateValue(pair[0], op.allowReserved) + "," + encodeUriTemplateValue(pair[1], op.allowReserved))
+				}
+				continue
+			}
+			for i, pair := range value {
+				if i > 0 {
+					b.WriteString(op.sep)
+				}
+				b.WriteString(encodeUriTemplateValue(pair[0], op.allowReserved))
+				if op.named && pair[1] == "" {
+					b.WriteString(op.ifEmpty)
+					continue
+				}
+				b.WriteString("=" + encodeUriTemplateValue(pair[1], op.allowReserved))
+			}
+		}
+	}
+	return nil
+}
+
+// uriTemplateValue converts a variable to a string, []string or [][2]string, returning false if it is undefined.
+func uriTemplateValue(value any) (any, bool) {
+	switch value := value.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return value, true
+	case []string:
+		return value, len(value) > 0
+	case map[string]string:
+		pairs := make([][2]string, 0, len(value))
+		for k, v := range value {
+			pairs = append(pairs, [2]string{k, v})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+		return pairs, len(pairs) > 0
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return items, len(items) > 0
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			pairs := make([][2]string, 0, v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				pairs = append(pairs, [2]string{iter.Key().String(), fmt.Sprint(iter.Value().Interface())})
+			}
+			sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+			return pairs, len(pairs) > 0
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, false
+		}
+		return uriTemplateValue(v.Elem().Interface())
+	}
+	return fmt.Sprint(value), true
+}
+
+// encodeUriTemplateValue percent-encodes all but the unreserved characters, and if allowReserved is true,
+// also leaves the reserved characters and existing percent-encoded triplets as they are.
+func encodeUriTemplateValue(value string, allowReserved bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case isUriUnreserved(c):
+			b.WriteByte(c)
+		case allowReserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case allowReserved && c == '%' && i+2 < len(value) && isHexDigit(value[i+1]) && isHexDigit(value[i+2]):
+			b.WriteString(value[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isUriUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'A' <= c && c <= 'F' || 'a' <= c && c <= 'f'
+}
+
+// compilePattern builds a regular expression which matches the URIs that the template expands to.
+//
+// Matching is the reverse of expansion, which is ambiguous in general, so some simplifications are made:
+//   - in expressions with several variables, each value is assumed not to contain the separator
+//   - the query string produced by {?...} and {&...} expressions is parsed as a whole, so parameters may be in any order.
+//     Parameters which are not named after a variable are the associative array of an exploded variable,
+//     and the URI does not match if there is no such variable
+//   - other exploded associative arrays are not extracted
+func (t *UriTemplate) compilePattern() error {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, part := range t.parts {
+		if part.expression == nil {
+			pattern.WriteString(regexp.QuoteMeta(part.literal))
+			continue
+		}
+
+		op := part.expression.operator
+		varspecs := part.expression.varspecs
+		switch {
+		case op.symbol == '?' || op.symbol == '&':
+			pattern.WriteString("(" + regexp.QuoteMeta(op.first) + "[^#]*)?")
+			t.captures = append(t.captures, uriTemplateCapture{operator: op})
+			t.queryVars = append(t.queryVars, varspecs...)
+
+		case op.named:
+			for i := range varspecs {
+				name := regexp.QuoteMeta(op.sep + varspecs[i].name)
+				// each item of an exploded list is named, so the value is matched as that of a single item
+				value := uriTemplateValuePattern(op, uriTemplateVarspec{name: varspecs[i].name, prefix: varspecs[i].prefix}, true)
+				if varspecs[i].explode {
+					pattern.WriteString("((?:" + name + "(?:=" + value + ")?)+)?")
+				} else {
+					pattern.WriteString("(" + name + "(?:=" + value + ")?)?")
+				}
+				t.captures = append(t.captures, uriTemplateCapture{operator: op, varspec: &varspecs[i]})
+			}
+
+		default:
+			for i := range varspecs {
+				prefix := op.sep
+				if i == 0 {
+					prefix = op.first
+				}
+				value := uriTemplateValuePattern(op, varspecs[i], len(varspecs) == 1 || (i == len(varspecs)-1 && varspecs[i].explode))
+				if prefix == "" {
+					pattern.WriteString("(" + value + ")")
+				} else {
+					pattern.WriteString("(?:" + regexp.QuoteMeta(prefix) + "(" + value + "))?")
+				}
+				t.captures = append(t.captures, uriTemplateCapture{operator: op, varspec: &varspecs[i]})
+			}
+		}
+	}
+	pattern.WriteString("$")
+
+	compiled, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return err
+	}
+	t.pattern = compiled
+	return nil
+}
+
+// uriTemplateValuePattern matches the value of a variable, as Expand() encodes it.
+//
+// Apart from the + and # operators, Expand() percent-encodes every character but the unreserved characters,
+// so other characters may only be separators: the commas of a list, or the separators and "=" of an exploded
+// list or associative array. If the variable can be followed by another variable of the same expression,
+// the value may not contain the separator of the expression.
+func uriTemplateValuePattern(op uriTemplateOperator, varspec uriTemplateVarspec, last bool) string {
+	var char string
+	if op.allowReserved {
+		excluded := "%"
+		if op.symbol == '+' {
+			excluded += "?#"
+		}
+		if !last {
+			excluded += op.sep
+		}
+		char = "[^" + regexp.QuoteMeta(excluded) + "]"
+	} else {
+		allowed := `A-Za-z0-9\-_~`
+		if op.sep != "." || last || varspec.explode {
+			allowed += `\.`
+		}
+		if op.sep != "," || last {
+			allowed += ","
+		}
+		if varspec.explode {
+			allowed += "=" + regexp.QuoteMeta(op.sep)
+		}
+		char = "[" + allowed + "]"
+	}
+
+	// lazy, so that a following expression such as {.ext} gets its share, eg: "report.pdf" matches {name}{.ext}.
+	// The length of a prefix is checked once the value has been decoded, as regexp limits repeat counts.
+	return "(?:" + char + "|%[0-9A-Fa-f]{2})*?"
+}
+
+// Match returns the values of the variables if the URI matches the template.
+// Values are percent-decoded. Variables which do not appear in the URI are omitted.
+func (t *UriTemplate) Match(uri string) (UriTemplateVariables, bool) {
+	match := t.pattern.FindStringSubmatchIndex(uri)
+	if match == nil {
+		return nil, false
+	}
+
+	variables := UriTemplateVariables{}
+	var query []string
+	for i, capture := range t.captures {
+		start, end := match[2*i+2], match[2*i+3]
+		if start < 0 {
+			continue
+		}
+		raw := uri[start:end]
+		op := capture.operator
+
+		switch {
+		case capture.varspec == nil:
+			query = append(query, strings.Split(raw[1:], "&")...)
+
+		case op.named:
+			var values []string
+			for _, param := range strings.Split(raw[1:], op.sep) {
+				_, value, _ := strings.Cut(param, "=")
+				values = append(values, value)
+			}
+			if !setUriTemplateVariable(variables, *capture.varspec, values) {
+				return nil, false
+			}
+
+		default:
+			values := []string{raw}
+			if capture.varspec.explode {
+				values = strings.Split(raw, op.sep)
+			}
+			if !setUriTemplateVariable(variables, *capture.varspec, values) {
+				return nil, false
+			}
+		}
+	}
+
+	if len(query) > 0 && !t.matchQuery(variables, query) {
+		return nil, false
+	}
+	return variables, true
+}
+
+// matchQuery sets the query variables from the parameters of the query string.
+func (t *UriTemplate) matchQuery(variables UriTemplateVariables, query []string) bool {
+	named := map[string]bool{}
+	var exploded []uriTemplateVarspec
+	for _, varspec := range t.queryVars {
+		named[varspec.name] = true
+		if varspec.explode {
+			exploded = append(exploded, varspec)
+		}
+	}
+
+	// parameters which are not named after a variable can only come from an exploded associative array
+	var unnamed bool
+	for _, param := range query {
+		if name, _, _ := strings.Cut(param, "="); !named[name] {
+			unnamed = true
+		}
+	}
+	if unnamed {
+		if len(exploded) != 1 {
+			return false
+		}
+		pairs := map[string]string{}
+		for _, param := range query {
+			name, value, _ := strings.Cut(param, "=")
+			if named[name] && name != exploded[0].name {
+				continue
+			}
+			decodedName, err := url.QueryUnescape(name)
+			if err != nil {
+				return false
+			}
+			if pairs[decodedName], err = url.PathUnescape(value); err != nil {
+				return false
+			}
+		}
+		variables[exploded[0].name] = pairs
+	}
+
+	for _, varspec := range t.queryVars {
+		if _, ok := variables[varspec.name]; ok {
+			continue
+		}
+		var values []string
+		for _, param := range query {
+			if name, value, _ := strings.Cut(param, "="); name == varspec.name {
+				values = append(values, value)
+			}
+		}
+		if len(values) > 0 && !setUriTemplateVariable(variables, varspec, values) {
+			return false
+		}
+	}
+	return true
+}
+
+func setUriTemplateVariable(variables UriTemplateVariables, varspec uriTemplateVarspec, values []string) bool {
+	decoded := make([]string, len(values))
+	for i, value := range values {
+		var err error
+		if decoded[i], err = url.PathUnescape(value); err != nil {
+			return false
+		}
+	}
+	if varspec.explode {
+		variables[varspec.name] = decoded
+		return true
+	}
+	if varspec.prefix > 0 && utf8.RuneCountInString(decoded[0]) > varspec.prefix {
+		return false
+	}
+	variables[varspec.name] = decoded[0]
+	return true
+}
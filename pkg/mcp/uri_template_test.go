@@ -0,0 +1,284 @@
+package mcp_test
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/nalbion/go-mcp/pkg/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUriTemplate(t *testing.T) {
+	// the variables from the examples in RFC 6570 section 3.2
+	variables := map[string]any{
+		"count":      []string{"one", "two", "three"},
+		"dom":        []string{"example", "com"},
+		"dub":        "me/too",
+		"hello":      "Hello World!",
+		"half":       "50%",
+		"var":        "value",
+		"who":        "fred",
+		"base":       "http://example.com/home/",
+		"path":       "/foo/bar",
+		"list":       []string{"red", "green", "blue"},
+		"keys":       map[string]string{"semi": ";", "dot": ".", "comma": ","},
+		"v":          "6",
+		"x":          1024,
+		"y":          768,
+		"empty":      "",
+		"empty_keys": map[string]string{},
+		"undef":      nil,
+	}
+
+	t.Run("should expand the examples from RFC 6570", func(t *testing.T) {
+		examples := map[string]string{
+			// level 1
+			"{var}":   "value",
+			"{hello}": "Hello%20World%21",
+			// level 2
+			"{+var}":           "value",
+			"{+hello}":         "Hello%20World!",
+			"{+path}/here":     "/foo/bar/here",
+			"here?ref={+path}": "here?ref=/foo/bar",
+			"X{#var}":          "X#value",
+			"X{#hello}":        "X#Hello%20World!",
+			"{half}":           "50%25",
+			"{+half}":          "50%25",
+			"{base}index":      "http%3A%2F%2Fexample.com%2Fhome%2Findex",
+			"{+base}index":     "http://example.com/home/index",
+			// level 3
+			"map?{x,y}":           "map?1024,768",
+			"{x,hello,y}":         "1024,Hello%20World%21,768",
+			"{+x,hello,y}":        "1024,Hello%20World!,768",
+			"{+path,x}/here":      "/foo/bar,1024/here",
+			"{#x,hello,y}":        "#1024,Hello%20World!,768",
+			"{#path,x}/here":      "#/foo/bar,1024/here",
+			"X{.var}":             "X.value",
+			"X{.x,y}":             "X.1024.768",
+			"{/var}":              "/value",
+			"{/var,x}/here":       "/value/1024/here",
+			"{;x,y}":              ";x=1024;y=768",
+			"{;x,y,empty}":        ";x=1024;y=768;empty",
+			"{?x,y}":              "?x=1024&y=768",
+			"{?x,y,empty}":        "?x=1024&y=768&empty=",
+			"?fixed=yes{&x}":      "?fixed=yes&x=1024",
+			"{&x,y,empty}":        "&x=1024&y=768&empty=",
+			"{var,undef}":         "value",
+			"{?undef,empty_keys}": "",
+			// level 4
+			"{var:3}":         "val",
+			"{var:30}":        "value",
+			"{list}":          "red,green,blue",
+			"{list*}":         "red,green,blue",
+			"{keys}":          "comma,%2C,dot,.,semi,%3B",
+			"{keys*}":         "comma=%2C,dot=.,semi=%3B",
+			"{+path:6}/here":  "/foo/b/here",
+			"{+list}":         "red,green,blue",
+			"{+keys*}":        "comma=,,dot=.,semi=;",
+			"{#list*}":        "#red,green,blue",
+			"X{.list}":        "X.red,green,blue",
+			"X{.list*}":       "X.red.green.blue",
+			"{/var:1,var}":    "/v/value",
+			"{/list*}":        "/red/green/blue",
+			"{/list*,path:4}": "/red/green/blue/%2Ffoo",
+			"{;hello:5}":      ";hello=Hello",
+			"{;list}":         ";list=red,green,blue",
+			"{;list*}":        ";list=red;list=green;list=blue",
+			"{;keys*}":        ";comma=%2C;dot=.;semi=%3B",
+			"{?var:3}":        "?var=val",
+			"{?list}":         "?list=red,green,blue",
+			"{?list*}":        "?list=red&list=green&list=blue",
+			"{?keys}":         "?keys=comma,%2C,dot,.,semi,%3B",
+			"{&var:3}":        "&var=val",
+			"{&list*}":        "&list=red&list=green&list=blue",
+			"{&keys*}":        "&comma=%2C&dot=.&semi=%3B",
+			"{count}":         "one,two,three",
+			"{/count*}":       "/one/two/three",
+			"{dom*}.example":  "example,com.example",
+			"{dub}":           "me%2Ftoo",
+		}
+		for template, expected := range examples {
+			// when
+			expanded, err := mcp.MustParseUriTemplate(template).Expand(variables)
+
+			// then
+			require.NoError(t, err, template)
+			assert.Equal(t, expected, expanded, template)
+		}
+	})
+
+	t.Run("should reject invalid templates", func(t *testing.T) {
+		for _, template := range []string{"{", "}", "{}", "{=var}", "{var:0}", "{var:10000}", "{var name}", "{-var}", "a b{var}"} {
+			// when
+			_, err := mcp.ParseUriTemplate(template)
+
+			// then
+			assert.Error(t, err, template)
+		}
+	})
+
+	t.Run("should not apply a prefix to a list", func(t *testing.T) {
+		// when
+		_, err := mcp.MustParseUriTemplate("{list:2}").Expand(variables)
+
+		// then
+		assert.ErrorContains(t, err, "a prefix cannot be applied to the list value of list")
+	})
+
+	t.Run("should match URIs and decode the variables", func(t *testing.T) {
+		examples := []struct {
+			template  string
+			uri       string
+			variables mcp.UriTemplateVariables
+		}{
+			{"users://{id}/profile", "users://42/profile", mcp.UriTemplateVariables{"id": "42"}},
+			{"file:///{+path}", "file:///home/user/notes%20v2.txt", mcp.UriTemplateVariables{"path": "home/user/notes v2.txt"}},
+			{"repo://{owner}/{repo}{/path*}", "repo://nalbion/go-mcp/pkg/mcp", mcp.UriTemplateVariables{"owner": "nalbion", "repo": "go-mcp", "path": []string{"pkg", "mcp"}}},
+			{"search://items{?q,limit}", "search://items?limit=10&q=red%20shoes", mcp.UriTemplateVariables{"q": "red shoes", "limit": "10"}},
+			{"search://items{?q,limit}", "search://items?q=shoes", mcp.UriTemplateVariables{"q": "shoes"}},
+			{"search://items{?tag*}", "search://items?tag=a&tag=b", mcp.UriTemplateVariables{"tag": []string{"a", "b"}}},
+			{"map://{x,y}", "map://1024,768", mcp.UriTemplateVariables{"x": "1024", "y": "768"}},
+			{"doc://{name}{.ext}", "doc://report.pdf", mcp.UriTemplateVariables{"name": "report", "ext": "pdf"}},
+			{"matrix://{;x,y}", "matrix://;x=1;y", mcp.UriTemplateVariables{"x": "1", "y": ""}},
+			{"page://{id}{#section}", "page://7#intro", mcp.UriTemplateVariables{"id": "7", "section": "intro"}},
+		}
+		for _, example := range examples {
+			// when
+			variables, ok := mcp.MustParseUriTemplate(example.template).Match(example.uri)
+
+			// then
+			require.True(t, ok, example.template)
+			assert.Equal(t, example.variables, variables, example.template)
+		}
+	})
+
+	t.Run("should not match other URIs", func(t *testing.T) {
+		template := mcp.MustParseUriTemplate("users://{id}/profile")
+
+		for _, uri := range []string{"users://42/settings", "users://42/profile/extra", "users://a/b/profile", "groups://42/profile"} {
+			// when
+			_, ok := template.Match(uri)
+
+			// then
+			assert.False(t, ok, uri)
+		}
+	})
+
+	t.Run("should match the URIs it expands to", func(t *testing.T) {
+		// given
+		template := mcp.MustParseUriTemplate("repo://{owner}/{repo}/blob{/path*}{?ref}")
+		uri, err := template.Expand(map[string]any{"owner": "nalbion", "repo": "go mcp", "path": []string{"pkg", "mcp.go"}, "ref": "main"})
+		require.NoError(t, err)
+
+		// when
+		variables, ok := template.Match(uri)
+
+		// then
+		require.True(t, ok)
+		assert.Equal(t, "repo://nalbion/go%20mcp/blob/pkg/mcp.go?ref=main", uri)
+		assert.Equal(t, "go mcp", variables.Get("repo"))
+		assert.Equal(t, []string{"pkg", "mcp.go"}, variables["path"])
+		assert.Equal(t, []string{"owner", "repo", "path", "ref"}, template.VariableNames())
+	})
+
+	t.Run("should match an exploded associative array in the query", func(t *testing.T) {
+		// given
+		template := mcp.MustParseUriTemplate("search://items{?q,filter*}")
+		uri, err := template.Expand(map[string]any{"q": "shoes", "filter": map[string]string{"filter": "new", "size": "9 1/2"}})
+		require.NoError(t, err)
+
+		// when
+		variables, ok := template.Match(uri)
+
+		// then
+		require.True(t, ok)
+		assert.Equal(t, "search://items?q=shoes&filter=new&size=9%201%2F2", uri)
+		assert.Equal(t, mcp.UriTemplateVariables{"q": "shoes", "filter": map[string]string{"filter": "new", "size": "9 1/2"}}, variables)
+	})
+
+	t.Run("should not match query parameters which are not in the template", func(t *testing.T) {
+		// when
+		_, ok := mcp.MustParseUriTemplate("search://items{?q,limit}").Match("search://items?q=shoes&page=2")
+
+		// then
+		assert.False(t, ok)
+	})
+
+	t.Run("should not match values longer than the prefix", func(t *testing.T) {
+		template := mcp.MustParseUriTemplate("users://{name:3}")
+
+		// when
+		variables, ok := template.Match("users://%C3%A9ve")
+		_, tooLong := template.Match("users://abcd")
+
+		// then
+		require.True(t, ok)
+		assert.Equal(t, "éve", variables.Get("name"))
+		assert.False(t, tooLong)
+	})
+
+	t.Run("should match values with large prefixes", func(t *testing.T) {
+		for _, prefix := range []int{334, 999, 1000, 9999} {
+			// given
+			template, err := mcp.ParseUriTemplate(fmt.Sprintf("x://{a:%d}", prefix))
+			require.NoError(t, err, prefix)
+			value := strings.Repeat("é", prefix)
+
+			// when
+			variables, ok := template.Match("x://" + url.PathEscape(value))
+			_, tooLong := template.Match("x://" + url.PathEscape(value+"a"))
+
+			// then
+			require.True(t, ok, prefix)
+			assert.Equal(t, value, variables.Get("a"), prefix)
+			assert.False(t, tooLong, prefix)
+		}
+	})
+
+	t.Run("should not match reserved characters which Expand encodes", func(t *testing.T) {
+		for template, uri := range map[string]string{
+			"users://{id}":     "users://a;b=c",
+			"users://{id}/":    "users://a@b/",
+			"doc://{name}{.x}": "doc://a$b.pdf",
+			"repo://{/path*}":  "repo:///a/b:c",
+			"matrix://{;x}":    "matrix://;x=a=b",
+		} {
+			// when
+			_, ok := mcp.MustParseUriTemplate(template).Match(uri)
+
+			// then
+			assert.False(t, ok, template)
+		}
+	})
+
+	t.Run("should match the URIs it expands to for each operator", func(t *testing.T) {
+		examples := []struct {
+			template  string
+			variables mcp.UriTemplateVariables
+		}{
+			{"users://{id}", mcp.UriTemplateVariables{"id": "a;b=c"}},
+			{"users://{id:3}/", mcp.UriTemplateVariables{"id": "a&b"}},
+			{"doc://{name}{.ext}", mcp.UriTemplateVariables{"name": "a=b", "ext": "tar.gz"}},
+			{"repo://{/path*}", mcp.UriTemplateVariables{"path": []string{"a;b", "c=d"}}},
+			{"matrix://{;x,y}", mcp.UriTemplateVariables{"x": "a;b", "y": "c=d"}},
+			{"search://items{?tags*}", mcp.UriTemplateVariables{"tags": map[string]string{"a": "1", "b": "2"}}},
+			{"file:///{+path}", mcp.UriTemplateVariables{"path": "a/b;c=d"}},
+		}
+		for _, example := range examples {
+			// given
+			template := mcp.MustParseUriTemplate(example.template)
+			uri, err := template.Expand(example.variables)
+			require.NoError(t, err, example.template)
+
+			// when
+			variables, ok := template.Match(uri)
+
+			// then
+			require.True(t, ok, uri)
+			assert.Equal(t, example.variables, variables, uri)
+		}
+	})
+}
@@ -2,48 +2,199 @@ package sse
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
-
-	"github.com/r3labs/sse"
 )
 
+// DEFAULT_RECONNECTION_TIME is used when neither the caller nor the server (via `retry:`) specify one.
+const DEFAULT_RECONNECTION_TIME = 3 * time.Second
+
+// ClientSSESession reads events from a text/event-stream endpoint, reconnecting with the
+// Last-Event-ID header whenever the stream ends, until Close() is called or the server
+// refuses the connection.
 type ClientSSESession struct {
-	client  *sse.Client
-	events  chan *sse.Event
+	client           *http.Client
+	url              *url.URL
+	reconnectionTime time.Duration
+	requestBuilder   func(req *http.Request)
+
+	events  chan *ServerSentEvent
+	errors  chan error
+	opened  chan struct{}
 	context context.Context
 	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu          sync.Mutex
+	lastEventID string
+	connections int
 }
 
 func NewClientSSESession(client *http.Client, url *url.URL, reconnectionTime time.Duration, requestBuilder func(req *http.Request)) *ClientSSESession {
 	ctx, cancel := context.WithCancel(context.Background())
-	sseClient := sse.NewClient(url.String())
-	sseClient.Connection = client
-	// sseClient.ReconnectStrategy = reconnectionTime
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if reconnectionTime <= 0 {
+		reconnectionTime = DEFAULT_RECONNECTION_TIME
+	}
 
 	session := &ClientSSESession{
-		client:  sseClient,
-		events:  make(chan *sse.Event),
-		context: ctx,
-		cancel:  cancel,
+		client:           client,
+		url:              url,
+		reconnectionTime: reconnectionTime,
+		requestBuilder:   requestBuilder,
+		events:           make(chan *ServerSentEvent),
+		errors:           make(chan error, 1),
+		opened:           make(chan struct{}, 1),
+		context:          ctx,
+		cancel:           cancel,
+		done:             make(chan struct{}),
 	}
 
 	go session.listen()
 	return session
 }
 
-func (s *ClientSSESession) listen() {
-	s.client.SubscribeWithContext(s.context, "", func(event *sse.Event) {
-		s.events <- event
-	})
+// Incoming delivers each event received from the server. The channel is closed when the session ends.
+func (s *ClientSSESession) Incoming() <-chan *ServerSentEvent {
+	return s.events
 }
 
-func (s *ClientSSESession) Incoming() <-chan *sse.Event {
-	return s.events
+// Errors reports the error that ended the session, if it did not end because of Close().
+func (s *ClientSSESession) Errors() <-chan error {
+	return s.errors
+}
+
+// Opened receives a value each time a connection (or reconnection) to the server is established.
+func (s *ClientSSESession) Opened() <-chan struct{} {
+	return s.opened
+}
+
+// LastEventID returns the ID of the last event received, which is sent as Last-Event-ID on reconnection.
+func (s *ClientSSESession) LastEventID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastEventID
+}
+
+// Connections returns the number of times the session has connected to the server.
+func (s *ClientSSESession) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
 }
 
 func (s *ClientSSESession) Close() {
 	s.cancel()
-	close(s.events)
+	<-s.done
+}
+
+func (s *ClientSSESession) listen() {
+	defer close(s.done)
+	defer close(s.events)
+
+	for {
+		err := s.connect()
+		if s.context.Err() != nil {
+			return
+		}
+
+		var fatal *fatalError
+		if errors.As(err, &fatal) {
+			s.errors <- fatal.err
+			return
+		}
+
+		select {
+		case <-s.context.Done():
+			return
+		case <-time.After(s.reconnectionTime):
+		}
+	}
+}
+
+// connect opens the stream and reads events until it ends.
+// Errors which should not be retried are wrapped in fatalError.
+func (s *ClientSSESession) connect() error {
+	req, err := http.NewRequestWithContext(s.context, http.MethodGet, s.url.String(), nil)
+	if err != nil {
+		return &fatalError{err}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.requestBuilder != nil {
+		s.requestBuilder(req)
+	}
+	if lastEventID := s.LastEventID(); lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// network errors are retried
+		return err
+	}
+	defer resp.Body.Close()
+
+	// "HTTP 204 No Content" tells the client to stop reconnecting
+	if resp.StatusCode == http.StatusNoContent {
+		return &fatalError{errors.New("server closed the event stream")}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &fatalError{fmt.Errorf("unexpected status connecting to event stream: %s", resp.Status)}
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		return &fatalError{fmt.Errorf("unexpected content type for event stream: %s", resp.Header.Get("Content-Type"))}
+	}
+
+	s.mu.Lock()
+	s.connections++
+	s.mu.Unlock()
+
+	select {
+	case s.opened <- struct{}{}:
+	default:
+	}
+
+	reader := NewEventReader(resp.Body)
+	reader.SetLastEventID(s.LastEventID())
+
+	for {
+		event, err := reader.ReadEvent()
+
+		s.mu.Lock()
+		s.lastEventID = reader.LastEventID()
+		s.mu.Unlock()
+		if retry := reader.Retry(); retry != nil {
+			s.reconnectionTime = time.Duration(*retry) * time.Millisecond
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		select {
+		case <-s.context.Done():
+			return s.context.Err()
+		case s.events <- event:
+		}
+	}
+}
+
+type fatalError struct {
+	err error
+}
+
+func (e *fatalError) Error() string {
+	return e.err.Error()
 }
@@ -0,0 +1,120 @@
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveEvent(t *testing.T, session *ClientSSESession) *ServerSentEvent {
+	t.Helper()
+	select {
+	case event := <-session.Incoming():
+		require.NotNil(t, event)
+		return event
+	case err := <-session.Errors():
+		t.Fatalf("session failed: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return nil
+}
+
+func TestClientSSESession(t *testing.T) {
+	t.Run("should reconnect with Last-Event-ID after the stream ends", func(t *testing.T) {
+		// given a server which sends one event per connection and then closes the stream
+		var mu sync.Mutex
+		var lastEventIDs []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+			count := len(lastEventIDs)
+			mu.Unlock()
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "retry: 10\nid: %d\ndata: event %d\n\n", count, count)
+		}))
+		defer server.Close()
+
+		serverUrl, _ := url.Parse(server.URL)
+		session := NewClientSSESession(server.Client(), serverUrl, time.Minute, nil)
+		defer session.Close()
+
+		// when we receive events across connections
+		first := receiveEvent(t, session)
+		second := receiveEvent(t, session)
+
+		// then the retry sent by the server is used and the last event ID is sent on reconnection
+		assert.Equal(t, "event 1", *first.Data)
+		assert.Equal(t, "event 2", *second.Data)
+		assert.Equal(t, "2", session.LastEventID())
+		mu.Lock()
+		assert.Equal(t, []string{"", "1"}, lastEventIDs[:2])
+		mu.Unlock()
+		assert.GreaterOrEqual(t, session.Connections(), 2)
+	})
+
+	t.Run("should apply the request builder", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: %s\n\n", r.Header.Get("Authorization"))
+		}))
+		defer server.Close()
+
+		serverUrl, _ := url.Parse(server.URL)
+		session := NewClientSSESession(server.Client(), serverUrl, time.Minute, func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer token")
+		})
+		defer session.Close()
+
+		event := receiveEvent(t, session)
+
+		assert.Equal(t, "Bearer token", *event.Data)
+	})
+
+	t.Run("should stop reconnecting when the server responds 204", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		serverUrl, _ := url.Parse(server.URL)
+		session := NewClientSSESession(server.Client(), serverUrl, 10*time.Millisecond, nil)
+		defer session.Close()
+
+		select {
+		case err := <-session.Errors():
+			assert.EqualError(t, err, "server closed the event stream")
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for error")
+		}
+		_, open := <-session.Incoming()
+		assert.False(t, open)
+	})
+
+	t.Run("should fail on an unexpected content type", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte("{}"))
+		}))
+		defer server.Close()
+
+		serverUrl, _ := url.Parse(server.URL)
+		session := NewClientSSESession(server.Client(), serverUrl, 10*time.Millisecond, nil)
+		defer session.Close()
+
+		select {
+		case err := <-session.Errors():
+			assert.Contains(t, err.Error(), "unexpected content type")
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for error")
+		}
+	})
+}
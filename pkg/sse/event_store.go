@@ -0,0 +1,140 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var ErrEventNotFound = errors.New("event not found")
+
+// EventStore keeps a history of the events sent on each stream so that a client which reconnects
+// with a Last-Event-ID header can be sent the events that it missed.
+// Implementations may be shared between server instances (eg: backed by Redis) as long as the
+// event IDs they generate identify the stream they belong to.
+type EventStore interface {
+	// StoreEvent records the data sent on a stream and returns the ID to send with the event.
+	StoreEvent(streamID string, data string) (string, error)
+	// StreamIDForEventID returns the stream that an event ID was issued for.
+	StreamIDForEventID(eventID string) (string, error)
+	// ReplayEventsAfter calls send, in order, for each event stored after lastEventID on the same stream.
+	// Returns ErrEventNotFound if lastEventID is unknown or has already been evicted.
+	ReplayEventsAfter(lastEventID string, send func(eventID string, data string) error) error
+	// RemoveStream discards the history of a stream which can no longer be resumed.
+	RemoveStream(streamID string) error
+}
+
+type storedEvent struct {
+	sequence int
+	data     string
+}
+
+type eventStream struct {
+	nextSequence int
+	events       []storedEvent
+}
+
+// DEFAULT_MAX_EVENTS is the number of events an InMemoryEventStore keeps for each stream if `maxEvents` is not positive.
+const DEFAULT_MAX_EVENTS = 1000
+
+// InMemoryEventStore is an EventStore which keeps up to `maxEvents` of the most recent events for each stream,
+// or DEFAULT_MAX_EVENTS if `maxEvents` is not positive. Event IDs are of the form `<streamID>_<sequence>`.
+type InMemoryEventStore struct {
+	maxEvents int
+	streams   map[string]*eventStream
+	mu        sync.Mutex
+}
+
+func NewInMemoryEventStore(maxEvents int) *InMemoryEventStore {
+	if maxEvents <= 0 {
+		maxEvents = DEFAULT_MAX_EVENTS
+	}
+	return &InMemoryEventStore{
+		maxEvents: maxEvents,
+		streams:   make(map[string]*eventStream),
+	}
+}
+
+func (s *InMemoryEventStore) StoreEvent(streamID string, data string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, ok := s.streams[streamID]
+	if !ok {
+		stream = &eventStream{nextSequence: 1}
+		s.streams[streamID] = stream
+	}
+
+	sequence := stream.nextSequence
+	stream.nextSequence++
+	stream.events = append(stream.events, storedEvent{sequence: sequence, data: data})
+	if len(stream.events) > s.maxEvents {
+		stream.events = stream.events[len(stream.events)-s.maxEvents:]
+	}
+
+	return formatEventID(streamID, sequence), nil
+}
+
+func (s *InMemoryEventStore) StreamIDForEventID(eventID string) (string, error) {
+	streamID, _, err := parseEventID(eventID)
+	return streamID, err
+}
+
+func (s *InMemoryEventStore) ReplayEventsAfter(lastEventID string, send func(eventID string, data string) error) error {
+	streamID, sequence, err := parseEventID(lastEventID)
+	if err != nil {
+		return err
+	}
+
+	// copy the events to be replayed so that `send` is not called while holding the lock
+	s.mu.Lock()
+	stream, ok := s.streams[streamID]
+	if !ok || sequence >= stream.nextSequence {
+		s.mu.Unlock()
+		return ErrEventNotFound
+	}
+	if sequence < stream.events[0].sequence-1 {
+		// some of the events that the client missed have been evicted
+		s.mu.Unlock()
+		return ErrEventNotFound
+	}
+	var missed []storedEvent
+	for _, event := range stream.events {
+		if event.sequence > sequence {
+			missed = append(missed, event)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, event := range missed {
+		if err := send(formatEventID(streamID, event.sequence), event.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryEventStore) RemoveStream(streamID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, streamID)
+	return nil
+}
+
+func formatEventID(streamID string, sequence int) string {
+	return fmt.Sprintf("%s_%d", streamID, sequence)
+}
+
+func parseEventID(eventID string) (string, int, error) {
+	i := strings.LastIndex(eventID, "_")
+	if i < 0 {
+		return "", 0, fmt.Errorf("%w: invalid event ID %q", ErrEventNotFound, eventID)
+	}
+	sequence, err := strconv.Atoi(eventID[i+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: invalid event ID %q", ErrEventNotFound, eventID)
+	}
+	return eventID[:i], sequence, nil
+}
@@ -0,0 +1,110 @@
+package sse
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type replayedEvent struct {
+	id   string
+	data string
+}
+
+func replayAfter(t *testing.T, store EventStore, lastEventID string) ([]replayedEvent, error) {
+	t.Helper()
+	var replayed []replayedEvent
+	err := store.ReplayEventsAfter(lastEventID, func(eventID string, data string) error {
+		replayed = append(replayed, replayedEvent{eventID, data})
+		return nil
+	})
+	return replayed, err
+}
+
+func TestInMemoryEventStore(t *testing.T) {
+	t.Run("should issue sequential IDs per stream", func(t *testing.T) {
+		store := NewInMemoryEventStore(10)
+
+		first, err := store.StoreEvent("a", "1")
+		require.NoError(t, err)
+		second, _ := store.StoreEvent("a", "2")
+		other, _ := store.StoreEvent("b", "1")
+
+		assert.Equal(t, "a_1", first)
+		assert.Equal(t, "a_2", second)
+		assert.Equal(t, "b_1", other)
+
+		streamID, err := store.StreamIDForEventID(other)
+		require.NoError(t, err)
+		assert.Equal(t, "b", streamID)
+	})
+
+	t.Run("should replay only the events after the last event ID on the same stream", func(t *testing.T) {
+		store := NewInMemoryEventStore(10)
+		store.StoreEvent("a", "1")
+		store.StoreEvent("b", "x")
+		store.StoreEvent("a", "2")
+		store.StoreEvent("a", "3")
+
+		replayed, err := replayAfter(t, store, "a_1")
+
+		require.NoError(t, err)
+		assert.Equal(t, []replayedEvent{{"a_2", "2"}, {"a_3", "3"}}, replayed)
+	})
+
+	t.Run("should replay nothing if the client is up to date", func(t *testing.T) {
+		store := NewInMemoryEventStore(10)
+		store.StoreEvent("a", "1")
+
+		replayed, err := replayAfter(t, store, "a_1")
+
+		require.NoError(t, err)
+		assert.Empty(t, replayed)
+	})
+
+	t.Run("should fail if missed events have been evicted", func(t *testing.T) {
+		store := NewInMemoryEventStore(2)
+		store.StoreEvent("a", "1")
+		store.StoreEvent("a", "2")
+		store.StoreEvent("a", "3")
+		store.StoreEvent("a", "4")
+
+		_, err := replayAfter(t, store, "a_1")
+		assert.ErrorIs(t, err, ErrEventNotFound)
+
+		// but the oldest retained event's predecessor can still be resumed from
+		replayed, err := replayAfter(t, store, "a_2")
+		require.NoError(t, err)
+		assert.Equal(t, []replayedEvent{{"a_3", "3"}, {"a_4", "4"}}, replayed)
+	})
+
+	t.Run("should keep DEFAULT_MAX_EVENTS if maxEvents is not positive", func(t *testing.T) {
+		for _, maxEvents := range []int{0, -1} {
+			store := NewInMemoryEventStore(maxEvents)
+			for i := 0; i < DEFAULT_MAX_EVENTS+2; i++ {
+				store.StoreEvent("a", fmt.Sprint(i))
+			}
+
+			_, err := replayAfter(t, store, "a_1")
+			assert.ErrorIs(t, err, ErrEventNotFound, maxEvents)
+
+			replayed, err := replayAfter(t, store, "a_2")
+			require.NoError(t, err)
+			assert.Len(t, replayed, DEFAULT_MAX_EVENTS, maxEvents)
+		}
+	})
+
+	t.Run("should fail for unknown or removed streams", func(t *testing.T) {
+		store := NewInMemoryEventStore(10)
+		store.StoreEvent("a", "1")
+		require.NoError(t, store.RemoveStream("a"))
+
+		_, err := replayAfter(t, store, "a_1")
+		assert.ErrorIs(t, err, ErrEventNotFound)
+
+		_, err = replayAfter(t, store, "garbage")
+		assert.ErrorIs(t, err, ErrEventNotFound)
+	})
+}
@@ -0,0 +1,165 @@
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EventReader parses a text/event-stream into ServerSentEvents.
+// see https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+type EventReader struct {
+	reader      *bufio.Reader
+	started     bool
+	skipLF      bool
+	lastEventID string
+	retry       *int
+}
+
+func NewEventReader(r io.Reader) *EventReader {
+	return &EventReader{
+		reader: bufio.NewReader(r),
+	}
+}
+
+// LastEventID returns the most recent `id` received on the stream, even if the event it was sent with
+// carried no data. This is the value to send in the Last-Event-ID header when reconnecting.
+func (r *EventReader) LastEventID() string {
+	return r.lastEventID
+}
+
+// SetLastEventID seeds the reader with the ID from a previous connection.
+func (r *EventReader) SetLastEventID(id string) {
+	r.lastEventID = id
+}
+
+// Retry returns the reconnection time in milliseconds most recently requested by the server, or nil.
+func (r *EventReader) Retry() *int {
+	return r.retry
+}
+
+// ReadEvent blocks until a complete event has been received and returns it.
+// Blocks that carry no data (such as keep-alive comments) update the reader's state but are not returned.
+// An incomplete event at the end of the stream is discarded and io.EOF is returned.
+func (r *EventReader) ReadEvent() (*ServerSentEvent, error) {
+	var data strings.Builder
+	var comments strings.Builder
+	var eventType string
+	hasData := false
+	hasComments := false
+
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if line == "" {
+			// dispatch the event
+			if !hasData {
+				eventType = ""
+				hasComments = false
+				comments.Reset()
+				continue
+			}
+
+			event := NewServerSentEvent().WithData(strings.TrimSuffix(data.String(), "\n"))
+			if eventType == "" {
+				eventType = "message"
+			}
+			event.WithEvent(eventType)
+			if r.lastEventID != "" {
+				event.WithID(r.lastEventID)
+			}
+			if r.retry != nil {
+				event.WithRetry(*r.retry)
+			}
+			if hasComments {
+				event.WithComments(strings.TrimSuffix(comments.String(), "\n"))
+			}
+			return event, nil
+		}
+
+		if line[0] == ':' {
+			hasComments = true
+			comments.WriteString(strings.TrimPrefix(line[1:], " "))
+			comments.WriteByte('\n')
+			continue
+		}
+
+		field, value, found := strings.Cut(line, ":")
+		if found {
+			value = strings.TrimPrefix(value, " ")
+		}
+
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			hasData = true
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			// ids containing NULL are ignored, as required by the spec
+			if !strings.ContainsRune(value, 0) {
+				r.lastEventID = value
+			}
+		case "retry":
+			if isASCIIDigits(value) {
+				if retry, err := strconv.Atoi(value); err == nil {
+					r.retry = &retry
+				}
+			}
+		default:
+			// unknown fields are ignored
+		}
+	}
+}
+
+// readLine reads a line terminated by CRLF, LF or CR and returns it without the terminator.
+func (r *EventReader) readLine() (string, error) {
+	var line bytes.Buffer
+	for {
+		b, err := r.reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		if r.skipLF {
+			// the LF of a CRLF pair may arrive in a later chunk than the CR
+			r.skipLF = false
+			if b == '\n' {
+				continue
+			}
+		}
+
+		switch b {
+		case '\n':
+			return r.stripBOM(line.String()), nil
+		case '\r':
+			r.skipLF = true
+			return r.stripBOM(line.String()), nil
+		default:
+			line.WriteByte(b)
+		}
+	}
+}
+
+func (r *EventReader) stripBOM(line string) string {
+	if !r.started {
+		r.started = true
+		return strings.TrimPrefix(line, "\uFEFF")
+	}
+	return line
+}
+
+func isASCIIDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
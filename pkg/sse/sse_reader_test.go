@@ -0,0 +1,134 @@
+package sse
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventReader_ReadEvent(t *testing.T) {
+	t.Run("should read a single-line event", func(t *testing.T) {
+		reader := NewEventReader(strings.NewReader("data: Hello, world!\n\n"))
+
+		// when
+		event, err := reader.ReadEvent()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "Hello, world!", *event.Data)
+		assert.Equal(t, "message", *event.Event)
+		assert.Nil(t, event.ID)
+	})
+
+	t.Run("should join multi-line data", func(t *testing.T) {
+		reader := NewEventReader(strings.NewReader("data: Hello,\ndata: world!\n\n"))
+
+		event, err := reader.ReadEvent()
+
+		require.NoError(t, err)
+		assert.Equal(t, "Hello,\nworld!", *event.Data)
+	})
+
+	t.Run("should read event name, id and retry", func(t *testing.T) {
+		reader := NewEventReader(strings.NewReader("id: 12345\nretry: 3000\nevent: greeting\ndata: Hello\n\n"))
+
+		event, err := reader.ReadEvent()
+
+		require.NoError(t, err)
+		assert.Equal(t, "greeting", *event.Event)
+		assert.Equal(t, "12345", *event.ID)
+		assert.Equal(t, 3000, *event.Retry)
+		assert.Equal(t, "12345", reader.LastEventID())
+	})
+
+	t.Run("should round-trip events written by ServerSSESession", func(t *testing.T) {
+		session, reader := NewServerSSESession(&SSESessionOptions{Buffered: true})
+		require.NoError(t, session.Send(NewServerSentEvent().WithComments("hi").WithID("1").WithEvent("endpoint").WithData("/messages")))
+		require.NoError(t, session.Send(NewServerSentEvent().WithID("2").WithData("{\n\"a\": 1\n}")))
+
+		eventReader := NewEventReader(reader)
+		first, err := eventReader.ReadEvent()
+		require.NoError(t, err)
+		second, err := eventReader.ReadEvent()
+		require.NoError(t, err)
+
+		assert.Equal(t, "endpoint", *first.Event)
+		assert.Equal(t, "/messages", *first.Data)
+		assert.Equal(t, "hi", *first.Comments)
+		assert.Equal(t, "{\n\"a\": 1\n}", *second.Data)
+		assert.Equal(t, "2", *second.ID)
+	})
+
+	t.Run("should accept CR and CRLF line endings", func(t *testing.T) {
+		reader := NewEventReader(strings.NewReader("data: a\r\ndata: b\r\rdata: c\r\n\r\n"))
+
+		first, err := reader.ReadEvent()
+		require.NoError(t, err)
+		second, err := reader.ReadEvent()
+		require.NoError(t, err)
+
+		assert.Equal(t, "a\nb", *first.Data)
+		assert.Equal(t, "c", *second.Data)
+	})
+
+	t.Run("should not split a CRLF that arrives in separate reads", func(t *testing.T) {
+		reader := NewEventReader(io.MultiReader(strings.NewReader("data: a\r"), strings.NewReader("\ndata: b\n\n")))
+
+		event, err := reader.ReadEvent()
+
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb", *event.Data)
+	})
+
+	t.Run("should skip comments and blocks without data", func(t *testing.T) {
+		reader := NewEventReader(strings.NewReader(": keep-alive\n\nid: 7\n\nevent: ignored\n\ndata: x\n\n"))
+
+		event, err := reader.ReadEvent()
+
+		require.NoError(t, err)
+		assert.Equal(t, "x", *event.Data)
+		assert.Equal(t, "message", *event.Event)
+		// the id from the data-less block still applies
+		assert.Equal(t, "7", *event.ID)
+	})
+
+	t.Run("should handle fields without a colon or space", func(t *testing.T) {
+		reader := NewEventReader(strings.NewReader("data\ndata:x\n\n"))
+
+		event, err := reader.ReadEvent()
+
+		require.NoError(t, err)
+		assert.Equal(t, "\nx", *event.Data)
+	})
+
+	t.Run("should ignore invalid retry and ids containing NULL", func(t *testing.T) {
+		reader := NewEventReader(strings.NewReader("id: 1\n\nid: a\x00b\nretry: 1s\ndata: x\n\n"))
+
+		event, err := reader.ReadEvent()
+
+		require.NoError(t, err)
+		assert.Equal(t, "1", *event.ID)
+		assert.Nil(t, event.Retry)
+	})
+
+	t.Run("should strip a leading BOM", func(t *testing.T) {
+		reader := NewEventReader(strings.NewReader("\uFEFFdata: x\n\n"))
+
+		event, err := reader.ReadEvent()
+
+		require.NoError(t, err)
+		assert.Equal(t, "x", *event.Data)
+	})
+
+	t.Run("should discard an incomplete event at EOF", func(t *testing.T) {
+		reader := NewEventReader(strings.NewReader("data: x\n"))
+
+		event, err := reader.ReadEvent()
+
+		assert.Nil(t, event)
+		assert.ErrorIs(t, err, io.EOF)
+	})
+}